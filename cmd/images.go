@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+var (
+	imagesCmd = &cobra.Command{
+		Use:   "images",
+		Short: "Lists every image referenced by the UpdatePayload with its component.",
+		Long:  "",
+		Run:   runImagesCmd,
+	}
+
+	imagesOpts struct {
+		payloadDir   string
+		releaseImage string
+		output       string
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(imagesCmd)
+	imagesCmd.PersistentFlags().StringVar(&imagesOpts.payloadDir, "payload-dir", payload.DefaultPayloadDir, "The directory the UpdatePayload was extracted to.")
+	imagesCmd.PersistentFlags().StringVar(&imagesOpts.releaseImage, "release-image", "", "The Openshift release image url, if known.")
+	imagesCmd.PersistentFlags().StringVarP(&imagesOpts.output, "output", "o", "", "Output format. One of: json. Defaults to a table of component and image.")
+}
+
+func runImagesCmd(cmd *cobra.Command, args []string) {
+	flag.Set("logtostderr", "true")
+	flag.Parse()
+
+	update, err := payload.LoadUpdate(imagesOpts.payloadDir, imagesOpts.releaseImage, os.Getenv("EXCLUDE_MANIFESTS"), clusterProfile(), nil)
+	if err != nil {
+		klog.Fatalf("Unable to load the UpdatePayload: %v", err)
+	}
+	images := payload.ImageReferences(update)
+	switch imagesOpts.output {
+	case "json":
+		data, err := json.MarshalIndent(images, "", "  ")
+		if err != nil {
+			klog.Fatalf("Unable to encode images: %v", err)
+		}
+		fmt.Println(string(data))
+	case "":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "COMPONENT\tIMAGE\tMANIFESTS")
+		for _, image := range images {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", image.Component, image.Image, strings.Join(image.Manifests, ","))
+		}
+		w.Flush()
+	default:
+		klog.Fatalf("unrecognized --output %q", imagesOpts.output)
+	}
+}
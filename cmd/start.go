@@ -35,5 +35,24 @@ func init() {
 	cmd.PersistentFlags().StringVar(&opts.ReleaseImage, "release-image", opts.ReleaseImage, "The Openshift release image url.")
 	cmd.PersistentFlags().StringVar(&opts.ServingCertFile, "serving-cert-file", opts.ServingCertFile, "The X.509 certificate file for serving metrics over HTTPS.  You must set both --serving-cert-file and --serving-key-file, or neither.")
 	cmd.PersistentFlags().StringVar(&opts.ServingKeyFile, "serving-key-file", opts.ServingKeyFile, "The X.509 key file for serving metrics over HTTPS.  You must set both --serving-cert-file and --serving-key-file, or neither.")
+	cmd.PersistentFlags().BoolVar(&opts.EnableStandbyVerification, "enable-standby-verification", opts.EnableStandbyVerification, "While not the leader, periodically verify the release manifests against the cluster without writing to it.")
+	cmd.PersistentFlags().BoolVar(&opts.EnableUpdateRehearsal, "enable-update-rehearsal", opts.EnableUpdateRehearsal, "Rehearse updates proposed in the cluster-version-rehearsal ConfigMap, recording what would happen without changing the ClusterVersion.")
+	cmd.PersistentFlags().DurationVar(&opts.SyncWorkerStallTimeout, "sync-worker-stall-timeout", opts.SyncWorkerStallTimeout, "How long the sync worker may make no progress despite pending work before goroutine stacks are logged and the SyncWorkerStalled condition is set. Zero disables the check.")
+	cmd.PersistentFlags().BoolVar(&opts.RestartStalledSyncWorker, "restart-stalled-sync-worker", opts.RestartStalledSyncWorker, "Cancel the sync attempt of a stalled sync worker so that it starts over.")
+	cmd.PersistentFlags().StringToStringVar(&opts.RunLevelKubeconfigs, "run-level-kubeconfig", opts.RunLevelKubeconfigs, "Apply the manifests of a run level through an alternate API endpoint, as RUNLEVEL=KUBECONFIG pairs such as 05=/etc/kubernetes/bootstrap.kubeconfig. May be repeated.")
+	cmd.PersistentFlags().StringVar(&opts.OwnershipIdentity, "ownership-identity", opts.OwnershipIdentity, "Stamp applied resources with this identity, and refuse to overwrite resources owned by a different identity unless they carry a release.openshift.io/takeover annotation naming this one.")
+	cmd.PersistentFlags().BoolVar(&opts.ParallelClusterOperatorWaits, "parallel-cluster-operator-waits", opts.ParallelClusterOperatorWaits, "During updates, initiate every component of a run level before waiting for its ClusterOperators in parallel.")
+	cmd.PersistentFlags().StringSliceVar(&opts.ToleratedClusterOperators, "tolerated-cluster-operators", opts.ToleratedClusterOperators, "ClusterOperators that may be unavailable or degraded when an update begins. Others must be Available=True and not Degraded=True unless the update is forced.")
+	cmd.PersistentFlags().BoolVar(&opts.Preflight, "preflight", opts.Preflight, "Before beginning an update, submit the release manifests with server-side dry-run and refuse the update, unless forced, if any are rejected.")
+	cmd.PersistentFlags().Float64Var(&opts.SlowOperatorFactor, "slow-operator-factor", opts.SlowOperatorFactor, "Report ClusterOperators that have been updating for more than this many times the 90th percentile of their earlier update durations. Set to 0 to disable.")
+	cmd.PersistentFlags().DurationVar(&opts.ClusterOperatorWaitTimeout, "cluster-operator-wait-timeout", opts.ClusterOperatorWaitTimeout, "How long updates wait for a ClusterOperator before they are reported as failing. The release.openshift.io/wait-timeout annotation on a ClusterOperator manifest overrides it. Zero waits indefinitely.")
+	cmd.PersistentFlags().DurationVar(&opts.ClusterOperatorStuckTimeout, "cluster-operator-stuck-timeout", opts.ClusterOperatorStuckTimeout, "How long a ClusterOperator may go without changing its versions or conditions during an update before it is reported as stuck and the update as failing, for example 30m. Zero, the default, disables the check.")
+	cmd.PersistentFlags().StringVar(&opts.ClusterOperatorWaitEvents, "cluster-operator-wait-events", opts.ClusterOperatorWaitEvents, "Where to record the events of ClusterOperator waits: ClusterVersion, ClusterOperator to show them with oc describe clusteroperator, or OperatorNamespace to also record them in the namespace of the operator's related objects.")
+	cmd.PersistentFlags().StringSliceVar(&opts.DisabledCapabilities, "disabled-capabilities", opts.DisabledCapabilities, "Capabilities whose manifests, named by their capability.openshift.io/name annotation, are not applied. Disabled manifests are reported by the ManifestsDisabled condition.")
+	cmd.PersistentFlags().StringSliceVar(&opts.RollbackTriggers, "rollback-trigger", opts.RollbackTriggers, "Roll a failing update back to the release the cluster updated from, by changing the desired update to it, once a failure persists, as REASON[@RUNLEVEL]=DURATION such as ClusterOperatorDegraded@10=30m. May be repeated.")
+	cmd.PersistentFlags().BoolVar(&opts.ReleaseSignaturePrecondition, "release-signature-precondition", opts.ReleaseSignaturePrecondition, "Before beginning an update, check that the release image is signed by a key the payload or the cluster-version-signature-bundle ConfigMap in openshift-config trusts. Releases signed only by untrusted keys cannot be forced.")
+	cmd.PersistentFlags().BoolVar(&opts.PodDisruptionBudgetPrecondition, "pod-disruption-budget-precondition", opts.PodDisruptionBudgetPrecondition, "Before beginning an update, check that no PodDisruptionBudget protecting pods on control plane nodes allows no disruptions, which would block draining those nodes.")
+	cmd.PersistentFlags().BoolVar(&opts.UpdateCheckpoints, "update-checkpoints", opts.UpdateCheckpoints, "Record the progress of updates in the cluster-version-checkpoint ConfigMap, so that an operator restarted during an update skips the manifests it already applied and resumes its ClusterOperator waits.")
+	cmd.PersistentFlags().StringVar(&opts.StatusWebhookURL, "status-webhook-url", opts.StatusWebhookURL, "An optional URL that receives a JSON document describing the sync status whenever it changes.")
 	rootCmd.AddCommand(cmd)
 }
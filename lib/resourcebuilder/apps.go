@@ -77,7 +77,7 @@ func (b *builder) modifyDeployment(ctx context.Context, deployment *appsv1.Deplo
 }
 
 func (b *builder) checkDeploymentHealth(ctx context.Context, deployment *appsv1.Deployment) error {
-	if b.mode == InitializingMode {
+	if b.mode == InitializingMode || b.mode == DryRunMode {
 		return nil
 	}
 
@@ -154,7 +154,7 @@ func (b *builder) modifyDaemonSet(ctx context.Context, daemonset *appsv1.DaemonS
 }
 
 func (b *builder) checkDaemonSetHealth(ctx context.Context, daemonset *appsv1.DaemonSet) error {
-	if b.mode == InitializingMode {
+	if b.mode == InitializingMode || b.mode == DryRunMode {
 		return nil
 	}
 
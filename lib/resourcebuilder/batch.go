@@ -26,7 +26,7 @@ func WaitForJobCompletion(ctx context.Context, client batchclientv1.JobsGetter,
 }
 
 func (b *builder) checkJobHealth(ctx context.Context, job *batchv1.Job) error {
-	if b.mode == InitializingMode {
+	if b.mode == InitializingMode || b.mode == DryRunMode {
 		return nil
 	}
 
@@ -1,6 +1,11 @@
 package resourcebuilder
 
-import "k8s.io/client-go/rest"
+import (
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
 
 // withProtobuf makes a client use protobuf.
 func withProtobuf(config *rest.Config) *rest.Config {
@@ -9,3 +14,29 @@ func withProtobuf(config *rest.Config) *rest.Config {
 	config.ContentType = "application/vnd.kubernetes.protobuf"
 	return config
 }
+
+// DryRunConfig returns a copy of config whose writes are submitted with server-side dry-run,
+// so that they are validated and admitted without being persisted. Use it with DryRunMode.
+func DryRunConfig(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &dryRunRoundTripper{delegate: rt}
+	})
+	return config
+}
+
+type dryRunRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func (rt *dryRunRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return rt.delegate.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set("dryRun", metav1.DryRunAll)
+	req.URL.RawQuery = query.Encode()
+	return rt.delegate.RoundTrip(req)
+}
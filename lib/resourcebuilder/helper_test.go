@@ -0,0 +1,44 @@
+package resourcebuilder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestDryRunConfig(t *testing.T) {
+	requests := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.Method] = r.URL.Query().Get("dryRun")
+	}))
+	defer server.Close()
+
+	config := &rest.Config{Host: server.URL}
+	transport, err := rest.TransportFor(DryRunConfig(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport}
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		req, err := http.NewRequest(method, server.URL+"/api/v1/namespaces?fieldManager=test", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	expected := map[string]string{http.MethodGet: "", http.MethodPost: "All", http.MethodPut: "All", http.MethodPatch: "All", http.MethodDelete: "All"}
+	for method, dryRun := range expected {
+		if requests[method] != dryRun {
+			t.Errorf("%s requested with dryRun=%q, expected %q", method, requests[method], dryRun)
+		}
+	}
+	if config.WrapTransport != nil {
+		t.Error("the original config was modified")
+	}
+}
@@ -72,6 +72,9 @@ const (
 	ReconcilingMode
 	InitializingMode
 	PrecreatingMode
+	// DryRunMode submits objects through a DryRunConfig client without waiting for them to
+	// become healthy.
+	DryRunMode
 )
 
 type Interface interface {
@@ -0,0 +1,118 @@
+package resourcebuilder
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+const (
+	// WaitForConditionAnnotation lists conditions, as a comma separated list of
+	// <type>=<status> pairs, that an object must report in status.conditions
+	// before the manifest is considered applied. For example "Available=True".
+	WaitForConditionAnnotation = "release.openshift.io/wait-for-condition"
+
+	// WaitForPathAnnotation lists fields, as a comma separated list of
+	// <path>=<value> pairs, that must have the expected value before the
+	// manifest is considered applied. Paths are dotted field references in
+	// JSONPath style, such as "{.status.phase}=Ready" or ".status.phase=Ready".
+	// Only field access is supported, not filters or array indexes.
+	WaitForPathAnnotation = "release.openshift.io/wait-for-path"
+)
+
+// HasWaitConditions returns true if the object declares any readiness expectations.
+func HasWaitConditions(obj *unstructured.Unstructured) bool {
+	annotations := obj.GetAnnotations()
+	return len(annotations[WaitForConditionAnnotation]) > 0 || len(annotations[WaitForPathAnnotation]) > 0
+}
+
+// CheckWaitConditions returns an error if actual does not yet satisfy the readiness
+// expectations declared by the annotations on required. Like the other health checks,
+// it does not poll; the caller retries until the expectations are met.
+func CheckWaitConditions(required, actual *unstructured.Unstructured) error {
+	annotations := required.GetAnnotations()
+	iden := actual.GetName()
+	if ns := actual.GetNamespace(); len(ns) > 0 {
+		iden = fmt.Sprintf("%s/%s", ns, iden)
+	}
+	iden = fmt.Sprintf("%s %s", strings.ToLower(actual.GetKind()), iden)
+
+	var unmet []string
+	conditions, err := parseWaitPairs(annotations[WaitForConditionAnnotation])
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation on %s: %v", WaitForConditionAnnotation, iden, err)
+	}
+	for _, c := range conditions {
+		if status, ok := conditionStatus(actual, c.key); !ok || status != c.value {
+			unmet = append(unmet, fmt.Sprintf("condition %s is %q, waiting for %q", c.key, status, c.value))
+		}
+	}
+	paths, err := parseWaitPairs(annotations[WaitForPathAnnotation])
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation on %s: %v", WaitForPathAnnotation, iden, err)
+	}
+	for _, p := range paths {
+		fields := strings.Split(strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(p.key, "{"), "}"), "."), ".")
+		value, ok, err := unstructured.NestedFieldNoCopy(actual.Object, fields...)
+		if err != nil {
+			return fmt.Errorf("invalid %s annotation on %s: %v", WaitForPathAnnotation, iden, err)
+		}
+		got := ""
+		if ok && value != nil {
+			got = fmt.Sprint(value)
+		}
+		if got != p.value {
+			unmet = append(unmet, fmt.Sprintf("%s is %q, waiting for %q", p.key, got, p.value))
+		}
+	}
+
+	if len(unmet) == 0 {
+		return nil
+	}
+	return &payload.UpdateError{
+		Nested:  fmt.Errorf("%s is not ready: %s", iden, strings.Join(unmet, ", ")),
+		Reason:  "ResourceNotReady",
+		Message: fmt.Sprintf("%s is not ready", iden),
+		Name:    iden,
+	}
+}
+
+type waitPair struct {
+	key   string
+	value string
+}
+
+func parseWaitPairs(s string) ([]waitPair, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	var pairs []waitPair
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		i := strings.LastIndex(item, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("%q is not of the form <key>=<value>", item)
+		}
+		pairs = append(pairs, waitPair{key: strings.TrimSpace(item[:i]), value: strings.TrimSpace(item[i+1:])})
+	}
+	return pairs, nil
+}
+
+func conditionStatus(obj *unstructured.Unstructured, conditionType string) (string, bool) {
+	conditions, ok, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !ok {
+		return "", false
+	}
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		return status, true
+	}
+	return "", false
+}
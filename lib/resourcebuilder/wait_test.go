@@ -0,0 +1,94 @@
+package resourcebuilder
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCheckWaitConditions(t *testing.T) {
+	actual := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"name":      "test",
+			"namespace": "default",
+		},
+		"status": map[string]interface{}{
+			"phase":    "Ready",
+			"replicas": int64(3),
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "True"},
+				map[string]interface{}{"type": "Degraded", "status": "False"},
+			},
+		},
+	}}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     string
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name:        "conditions met",
+			annotations: map[string]string{WaitForConditionAnnotation: "Available=True, Degraded=False"},
+		},
+		{
+			name:        "condition not met",
+			annotations: map[string]string{WaitForConditionAnnotation: "Degraded=True"},
+			wantErr:     `widget default/test is not ready: condition Degraded is "False", waiting for "True"`,
+		},
+		{
+			name:        "condition missing",
+			annotations: map[string]string{WaitForConditionAnnotation: "Upgradeable=True"},
+			wantErr:     `widget default/test is not ready: condition Upgradeable is "", waiting for "True"`,
+		},
+		{
+			name:        "paths met",
+			annotations: map[string]string{WaitForPathAnnotation: "{.status.phase}=Ready,.status.replicas=3"},
+		},
+		{
+			name:        "path not met",
+			annotations: map[string]string{WaitForPathAnnotation: "{.status.phase}=Done"},
+			wantErr:     `widget default/test is not ready: {.status.phase} is "Ready", waiting for "Done"`,
+		},
+		{
+			name:        "path missing",
+			annotations: map[string]string{WaitForPathAnnotation: ".status.other=x"},
+			wantErr:     `widget default/test is not ready: .status.other is "", waiting for "x"`,
+		},
+		{
+			name:        "invalid annotation",
+			annotations: map[string]string{WaitForConditionAnnotation: "Available"},
+			wantErr:     `invalid release.openshift.io/wait-for-condition annotation on widget default/test: "Available" is not of the form <key>=<value>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			required := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			required.SetAnnotations(tt.annotations)
+			if got, want := HasWaitConditions(required), len(tt.annotations) > 0; got != want {
+				t.Errorf("HasWaitConditions() = %t, want %t", got, want)
+			}
+			err := CheckWaitConditions(required, actual)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tt.wantErr)
+			}
+			msg := err.Error()
+			if uerr, ok := err.(interface{ Unwrap() error }); ok && uerr.Unwrap() != nil {
+				msg = uerr.Unwrap().Error()
+			}
+			if msg != tt.wantErr {
+				t.Fatalf("unexpected error:\n%s\n%s", msg, tt.wantErr)
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	configinformersv1 "github.com/openshift/client-go/config/informers/externalversions/config/v1"
 	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
 	"github.com/openshift/cluster-version-operator/lib/resourceapply"
+	"github.com/openshift/cluster-version-operator/pkg/internal"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -124,7 +125,9 @@ func (ctrl *Controller) processNextWorkItem(ctx context.Context) bool {
 	}
 	defer ctrl.queue.Done(key)
 
+	start := time.Now()
 	err := ctrl.syncHandler(ctx, key.(string))
+	internal.ObserveReconcile("autoupdater", start, err)
 	ctrl.handleErr(err, key)
 
 	return true
@@ -0,0 +1,153 @@
+package cvo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+// updateAuditConfigMap holds an UpdateAudit for ClusterVersion status.history entries, keyed by
+// the entry's start time in seconds since the epoch. The history API has no room for them.
+const updateAuditConfigMap = "cluster-version-update-audit"
+
+// UpdateAudit records how the sync worker accepted a release, so that how a version got onto
+// a cluster can be audited after the related events have expired.
+type UpdateAudit struct {
+	Version string `json:"version,omitempty"`
+	Image   string `json:"image"`
+	// Verification is Signature if the release signature was verified, Local if the release
+	// is the operator's own, or None.
+	Verification  string              `json:"verification"`
+	Force         bool                `json:"force"`
+	Preconditions PreconditionSummary `json:"preconditions"`
+	// Waivers are the administrator overrides that let precondition failures pass.
+	Waivers []PreconditionWaiver `json:"waivers,omitempty"`
+	// Preflight is Passed, Failed, or Overridden if the release was submitted with server-side
+	// dry-run before the update began.
+	Preflight string `json:"preflight,omitempty"`
+}
+
+// PreconditionSummary counts precondition results for an UpdateAudit.
+type PreconditionSummary struct {
+	// Skipped is true if preconditions were not run, as for the operator's own release.
+	Skipped    bool `json:"skipped,omitempty"`
+	Passed     int  `json:"passed"`
+	Overridden int  `json:"overridden"`
+	Failed     int  `json:"failed"`
+	Waived     int  `json:"waived,omitempty"`
+	// Advisory counts failures with a severity that does not block the update.
+	Advisory int `json:"advisory,omitempty"`
+}
+
+// PreconditionWaiver records a waived precondition failure for an UpdateAudit.
+type PreconditionWaiver struct {
+	Precondition string    `json:"precondition"`
+	RequestedBy  string    `json:"requestedBy"`
+	Reason       string    `json:"reason,omitempty"`
+	Expires      time.Time `json:"expires"`
+	// Bypassed describes the conditions the waiver allowed the update past.
+	Bypassed []string `json:"bypassed,omitempty"`
+}
+
+func newPreconditionWaiver(waiver *precondition.Waiver) PreconditionWaiver {
+	return PreconditionWaiver{
+		Precondition: waiver.Name,
+		RequestedBy:  waiver.RequestedBy,
+		Reason:       waiver.Reason,
+		Expires:      waiver.Expires.UTC(),
+		Bypassed:     waiver.Bypassed,
+	}
+}
+
+func verificationMethod(info PayloadInfo) string {
+	switch {
+	case info.Local:
+		return "Local"
+	case info.Verified:
+		return "Signature"
+	default:
+		return "None"
+	}
+}
+
+// setUpdateAudit remembers audit until it can be recorded for its history entry.
+func (optr *Operator) setUpdateAudit(audit UpdateAudit) {
+	optr.auditLock.Lock()
+	defer optr.auditLock.Unlock()
+	optr.pendingAudit = &audit
+}
+
+// syncUpdateAudit records the pending audit for the most recent history entry, if it is for
+// the same release. Failures are reported and retried on the next status sync.
+func (optr *Operator) syncUpdateAudit(ctx context.Context, history []configv1.UpdateHistory) {
+	optr.auditLock.Lock()
+	defer optr.auditLock.Unlock()
+	audit := optr.pendingAudit
+	if audit == nil || len(history) == 0 || history[0].Image != audit.Image || optr.kubeClient == nil {
+		return
+	}
+	data, err := json.Marshal(audit)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to encode the update audit: %v", err))
+		return
+	}
+	key := strconv.FormatInt(history[0].StartedTime.Unix(), 10)
+	if optr.recordedAudit == key+"="+string(data) {
+		return
+	}
+	if err := optr.writeUpdateAudit(ctx, key, string(data), history); err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to record the update audit for %s: %v", audit.Image, err))
+		return
+	}
+	optr.recordedAudit = key + "=" + string(data)
+}
+
+// writeUpdateAudit stores value under key, dropping audits of entries no longer in history. An
+// audit already stored under key is kept, so the first audit of an entry is the one recorded.
+func (optr *Operator) writeUpdateAudit(ctx context.Context, key, value string, history []configv1.UpdateHistory) error {
+	client := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace)
+	cm, err := client.Get(ctx, updateAuditConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: optr.namespace, Name: updateAuditConfigMap},
+			Data:       map[string]string{key: value},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	started := make(map[string]struct{}, len(history))
+	for _, entry := range history {
+		started[strconv.FormatInt(entry.StartedTime.Unix(), 10)] = struct{}{}
+	}
+	if existing, ok := cm.Data[key]; ok {
+		if existing != value {
+			klog.V(2).Infof("Not replacing the update audit already recorded for the update started at %s", key)
+		}
+		return nil
+	}
+	cm = cm.DeepCopy()
+	for k := range cm.Data {
+		if _, ok := started[k]; !ok {
+			delete(cm.Data, k)
+		}
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[key] = value
+	_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
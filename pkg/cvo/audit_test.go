@@ -0,0 +1,130 @@
+package cvo
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func TestOperator_syncUpdateAudit(t *testing.T) {
+	ctx := context.Background()
+	started := func(seconds int64) metav1.Time { return metav1.NewTime(time.Unix(seconds, 0)) }
+	kubeClient := kfake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-cluster-version", Name: updateAuditConfigMap},
+		Data:       map[string]string{"100": `{"image":"image/image:0"}`, "200": `{"image":"image/image:1"}`},
+	})
+	optr := &Operator{namespace: "openshift-cluster-version", kubeClient: kubeClient}
+	history := []configv1.UpdateHistory{
+		{State: configv1.PartialUpdate, Image: "image/image:2", StartedTime: started(300)},
+		{State: configv1.CompletedUpdate, Image: "image/image:1", StartedTime: started(200)},
+	}
+	read := func() map[string]string {
+		cm, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, updateAuditConfigMap, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cm.Data
+	}
+
+	// an audit for another release is not recorded against the latest entry
+	optr.setUpdateAudit(UpdateAudit{Image: "image/image:3"})
+	optr.syncUpdateAudit(ctx, history)
+	if got := read(); len(got) != 2 {
+		t.Fatalf("unexpected audits: %v", got)
+	}
+
+	optr.setUpdateAudit(UpdateAudit{
+		Version:       "4.8.2",
+		Image:         "image/image:2",
+		Verification:  "Signature",
+		Force:         true,
+		Preconditions: PreconditionSummary{Passed: 1, Overridden: 1},
+	})
+	optr.syncUpdateAudit(ctx, history)
+	want := map[string]string{
+		"200": `{"image":"image/image:1"}`,
+		"300": `{"version":"4.8.2","image":"image/image:2","verification":"Signature","force":true,"preconditions":{"passed":1,"overridden":1,"failed":0}}`,
+	}
+	if got := read(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected audits:\n%v\n%v", got, want)
+	}
+
+	// unchanged audits are not written again
+	kubeClient.ClearActions()
+	optr.syncUpdateAudit(ctx, history)
+	if actions := kubeClient.Actions(); len(actions) != 0 {
+		t.Fatalf("unexpected actions: %v", actions)
+	}
+}
+
+type discardStatusReporter struct{}
+
+func (discardStatusReporter) Report(SyncWorkerStatus) {}
+
+func TestSyncWorker_recordAuditAcrossRestart(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := kfake.NewSimpleClientset()
+	read := func() map[string]string {
+		cm, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, updateAuditConfigMap, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cm.Data
+	}
+	// load runs the worker of optr until the payload is loaded; applying it fails on the
+	// cancelled context, which is not under test
+	load := func(optr *Operator, info PayloadInfo, state payload.State) {
+		worker := NewSyncWorker(&fakeDirectoryRetriever{Info: info}, &testResourceBuilder{client: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())}, time.Second, wait.Backoff{Steps: 1}, "exclude-test", record.NewFakeRecorder(100), payload.DefaultClusterProfile)
+		worker.audit = optr.setUpdateAudit
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		work := &SyncWork{Desired: configv1.Update{Image: "image/image:1"}, State: state}
+		_ = worker.syncOnce(cancelled, work, 1, discardStatusReporter{}, &configv1.ClusterVersion{})
+	}
+	history := []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Image: "image/image:1", StartedTime: metav1.NewTime(time.Unix(100, 0))}}
+
+	optr := &Operator{namespace: "openshift-cluster-version", kubeClient: kubeClient}
+	load(optr, PayloadInfo{Directory: "testdata/payloadtest", Verified: true}, payload.UpdatingPayload)
+	optr.syncUpdateAudit(ctx, history)
+	want := read()
+	if !strings.Contains(want["100"], `"verification":"Signature"`) {
+		t.Fatalf("unexpected audits: %v", want)
+	}
+
+	// the operator restarted on the new release reloads it as its own, while reconciling
+	for _, info := range []PayloadInfo{
+		{Directory: "testdata/payloadtest", Local: true},
+		{Directory: "testdata/payloadtest", Verified: true},
+	} {
+		restarted := &Operator{namespace: "openshift-cluster-version", kubeClient: kubeClient}
+		load(restarted, info, payload.ReconcilingPayload)
+		if restarted.pendingAudit != nil {
+			t.Fatalf("unexpected audit of a reconcile load of %#v: %#v", info, restarted.pendingAudit)
+		}
+		restarted.syncUpdateAudit(ctx, history)
+		if got := read(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("unexpected audits after restart:\n%v\n%v", got, want)
+		}
+	}
+
+	// an audit already recorded for an entry is not replaced
+	restarted := &Operator{namespace: "openshift-cluster-version", kubeClient: kubeClient}
+	restarted.setUpdateAudit(UpdateAudit{Image: "image/image:1", Verification: "Local", Preconditions: PreconditionSummary{Skipped: true}})
+	restarted.syncUpdateAudit(ctx, history)
+	if got := read(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected audits after a replacement:\n%v\n%v", got, want)
+	}
+}
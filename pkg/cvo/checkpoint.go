@@ -0,0 +1,309 @@
+package cvo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	cvointernal "github.com/openshift/cluster-version-operator/pkg/cvo/internal"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// checkpointConfigMap in the operator's namespace records the progress of the update in
+// progress, so that an operator which restarts during the update can resume it.
+const checkpointConfigMap = "cluster-version-checkpoint"
+
+// updateCheckpoint records which manifests the update in progress has applied, which run
+// levels it has completed, and when it began waiting for the ClusterOperators it is waiting
+// on. An operator that restarts during the update skips the manifests recorded as applied,
+// if they have not changed, on its first attempt at the update, and resumes its waits where
+// the earlier operator left them. A nil updateCheckpoint records nothing.
+type updateCheckpoint struct {
+	// waits are the ClusterOperator waits of updates, which are resumed and recorded.
+	waits *cvointernal.ClusterOperatorWaits
+
+	lock  sync.Mutex
+	image string
+	// tasks are the hashes of the manifests that were applied, by manifest.
+	tasks map[string]string
+	// levels are the manifests of each run level.
+	levels map[string][]string
+	dirty  bool
+	// revision increases with each change, so that a change made while the checkpoint is
+	// being written is not mistaken for written.
+	revision int64
+	// recorded is set while the checkpoint ConfigMap may exist.
+	recorded bool
+
+	// loaded is the checkpoint recorded by an earlier operator, until it is resumed.
+	loaded *checkpointData
+	read   bool
+}
+
+// checkpointData is the content of the checkpoint ConfigMap.
+type checkpointData struct {
+	image     string
+	tasks     map[string]string
+	runLevels []string
+	waits     map[string]time.Time
+}
+
+// EnableUpdateCheckpoints records the progress of updates in the checkpoint ConfigMap, so
+// that an operator which restarts during an update skips the manifests it already applied and
+// resumes its ClusterOperator waits. It must be called before InitializeFromPayload.
+func (optr *Operator) EnableUpdateCheckpoints() {
+	optr.checkpoint = &updateCheckpoint{waits: optr.operatorWaits}
+}
+
+// checkpointKey identifies the manifest of task across releases.
+func checkpointKey(task *payload.Task) string {
+	m := task.Manifest
+	return fmt.Sprintf("%s/%s/%s/%s", m.GVK.Group, m.GVK.Kind, m.Obj.GetNamespace(), m.Obj.GetName())
+}
+
+// checkpointHash returns the hash of the manifest of task.
+func checkpointHash(task *payload.Task) string {
+	hash := fnv.New64()
+	hash.Write(task.Manifest.Raw)
+	return base64.URLEncoding.EncodeToString(hash.Sum(nil))
+}
+
+// begin starts recording the update to image, whose run levels apply the manifests levels
+// lists by checkpointKey. The first time the update is attempted after its checkpoint was
+// loaded, it returns the hashes of the manifests the earlier operator applied, which need not
+// be applied again, and resumes the earlier operator's ClusterOperator waits, returning when
+// each began.
+func (c *updateCheckpoint) begin(image string, levels map[string][]string) (map[string]string, map[string]time.Time) {
+	if c == nil {
+		return nil, nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.image != image {
+		c.image = image
+		c.tasks = map[string]string{}
+		c.dirty = true
+		c.revision++
+	}
+	c.levels = levels
+	loaded := c.loaded
+	c.loaded = nil
+	if loaded == nil || loaded.image != image {
+		return nil, nil
+	}
+	klog.V(2).Infof("Resuming the update to %s after the operator restarted, with %d manifests applied and run levels %s completed", image, len(loaded.tasks), strings.Join(loaded.runLevels, ", "))
+	c.waits.Resume(loaded.waits)
+	return loaded.tasks, loaded.waits
+}
+
+// succeeded records that the manifest of task was applied by the update to image.
+func (c *updateCheckpoint) succeeded(image string, task *payload.Task) {
+	if c == nil {
+		return
+	}
+	key, hash := checkpointKey(task), checkpointHash(task)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.image != image || c.tasks[key] == hash {
+		return
+	}
+	c.tasks[key] = hash
+	c.dirty = true
+	c.revision++
+}
+
+// runLevelsLocked returns the run levels whose manifests have all been applied.
+func (c *updateCheckpoint) runLevelsLocked() []string {
+	var levels []string
+	for level, keys := range c.levels {
+		if len(level) == 0 {
+			continue
+		}
+		complete := true
+		for _, key := range keys {
+			if _, ok := c.tasks[key]; !ok {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			levels = append(levels, level)
+		}
+	}
+	sort.Strings(levels)
+	return levels
+}
+
+// formatCheckpoint formats data as the data of the checkpoint ConfigMap.
+func formatCheckpoint(data *checkpointData) (map[string]string, error) {
+	tasks, err := json.Marshal(data.tasks)
+	if err != nil {
+		return nil, err
+	}
+	waits := make(map[string]string, len(data.waits))
+	for name, since := range data.waits {
+		waits[name] = since.UTC().Format(time.RFC3339)
+	}
+	waitData, err := json.Marshal(waits)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"image":     data.image,
+		"tasks":     string(tasks),
+		"runLevels": strings.Join(data.runLevels, ","),
+		"waits":     string(waitData),
+	}, nil
+}
+
+// parseCheckpoint parses the data of the checkpoint ConfigMap, returning nil if it does not
+// describe the progress of an update.
+func parseCheckpoint(data map[string]string) *checkpointData {
+	if len(data["image"]) == 0 {
+		klog.Warningf("Ignoring %s, which does not name the image of an update", checkpointConfigMap)
+		return nil
+	}
+	checkpoint := &checkpointData{image: data["image"]}
+	if err := json.Unmarshal([]byte(data["tasks"]), &checkpoint.tasks); err != nil {
+		klog.Warningf("Ignoring %s, which has invalid applied manifests: %v", checkpointConfigMap, err)
+		return nil
+	}
+	if len(data["runLevels"]) > 0 {
+		checkpoint.runLevels = strings.Split(data["runLevels"], ",")
+	}
+	var waits map[string]string
+	if err := json.Unmarshal([]byte(data["waits"]), &waits); err != nil {
+		klog.Warningf("Ignoring the invalid cluster operator waits in %s: %v", checkpointConfigMap, err)
+	}
+	checkpoint.waits = make(map[string]time.Time, len(waits))
+	for name, value := range waits {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			klog.Warningf("Ignoring the invalid start of the wait for cluster operator %s in %s: %v", name, checkpointConfigMap, err)
+			continue
+		}
+		checkpoint.waits[name] = since
+	}
+	return checkpoint
+}
+
+// loadCheckpoint loads the checkpoint recorded by an earlier operator, if it has not been
+// loaded.
+func (optr *Operator) loadCheckpoint(ctx context.Context) {
+	c := optr.checkpoint
+	c.lock.Lock()
+	read := c.read
+	c.lock.Unlock()
+	if read || optr.kubeClient == nil {
+		return
+	}
+	cm, err := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace).Get(ctx, checkpointConfigMap, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		utilruntime.HandleError(fmt.Errorf("unable to load the update checkpoint: %v", err))
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err == nil {
+		c.loaded = parseCheckpoint(cm.Data)
+		c.recorded = true
+	}
+	c.read = true
+}
+
+// syncCheckpoint records the progress of the update described by history while it is in
+// progress, and removes the checkpoint once it completes. The checkpoint is built under the
+// lock, and written after releasing it, so that recording progress does not wait on the API.
+func (optr *Operator) syncCheckpoint(ctx context.Context, history []configv1.UpdateHistory) {
+	c := optr.checkpoint
+	if c == nil || optr.kubeClient == nil || len(history) == 0 {
+		return
+	}
+	optr.loadCheckpoint(ctx)
+	client := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace)
+
+	c.lock.Lock()
+	if !c.read {
+		c.lock.Unlock()
+		return
+	}
+	if history[0].State == configv1.CompletedUpdate {
+		c.loaded = nil
+		c.tasks = nil
+		c.levels = nil
+		c.image = ""
+		c.dirty = false
+		recorded := c.recorded
+		c.lock.Unlock()
+		if !recorded {
+			return
+		}
+		if err := client.Delete(ctx, checkpointConfigMap, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			utilruntime.HandleError(fmt.Errorf("unable to remove the update checkpoint: %v", err))
+			return
+		}
+		c.lock.Lock()
+		if len(c.image) == 0 {
+			c.recorded = false
+		}
+		c.lock.Unlock()
+		return
+	}
+	// the update has not been resumed from the earlier checkpoint yet
+	if c.loaded != nil {
+		c.lock.Unlock()
+		return
+	}
+	started := c.waits.Started()
+	if c.image != history[0].Image || c.tasks == nil || (!c.dirty && len(started) == 0) {
+		c.lock.Unlock()
+		return
+	}
+	image, revision := c.image, c.revision
+	data, err := formatCheckpoint(&checkpointData{image: c.image, tasks: c.tasks, runLevels: c.runLevelsLocked(), waits: started})
+	c.lock.Unlock()
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to record the update checkpoint for %s: %v", image, err))
+		return
+	}
+
+	cm, err := client.Get(ctx, checkpointConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: optr.namespace, Name: checkpointConfigMap},
+			Data:       data,
+		}, metav1.CreateOptions{})
+	} else if err == nil && !reflect.DeepEqual(cm.Data, data) {
+		cm = cm.DeepCopy()
+		cm.Data = data
+		_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to record the update checkpoint for %s: %v", image, err))
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.image != image {
+		return
+	}
+	c.recorded = true
+	if c.revision == revision {
+		c.dirty = false
+	}
+}
@@ -0,0 +1,206 @@
+package cvo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
+	cvointernal "github.com/openshift/cluster-version-operator/pkg/cvo/internal"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func TestUpdateCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	newTask := func(filename, kind, name string) *payload.Task {
+		obj := &unstructured.Unstructured{}
+		obj.SetName(name)
+		return &payload.Task{Manifest: &manifest.Manifest{
+			OriginalFilename: filename,
+			Raw:              []byte(`{"kind":"` + kind + `","metadata":{"name":"` + name + `"}}`),
+			GVK:              configv1.SchemeGroupVersion.WithKind(kind),
+			Obj:              obj,
+		}}
+	}
+	config := newTask("0000_10_config-operator_01_config.yaml", "ConfigMap", "config")
+	network := newTask("0000_20_network_01_operator.yaml", "ClusterOperator", "network")
+	dns := newTask("0000_20_dns_01_operator.yaml", "ClusterOperator", "dns")
+	levels := map[string][]string{
+		"10": {checkpointKey(config)},
+		"20": {checkpointKey(network), checkpointKey(dns)},
+	}
+	image := "image/image:2"
+	partial := []configv1.UpdateHistory{{State: configv1.PartialUpdate, Image: image}}
+
+	kubeClient := kfake.NewSimpleClientset()
+	optr := &Operator{namespace: "openshift-cluster-version", kubeClient: kubeClient, operatorWaits: &cvointernal.ClusterOperatorWaits{}}
+	optr.EnableUpdateCheckpoints()
+	optr.loadCheckpoint(ctx)
+	if resumed, waiting := optr.checkpoint.begin(image, levels); resumed != nil || waiting != nil {
+		t.Fatalf("unexpected resume without a checkpoint: %v %v", resumed, waiting)
+	}
+	optr.checkpoint.succeeded(image, config)
+	optr.checkpoint.succeeded(image, network)
+	optr.syncCheckpoint(ctx, partial)
+
+	cm, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, checkpointConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoint := parseCheckpoint(cm.Data)
+	expected := &checkpointData{
+		image:     image,
+		tasks:     map[string]string{checkpointKey(config): checkpointHash(config), checkpointKey(network): checkpointHash(network)},
+		runLevels: []string{"10"},
+		waits:     map[string]time.Time{},
+	}
+	if !reflect.DeepEqual(checkpoint, expected) {
+		t.Fatalf("unexpected checkpoint %#v, expected %#v", checkpoint, expected)
+	}
+
+	// a restarted operator resumes the update once, from the recorded checkpoint and waits
+	since := time.Unix(1600000000, 0)
+	cm = cm.DeepCopy()
+	cm.Data["waits"] = `{"dns":"` + since.UTC().Format(time.RFC3339) + `"}`
+	if _, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	optr = &Operator{namespace: "openshift-cluster-version", kubeClient: kubeClient, operatorWaits: &cvointernal.ClusterOperatorWaits{}}
+	optr.EnableUpdateCheckpoints()
+	optr.loadCheckpoint(ctx)
+	resumed, waiting := optr.checkpoint.begin(image, levels)
+	if !reflect.DeepEqual(resumed, expected.tasks) {
+		t.Fatalf("unexpected resumed manifests %v, expected %v", resumed, expected.tasks)
+	}
+	if !reflect.DeepEqual(waiting, map[string]time.Time{"dns": since.UTC()}) {
+		t.Fatalf("unexpected resumed waits %v", waiting)
+	}
+	if resumed, waiting := optr.checkpoint.begin(image, levels); resumed != nil || waiting != nil {
+		t.Fatalf("unexpected second resume: %v %v", resumed, waiting)
+	}
+
+	// resumed manifests are recorded again as they are skipped
+	optr.checkpoint.succeeded(image, config)
+	optr.checkpoint.succeeded(image, network)
+	optr.checkpoint.succeeded(image, dns)
+	optr.syncCheckpoint(ctx, partial)
+	cm, err = kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, checkpointConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runLevels := cm.Data["runLevels"]; runLevels != "10,20" {
+		t.Fatalf("unexpected completed run levels %q", runLevels)
+	}
+
+	// the checkpoint is removed once the update completes
+	optr.syncCheckpoint(ctx, []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Image: image}})
+	if _, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, checkpointConfigMap, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the checkpoint to be removed, got %v", err)
+	}
+}
+
+func Test_SyncWorker_applyResumesCheckpoint(t *testing.T) {
+	var manifests []manifest.Manifest
+	for _, s := range []string{
+		`{"apiVersion": "test.cvo.io/v1", "kind": "TestA", "metadata": {"namespace": "default", "name": "testa"}}`,
+		`{"apiVersion": "test.cvo.io/v1", "kind": "TestB", "metadata": {"namespace": "default", "name": "testb"}}`,
+	} {
+		m := manifest.Manifest{}
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			t.Fatal(err)
+		}
+		manifests = append(manifests, m)
+	}
+	testa, testb := &payload.Task{Manifest: &manifests[0]}, &payload.Task{Manifest: &manifests[1]}
+	up := &payload.Update{
+		Release:   configv1.Release{Version: "v0.0.0", Image: "test"},
+		Manifests: manifests,
+	}
+
+	r := &recorder{}
+	testMapper := resourcebuilder.NewResourceMapper()
+	testMapper.RegisterGVK(schema.GroupVersionKind{Group: "test.cvo.io", Version: "v1", Kind: "TestA"}, newTestBuilder(r, map[action]error{}))
+	testMapper.RegisterGVK(schema.GroupVersionKind{Group: "test.cvo.io", Version: "v1", Kind: "TestB"}, newTestBuilder(r, map[action]error{}))
+	testMapper.AddToMap(resourcebuilder.Mapper)
+
+	// the earlier operator applied testa, and an older testb whose manifest has since changed
+	since := time.Unix(1600000000, 0).UTC()
+	checkpoint := &updateCheckpoint{
+		read: true,
+		loaded: &checkpointData{
+			image: "test",
+			tasks: map[string]string{checkpointKey(testa): checkpointHash(testa), checkpointKey(testb): "changed"},
+			waits: map[string]time.Time{"dns": since},
+		},
+	}
+	worker := &SyncWorker{
+		eventRecorder: record.NewFakeRecorder(100),
+		builder:       NewResourceBuilder(nil, nil, nil),
+		checkpoint:    checkpoint,
+		timings:       &operatorTimings{},
+	}
+	if err := worker.apply(context.Background(), up, &SyncWork{State: payload.UpdatingPayload, Desired: configv1.Update{Image: "test"}}, 1, &statusWrapper{w: worker, previousStatus: worker.Status()}); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []action{newAction(schema.GroupVersionKind{Group: "test.cvo.io", Version: "v1", Kind: "TestB"}, "default", "testb")}; !reflect.DeepEqual(r.actions, expected) {
+		t.Fatalf("unexpected actions %v, expected only the changed manifest to be applied", r.actions)
+	}
+	if expected := map[string]string{checkpointKey(testa): checkpointHash(testa), checkpointKey(testb): checkpointHash(testb)}; !reflect.DeepEqual(checkpoint.tasks, expected) {
+		t.Fatalf("unexpected recorded manifests %v, expected %v", checkpoint.tasks, expected)
+	}
+	if started := worker.timings.started["dns"]; !started.Equal(since) {
+		t.Fatalf("expected the wait for dns to resume from %s, got %s", since, started)
+	}
+
+	// later attempts at the update apply every manifest
+	r.actions = nil
+	if err := worker.apply(context.Background(), up, &SyncWork{State: payload.UpdatingPayload, Desired: configv1.Update{Image: "test"}}, 1, &statusWrapper{w: worker, previousStatus: worker.Status()}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.actions) != 2 {
+		t.Fatalf("unexpected actions %v, expected every manifest to be applied", r.actions)
+	}
+}
+
+func TestParseCheckpoint(t *testing.T) {
+	for _, data := range []map[string]string{
+		{},
+		{"image": "image/image:2", "tasks": "not json"},
+	} {
+		if checkpoint := parseCheckpoint(data); checkpoint != nil {
+			t.Errorf("unexpected checkpoint from %v: %#v", data, checkpoint)
+		}
+	}
+
+	checkpoint := parseCheckpoint(map[string]string{"image": "image/image:2", "tasks": "{}", "waits": `{"dns":"soon"}`})
+	if checkpoint == nil || len(checkpoint.waits) != 0 {
+		t.Fatalf("unexpected checkpoint: %#v", checkpoint)
+	}
+
+	// formatted checkpoints parse back to themselves
+	expected := &checkpointData{
+		image:     "image/image:2",
+		tasks:     map[string]string{"/ConfigMap//config": "hash"},
+		runLevels: []string{"10", "20"},
+		waits:     map[string]time.Time{"dns": time.Unix(1600000000, 0).UTC()},
+	}
+	data, err := formatCheckpoint(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint := parseCheckpoint(data); !reflect.DeepEqual(checkpoint, expected) {
+		t.Fatalf("unexpected checkpoint %#v, expected %#v", checkpoint, expected)
+	}
+}
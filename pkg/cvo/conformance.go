@@ -0,0 +1,175 @@
+package cvo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/cvo/internal/dynamicclient"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// conformanceGetFunc retrieves the in-cluster object for a manifest.
+type conformanceGetFunc func(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, error)
+
+// conformanceVerifier compares the manifests of the local release image with the cluster
+// without writing to it.
+type conformanceVerifier struct {
+	update *payload.Update
+	get    conformanceGetFunc
+}
+
+// conformanceFinding is a manifest whose in-cluster object is missing or differs from it.
+type conformanceFinding struct {
+	manifest *manifest.Manifest
+	message  string
+}
+
+func (f conformanceFinding) String() string {
+	return fmt.Sprintf("%s %s", describeManifest(f.manifest.GVK.Kind, f.manifest.Obj.GetNamespace(), f.manifest.Obj.GetName()), f.message)
+}
+
+func newConformanceVerifier(update *payload.Update, restConfig *rest.Config) (*conformanceVerifier, error) {
+	factory, err := dynamicclient.NewFactory(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &conformanceVerifier{
+		update: update,
+		get: func(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, error) {
+			client, err := factory.ResourceClient(gvk, namespace)
+			if err != nil {
+				return nil, err
+			}
+			return client.Get(ctx, name, metav1.GetOptions{})
+		},
+	}, nil
+}
+
+// conformanceCheck describes a problem with an in-cluster object that conforms to its
+// manifest, or returns an empty string.
+type conformanceCheck func(m *manifest.Manifest, actual *unstructured.Unstructured) string
+
+// verify returns each managed manifest whose in-cluster object is missing, differs from the
+// manifest, or fails one of checks. ClusterOperators are skipped, because the operators
+// create and own them rather than the cluster-version operator.
+func (v *conformanceVerifier) verify(ctx context.Context, overrides []configv1.ComponentOverride, checks ...conformanceCheck) ([]conformanceFinding, error) {
+	var findings []conformanceFinding
+	for i := range v.update.Manifests {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		m := &v.update.Manifests[i]
+		if m.GVK == configv1.SchemeGroupVersion.WithKind("ClusterOperator") {
+			continue
+		}
+		if ov, ok := getOverrideForManifest(overrides, m); ok && ov.Unmanaged {
+			continue
+		}
+		var discrepancy string
+		actual, err := v.get(ctx, m.GVK, m.Obj.GetNamespace(), m.Obj.GetName())
+		switch {
+		case apierrors.IsNotFound(err):
+			discrepancy = "does not exist"
+		case err != nil:
+			klog.V(2).Infof("Unable to read %s to verify it: %v", describeManifest(m.GVK.Kind, m.Obj.GetNamespace(), m.Obj.GetName()), err)
+			continue
+		default:
+			discrepancy = manifestDiscrepancy(m.Obj, actual)
+			for _, check := range checks {
+				if len(discrepancy) > 0 {
+					break
+				}
+				discrepancy = check(m, actual)
+			}
+		}
+		if len(discrepancy) == 0 {
+			continue
+		}
+		findings = append(findings, conformanceFinding{manifest: m, message: discrepancy})
+	}
+	return findings, nil
+}
+
+// manifestDiscrepancy describes the first field set by required, other than status and
+// metadata besides labels and annotations, that actual does not match, or returns an empty
+// string if actual conforms. Fields only present in actual, such as defaults, are ignored.
+func manifestDiscrepancy(required, actual *unstructured.Unstructured) string {
+	var keys []string
+	for key := range required.Object {
+		if key != "metadata" && key != "status" && key != "apiVersion" && key != "kind" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range []string{"labels", "annotations"} {
+		if want, ok, _ := unstructured.NestedFieldNoCopy(required.Object, "metadata", key); ok {
+			got, _, _ := unstructured.NestedFieldNoCopy(actual.Object, "metadata", key)
+			if path := subsetMismatch(want, got, ".metadata."+key); len(path) > 0 {
+				return fmt.Sprintf("does not match the manifest at %s", path)
+			}
+		}
+	}
+	for _, key := range keys {
+		if path := subsetMismatch(required.Object[key], actual.Object[key], "."+key); len(path) > 0 {
+			return fmt.Sprintf("does not match the manifest at %s", path)
+		}
+	}
+	return ""
+}
+
+// subsetMismatch returns the path of the first value in want that is not present in got,
+// or an empty string if got contains want. Lists must have the same length.
+func subsetMismatch(want, got interface{}, path string) string {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return path
+		}
+		keys := make([]string, 0, len(w))
+		for key := range w {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if mismatch := subsetMismatch(w[key], g[key], path+"."+key); len(mismatch) > 0 {
+				return mismatch
+			}
+		}
+		return ""
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return path
+		}
+		for i := range w {
+			if mismatch := subsetMismatch(w[i], g[i], fmt.Sprintf("%s[%d]", path, i)); len(mismatch) > 0 {
+				return mismatch
+			}
+		}
+		return ""
+	case int64:
+		if g, ok := got.(float64); ok && float64(w) == g {
+			return ""
+		}
+	case float64:
+		if g, ok := got.(int64); ok && float64(g) == w {
+			return ""
+		}
+	}
+	if !reflect.DeepEqual(want, got) {
+		return path
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package cvo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func Test_manifestDiscrepancy(t *testing.T) {
+	required := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":        "config",
+			"namespace":   "openshift-monitoring",
+			"annotations": map[string]interface{}{"include.release.openshift.io/self-managed-high-availability": "true"},
+		},
+		"data": map[string]interface{}{"retention": "24h"},
+		"spec": map[string]interface{}{"replicas": int64(2), "ports": []interface{}{map[string]interface{}{"port": int64(443)}}},
+	}}
+	tests := []struct {
+		name   string
+		actual map[string]interface{}
+		want   string
+	}{
+		{
+			name: "conforms with defaults and extra metadata",
+			actual: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":            "config",
+					"resourceVersion": "3",
+					"annotations":     map[string]interface{}{"include.release.openshift.io/self-managed-high-availability": "true", "other": "value"},
+				},
+				"data":   map[string]interface{}{"retention": "24h"},
+				"spec":   map[string]interface{}{"replicas": float64(2), "ports": []interface{}{map[string]interface{}{"port": int64(443), "protocol": "TCP"}}},
+				"status": map[string]interface{}{},
+			},
+		},
+		{
+			name: "changed value",
+			actual: map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": map[string]interface{}{"include.release.openshift.io/self-managed-high-availability": "true"}},
+				"data":     map[string]interface{}{"retention": "7d"},
+				"spec":     map[string]interface{}{"replicas": int64(2), "ports": []interface{}{map[string]interface{}{"port": int64(443)}}},
+			},
+			want: "does not match the manifest at .data.retention",
+		},
+		{
+			name: "missing annotation",
+			actual: map[string]interface{}{
+				"data": map[string]interface{}{"retention": "24h"},
+				"spec": map[string]interface{}{"replicas": int64(2), "ports": []interface{}{map[string]interface{}{"port": int64(443)}}},
+			},
+			want: "does not match the manifest at .metadata.annotations",
+		},
+		{
+			name: "extra list item",
+			actual: map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": map[string]interface{}{"include.release.openshift.io/self-managed-high-availability": "true"}},
+				"data":     map[string]interface{}{"retention": "24h"},
+				"spec":     map[string]interface{}{"replicas": int64(2), "ports": []interface{}{map[string]interface{}{"port": int64(443)}, map[string]interface{}{"port": int64(80)}}},
+			},
+			want: "does not match the manifest at .spec.ports",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := manifestDiscrepancy(required, &unstructured.Unstructured{Object: tt.actual}); got != tt.want {
+				t.Fatalf("manifestDiscrepancy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_conformanceVerifier_verify(t *testing.T) {
+	newManifest := func(gvk schema.GroupVersionKind, namespace, name string, data map[string]interface{}) manifest.Manifest {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"data": data}}
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		return manifest.Manifest{GVK: gvk, Obj: obj}
+	}
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	clusterOperator := configv1.SchemeGroupVersion.WithKind("ClusterOperator")
+	cluster := map[string]*unstructured.Unstructured{
+		"a": {Object: map[string]interface{}{"data": map[string]interface{}{"key": "value"}}},
+		"b": {Object: map[string]interface{}{"data": map[string]interface{}{"key": "changed"}}},
+		"c": {Object: map[string]interface{}{"data": map[string]interface{}{"key": "changed"}}},
+	}
+	verifier := &conformanceVerifier{
+		update: &payload.Update{Manifests: []manifest.Manifest{
+			newManifest(configMap, "ns", "a", map[string]interface{}{"key": "value"}),
+			newManifest(configMap, "ns", "b", map[string]interface{}{"key": "value"}),
+			newManifest(configMap, "ns", "c", map[string]interface{}{"key": "value"}),
+			newManifest(configMap, "ns", "missing", nil),
+			newManifest(clusterOperator, "", "ingress", nil),
+		}},
+		get: func(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, error) {
+			if obj, ok := cluster[name]; ok {
+				return obj, nil
+			}
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+		},
+	}
+	overrides := []configv1.ComponentOverride{{Kind: "ConfigMap", Namespace: "ns", Name: "c", Unmanaged: true}}
+
+	findings, err := verifier.verify(context.Background(), overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, finding := range findings {
+		got = append(got, finding.String())
+	}
+	want := []string{
+		`configmap "ns/b" does not match the manifest at .data.key`,
+		`configmap "ns/missing" does not exist`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected discrepancies:\n%q\n%q", got, want)
+	}
+}
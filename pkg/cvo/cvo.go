@@ -11,11 +11,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	informerscorev1 "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -38,11 +40,17 @@ import (
 	cvointernal "github.com/openshift/cluster-version-operator/pkg/cvo/internal"
 	"github.com/openshift/cluster-version-operator/pkg/cvo/internal/dynamicclient"
 	"github.com/openshift/cluster-version-operator/pkg/internal"
+	"github.com/openshift/cluster-version-operator/pkg/operatorversions"
 	"github.com/openshift/cluster-version-operator/pkg/payload"
 	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+	preconditionco "github.com/openshift/cluster-version-operator/pkg/payload/precondition/clusteroperator"
 	preconditioncv "github.com/openshift/cluster-version-operator/pkg/payload/precondition/clusterversion"
+	preconditionmcp "github.com/openshift/cluster-version-operator/pkg/payload/precondition/machineconfigpool"
+	preconditionsignature "github.com/openshift/cluster-version-operator/pkg/payload/precondition/signature"
+	preconditiongates "github.com/openshift/cluster-version-operator/pkg/payload/precondition/upgradegates"
 	"github.com/openshift/library-go/pkg/manifest"
 	"github.com/openshift/library-go/pkg/verify"
+	"github.com/openshift/library-go/pkg/verify/store"
 	"github.com/openshift/library-go/pkg/verify/store/configmap"
 	"github.com/openshift/library-go/pkg/verify/store/sigstore"
 )
@@ -50,6 +58,9 @@ import (
 const (
 	// maxRetries is the number of times a work-item will be retried before it is dropped out of the queue.
 	maxRetries = 15
+
+	// maxSyncWorkers is the number of manifests the sync worker may apply in parallel.
+	maxSyncWorkers = 16
 )
 
 // Operator defines cluster version operator. The CVO attempts to reconcile the appropriate image
@@ -113,6 +124,14 @@ type Operator struct {
 	proxyLister           configlistersv1.ProxyLister
 	cacheSynced           []cache.InformerSynced
 
+	// clusterOperators gets ClusterOperators from the cache for the sync worker, waking
+	// it when they change.
+	clusterOperators cvointernal.ClusterOperatorsGetter
+
+	// operatorVersions tracks the versions reported by every ClusterOperator
+	// for consumers that need to know which operators are not yet at a version.
+	operatorVersions *operatorversions.Cache
+
 	// queue tracks applying updates to a cluster.
 	queue workqueue.RateLimitingInterface
 	// availableUpdatesQueue tracks checking for updates from the update server.
@@ -140,6 +159,82 @@ type Operator struct {
 	// statusInterval is how often the configSync worker is allowed to retrigger
 	// the main sync status loop.
 	statusInterval time.Duration
+	// statusWriteLimiter, if set, limits how often status changes that are not
+	// transitions are written, coalescing pending progress into later writes.
+	statusWriteLimiter *rate.Limiter
+
+	// conformance, if set, verifies the local release image against the cluster, for
+	// standby replicas and after updates complete.
+	conformance *conformanceVerifier
+	// standbyLast holds the discrepancies found by the last standby verification.
+	standbyLast []string
+
+	// postUpdateQueue tracks verifying the cluster after an update completes.
+	postUpdateQueue workqueue.RateLimitingInterface
+	// postUpdateLock guards the pending and most recent post-update verification.
+	postUpdateLock    sync.Mutex
+	postUpdatePending string
+	postUpdateResult  *postUpdateVerification
+	// postUpdateLoaded is set once the result recorded by an earlier operator was read.
+	postUpdateLoaded bool
+
+	// auditLock guards the audit of the most recently loaded release and what was last
+	// recorded to the update audit ConfigMap.
+	auditLock     sync.Mutex
+	pendingAudit  *UpdateAudit
+	recordedAudit string
+
+	// watchdog, if set, detects a sync worker that has stopped making progress.
+	watchdog *syncWatchdog
+
+	// runLevelEndpoints, if set, are the alternate API endpoints for the manifests of
+	// particular run levels.
+	runLevelEndpoints map[string]RunLevelEndpoint
+
+	// ownership, if set, stamps applied resources with the operator's identity.
+	ownership *ownership
+
+	// parallelOperatorWaits makes updates wait for the ClusterOperators of a run level in
+	// parallel.
+	parallelOperatorWaits bool
+
+	// operatorTimings, if set, reports ClusterOperators that update slower than usual.
+	operatorTimings *operatorTimings
+
+	// checkpoint, if set, records the progress of updates so that they can be resumed after
+	// the operator restarts.
+	checkpoint *updateCheckpoint
+
+	// rollback, if set, rolls failed updates back to the release they updated from.
+	rollback *rollback
+
+	// rehearsal, if set, rehearses proposed updates without changing the ClusterVersion.
+	rehearsal *rehearsal
+
+	// preflight makes updates submit the release with server-side dry-run before beginning.
+	preflight bool
+
+	// releaseSignatureStores, if set, are searched by the release signature precondition,
+	// which is only run when they are.
+	releaseSignatureStores []store.Store
+
+	// dynamicClient reads the MachineConfigPools checked before an update begins.
+	dynamicClient dynamic.Interface
+
+	// podDisruptionBudgets makes the node rollout precondition also check that no
+	// PodDisruptionBudget would block draining the control plane.
+	podDisruptionBudgets bool
+
+	// toleratedClusterOperators are not required to be healthy before an update begins.
+	toleratedClusterOperators []string
+
+	// operatorWaits, if set, reports ClusterOperators that updates have waited on for too
+	// long or that have stopped making progress, and tolerates those the administrator runs
+	// degraded.
+	operatorWaits *cvointernal.ClusterOperatorWaits
+
+	// progress retains structured progress events for the /progress endpoint.
+	progress *progressFeed
 
 	// lastAtLock guards access to controller memory about the sync loop
 	lastAtLock          sync.Mutex
@@ -150,6 +245,13 @@ type Operator struct {
 	exclude string
 
 	clusterProfile string
+
+	// disabledCapabilities are capabilities whose manifests are not applied.
+	disabledCapabilities []string
+
+	// statusWebhook, if set, receives the sync worker status in addition to
+	// ClusterVersion and events.
+	statusWebhook *webhookStatusReporter
 }
 
 // New returns a new cluster version operator.
@@ -169,6 +271,7 @@ func New(
 	kubeClient kubernetes.Interface,
 	exclude string,
 	clusterProfile string,
+	statusWebhookURL string,
 ) *Operator {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
@@ -185,6 +288,7 @@ func New(
 		enableDefaultClusterVersion: enableDefaultClusterVersion,
 
 		statusInterval:             15 * time.Second,
+		statusWriteLimiter:         rate.NewLimiter(rate.Every(15*time.Second), 2),
 		minimumUpdateCheckInterval: minimumInterval,
 		payloadDir:                 overridePayloadDir,
 		defaultUpstreamServer:      "https://api.openshift.com/api/upgrades_info/v1/graph",
@@ -196,14 +300,24 @@ func New(
 		queue:                 workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "clusterversion"),
 		availableUpdatesQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "availableupdates"),
 		upgradeableQueue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "upgradeable"),
+		postUpdateQueue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "postupdateverification"),
+
+		progress: newProgressFeed(progressFeedCapacity),
+
+		operatorWaits: &cvointernal.ClusterOperatorWaits{
+			Ref: &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: name, Namespace: namespace},
+		},
 
 		exclude:        exclude,
 		clusterProfile: clusterProfile,
 	}
 
+	optr.operatorWaits.Recorder = cvointernal.NewEventRecorder(kubeClient.CoreV1(), namespace)
 	cvInformer.Informer().AddEventHandler(optr.eventHandler())
 
 	optr.coLister = coInformer.Lister()
+	optr.clusterOperators = cvointernal.NewInformerClusterOperatorsGetter(coInformer)
+	optr.operatorVersions = operatorversions.NewForInformer(coInformer)
 	optr.cacheSynced = append(optr.cacheSynced, coInformer.Informer().HasSynced)
 
 	optr.cvLister = cvInformer.Lister()
@@ -216,6 +330,10 @@ func New(
 	// make sure this is initialized after all the listers are initialized
 	optr.upgradeableChecks = optr.defaultUpgradeableChecks()
 
+	if len(statusWebhookURL) > 0 {
+		optr.statusWebhook = newWebhookStatusReporter(statusWebhookURL, optr.HTTPClient)
+	}
+
 	return optr
 }
 
@@ -223,7 +341,7 @@ func New(
 // controller that loads and applies content to the cluster. It returns an error if the payload appears to
 // be in error rather than continuing.
 func (optr *Operator) InitializeFromPayload(restConfig *rest.Config, burstRestConfig *rest.Config) error {
-	update, err := payload.LoadUpdate(optr.defaultPayloadDir(), optr.release.Image, optr.exclude, optr.clusterProfile)
+	update, err := payload.LoadUpdate(optr.defaultPayloadDir(), optr.release.Image, optr.exclude, optr.clusterProfile, optr.disabledCapabilities)
 	if err != nil {
 		return fmt.Errorf("the local release contents are invalid - no current version can be determined from disk: %v", err)
 	}
@@ -237,6 +355,12 @@ func (optr *Operator) InitializeFromPayload(restConfig *rest.Config, burstRestCo
 		return fmt.Errorf("unable to create a configuration client: %v", err)
 	}
 
+	if optr.dynamicClient == nil {
+		if optr.dynamicClient, err = dynamic.NewForConfig(restConfig); err != nil {
+			return fmt.Errorf("unable to create a dynamic client: %v", err)
+		}
+	}
+
 	// attempt to load a verifier as defined in the payload
 	verifier, signatureStore, err := loadConfigMapVerifierDataFromUpdate(update, httpClientConstructor.HTTPClient, configClient)
 	if err != nil {
@@ -250,12 +374,15 @@ func (optr *Operator) InitializeFromPayload(restConfig *rest.Config, burstRestCo
 	}
 	optr.verifier = verifier
 	optr.signatureStore = signatureStore
+	if optr.releaseSignatureStores != nil {
+		optr.releaseSignatureStores = append([]store.Store{configmap.NewStore(configClient, nil)}, preconditionsignature.StoresFromManifests(update.Manifests, httpClientConstructor.HTTPClient)...)
+	}
 
 	// after the verifier has been loaded, initialize the sync worker with a payload retriever
 	// which will consume the verifier
-	optr.configSync = NewSyncWorkerWithPreconditions(
+	worker := NewSyncWorkerWithPreconditions(
 		optr.defaultPayloadRetriever(),
-		NewResourceBuilder(restConfig, burstRestConfig, &dummyContextOperatorGetter{wrapped: optr.coLister}),
+		optr.newResourceBuilder(restConfig, burstRestConfig),
 		optr.defaultPreconditionChecks(),
 		optr.minimumUpdateCheckInterval,
 		wait.Backoff{
@@ -267,6 +394,28 @@ func (optr *Operator) InitializeFromPayload(restConfig *rest.Config, burstRestCo
 		optr.eventRecorder,
 		optr.clusterProfile,
 	)
+	if optr.rehearsal != nil {
+		optr.rehearsal.retriever = optr.defaultPayloadRetriever()
+		optr.rehearsal.preconditions = optr.defaultPreconditionChecks()
+	}
+	worker.holdBack = optr.holdBackWindows
+	worker.audit = optr.setUpdateAudit
+	worker.watchdog = optr.watchdog
+	worker.parallelOperatorWaits = optr.parallelOperatorWaits
+	worker.timings = optr.operatorTimings
+	worker.checkpoint = optr.checkpoint
+	worker.preflight = optr.preflight
+	worker.progress = optr.progress
+	worker.disabledCapabilities = optr.disabledCapabilities
+	worker.reporters = append(worker.reporters, newEventStatusReporter(optr.eventRecorder))
+	worker.reporters = append(worker.reporters, &postUpdateReporter{schedule: optr.schedulePostUpdateVerification})
+	if optr.statusWebhook != nil {
+		worker.reporters = append(worker.reporters, optr.statusWebhook)
+	}
+	optr.configSync = worker
+	if optr.conformance, err = newConformanceVerifier(update, restConfig); err != nil {
+		return fmt.Errorf("unable to create a client to verify the release: %v", err)
+	}
 
 	return nil
 }
@@ -300,6 +449,7 @@ func (optr *Operator) Run(runContext context.Context, shutdownContext context.Co
 	defer optr.queue.ShutDown()
 	defer optr.availableUpdatesQueue.ShutDown()
 	defer optr.upgradeableQueue.ShutDown()
+	defer optr.postUpdateQueue.ShutDown()
 	stopCh := runContext.Done()
 
 	klog.Infof("Starting ClusterVersionOperator with minimum reconcile period %s", optr.minimumUpdateCheckInterval)
@@ -312,6 +462,11 @@ func (optr *Operator) Run(runContext context.Context, shutdownContext context.Co
 		return fmt.Errorf("caches never synchronized: %w", runContext.Err())
 	}
 
+	// load the checkpoint of an update in progress before the sync worker starts
+	if optr.checkpoint != nil {
+		optr.loadCheckpoint(runContext)
+	}
+
 	// trigger the first cluster version reconcile always
 	optr.queue.Add(optr.queueKey())
 
@@ -325,7 +480,7 @@ func (optr *Operator) Run(runContext context.Context, shutdownContext context.Co
 	resultChannelCount++
 	go func() {
 		defer utilruntime.HandleCrash()
-		optr.configSync.Start(runContext, 16, optr.name, optr.cvLister)
+		optr.configSync.Start(runContext, maxSyncWorkers, optr.name, optr.cvLister)
 		resultChannel <- asyncResult{name: "sync worker"}
 	}()
 
@@ -333,7 +488,7 @@ func (optr *Operator) Run(runContext context.Context, shutdownContext context.Co
 	go func() {
 		defer utilruntime.HandleCrash()
 		wait.UntilWithContext(runContext, func(runContext context.Context) {
-			optr.worker(runContext, optr.availableUpdatesQueue, optr.availableUpdatesSync)
+			optr.worker(runContext, "availableupdates", optr.availableUpdatesQueue, optr.availableUpdatesSync)
 		}, time.Second)
 		resultChannel <- asyncResult{name: "available updates"}
 	}()
@@ -341,16 +496,27 @@ func (optr *Operator) Run(runContext context.Context, shutdownContext context.Co
 	resultChannelCount++
 	go func() {
 		defer utilruntime.HandleCrash()
-		wait.UntilWithContext(runContext, func(runContext context.Context) { optr.worker(runContext, optr.upgradeableQueue, optr.upgradeableSync) }, time.Second)
+		wait.UntilWithContext(runContext, func(runContext context.Context) {
+			optr.worker(runContext, "upgradeable", optr.upgradeableQueue, optr.upgradeableSync)
+		}, time.Second)
 		resultChannel <- asyncResult{name: "upgradeable"}
 	}()
 
+	resultChannelCount++
+	go func() {
+		defer utilruntime.HandleCrash()
+		wait.UntilWithContext(runContext, func(runContext context.Context) {
+			optr.worker(runContext, "postupdateverification", optr.postUpdateQueue, optr.postUpdateVerificationSync)
+		}, time.Second)
+		resultChannel <- asyncResult{name: "post-update verification"}
+	}()
+
 	resultChannelCount++
 	go func() {
 		defer utilruntime.HandleCrash()
 		wait.UntilWithContext(runContext, func(runContext context.Context) {
 			// run the worker, then when the queue is closed sync one final time to flush any pending status
-			optr.worker(runContext, optr.queue, func(runContext context.Context, key string) error { return optr.sync(runContext, key) })
+			optr.worker(runContext, "clusterversion", optr.queue, func(runContext context.Context, key string) error { return optr.sync(runContext, key) })
 			if err := optr.sync(shutdownContext, optr.queueKey()); err != nil {
 				utilruntime.HandleError(fmt.Errorf("unable to perform final sync: %v", err))
 			}
@@ -358,6 +524,33 @@ func (optr *Operator) Run(runContext context.Context, shutdownContext context.Co
 		resultChannel <- asyncResult{name: "cluster version sync"}
 	}()
 
+	if optr.watchdog != nil {
+		resultChannelCount++
+		go func() {
+			defer utilruntime.HandleCrash()
+			optr.runSyncWatchdog(runContext, syncWatchdogInterval)
+			resultChannel <- asyncResult{name: "sync worker watchdog"}
+		}()
+	}
+
+	if optr.rehearsal != nil {
+		resultChannelCount++
+		go func() {
+			defer utilruntime.HandleCrash()
+			optr.runRehearsals(runContext, rehearsalInterval)
+			resultChannel <- asyncResult{name: "update rehearsal"}
+		}()
+	}
+
+	if optr.statusWebhook != nil {
+		resultChannelCount++
+		go func() {
+			defer utilruntime.HandleCrash()
+			optr.statusWebhook.Run(runContext)
+			resultChannel <- asyncResult{name: "status webhook"}
+		}()
+	}
+
 	if optr.signatureStore != nil {
 		resultChannelCount++
 		go func() {
@@ -401,6 +594,7 @@ func (optr *Operator) Run(runContext context.Context, shutdownContext context.Co
 			optr.queue.ShutDown()
 			optr.availableUpdatesQueue.ShutDown()
 			optr.upgradeableQueue.ShutDown()
+			optr.postUpdateQueue.ShutDown()
 		}
 	}
 
@@ -432,8 +626,16 @@ func (optr *Operator) eventHandler() cache.ResourceEventHandler {
 	}
 }
 
-func (optr *Operator) worker(ctx context.Context, queue workqueue.RateLimitingInterface, syncHandler func(context.Context, string) error) {
-	for processNextWorkItem(ctx, queue, syncHandler, optr.syncFailingStatus) {
+// worker processes the queue until it is shut down, recording reconcile metrics for the
+// named controller.
+func (optr *Operator) worker(ctx context.Context, controller string, queue workqueue.RateLimitingInterface, syncHandler func(context.Context, string) error) {
+	observed := func(ctx context.Context, key string) error {
+		start := time.Now()
+		err := syncHandler(ctx, key)
+		internal.ObserveReconcile(controller, start, err)
+		return err
+	}
+	for processNextWorkItem(ctx, queue, observed, optr.syncFailingStatus) {
 	}
 }
 
@@ -538,11 +740,26 @@ func (optr *Operator) sync(ctx context.Context, key string) error {
 		state = payload.UpdatingPayload
 	}
 
+	// apply the release rolled back to instead of a failed update
+	desired, state = optr.rollbackTarget(ctx, desired, state, config)
+
+	// let the waits for ClusterOperators tolerate those the administrator knowingly runs degraded
+	optr.operatorWaits.TolerateDegraded(internal.ToleratedDegradedOperators(config.Annotations))
+
 	// inform the config sync loop about our desired state
 	status := optr.configSync.Update(config.Generation, desired, config.Spec.Overrides, state)
+	if to, ok := optr.startRollback(ctx, desired, state, status, config, time.Now()); ok {
+		desired, state = optr.rollbackTarget(ctx, to, state, config)
+		status = optr.configSync.Update(config.Generation, desired, config.Spec.Overrides, state)
+	}
 
 	// write cluster version status
-	return optr.syncStatus(ctx, original, config, status, errs)
+	if err := optr.syncStatus(ctx, original, config, status, errs); err != nil {
+		return err
+	}
+
+	// apply the manifests of any components the administrator asked to resynchronize
+	return optr.resyncComponents(ctx, original)
 }
 
 // availableUpdatesSync is triggered on cluster version change (and periodic requeues) to
@@ -740,6 +957,101 @@ type resourceBuilder struct {
 	modifier    resourcebuilder.MetaV1ObjectModifierFunc
 
 	clusterOperators cvointernal.ClusterOperatorsGetter
+
+	// operatorWaits, if set, is shared by every ClusterOperator wait.
+	operatorWaits *cvointernal.ClusterOperatorWaits
+
+	// ownership, if set, stamps applied resources and protects resources owned by another
+	// cluster-version operator.
+	ownership *ownership
+
+	// largeObjects limits how many large manifests are applied at once, so
+	// that several huge objects do not compete for a stressed server.
+	largeObjects chan struct{}
+}
+
+// largeObjectRequestTimeout is the per-request timeout used when applying large manifests.
+const largeObjectRequestTimeout = 2 * time.Minute
+
+// EnableParallelClusterOperatorWaits makes updates initiate every component of a run level
+// before waiting for its ClusterOperators in parallel, reporting the operators that are still
+// updating in a single combined error, instead of holding a sync worker for each wait. It must
+// be called before InitializeFromPayload.
+func (optr *Operator) EnableParallelClusterOperatorWaits() {
+	optr.parallelOperatorWaits = true
+}
+
+// EnableReleaseSignaturePrecondition makes updates check, before they begin, that the release
+// image is signed by a key the payload's verifier or the signature bundle ConfigMap trusts. It
+// must be called before InitializeFromPayload.
+func (optr *Operator) EnableReleaseSignaturePrecondition() {
+	optr.releaseSignatureStores = []store.Store{}
+}
+
+// EnablePodDisruptionBudgetPrecondition makes updates check, before they begin, that no
+// PodDisruptionBudget protecting pods on control plane nodes allows no disruptions, which would
+// block draining those nodes. It must be called before InitializeFromPayload.
+func (optr *Operator) EnablePodDisruptionBudgetPrecondition() {
+	optr.podDisruptionBudgets = true
+}
+
+// DisableCapabilities skips the manifests of the named capabilities, which are listed in the
+// CapabilityAnnotation of manifests, and reports them as disabled on the ClusterVersion. It
+// must be called before InitializeFromPayload.
+func (optr *Operator) DisableCapabilities(names []string) {
+	optr.disabledCapabilities = names
+}
+
+// TolerateClusterOperators lets updates begin while the named ClusterOperators are not
+// available or are degraded. It must be called before InitializeFromPayload.
+func (optr *Operator) TolerateClusterOperators(names []string) {
+	optr.toleratedClusterOperators = names
+}
+
+// SetClusterOperatorWaitLimits reports updates as failing once they have waited longer than
+// timeout for a ClusterOperator, or once a ClusterOperator has not changed its versions or
+// conditions for stuckAfter, recording a ClusterOperatorStuck event. Zero disables either
+// limit. It must be called before InitializeFromPayload.
+func (optr *Operator) SetClusterOperatorWaitLimits(timeout, stuckAfter time.Duration) {
+	optr.operatorWaits.Timeout = timeout
+	optr.operatorWaits.StuckAfter = stuckAfter
+}
+
+// RouteClusterOperatorWaitEvents records the events of ClusterOperator waits on the objects
+// routing names: ClusterVersion, the default, ClusterOperator, or OperatorNamespace to record
+// them on the ClusterOperator and again in its operator's namespace. It must be called before
+// InitializeFromPayload.
+func (optr *Operator) RouteClusterOperatorWaitEvents(routing string) error {
+	events, err := cvointernal.ParseEventRouting(routing)
+	if err != nil {
+		return err
+	}
+	optr.operatorWaits.Events = events
+	return nil
+}
+
+// newResourceBuilder creates the resource builder for the sync worker, applying the manifests
+// of run levels with alternate endpoints through those endpoints.
+func (optr *Operator) newResourceBuilder(restConfig, burstRestConfig *rest.Config) payload.ResourceBuilder {
+	var clusterOperators cvointernal.ClusterOperatorsGetter = &dummyContextOperatorGetter{wrapped: optr.coLister}
+	if optr.clusterOperators != nil {
+		clusterOperators = optr.clusterOperators
+	}
+	newBuilder := func(config, burstConfig *rest.Config) payload.ResourceBuilder {
+		builder := NewResourceBuilder(config, burstConfig, clusterOperators).(*resourceBuilder)
+		builder.ownership = optr.ownership
+		builder.operatorWaits = optr.operatorWaits
+		return builder
+	}
+	builder := newBuilder(restConfig, burstRestConfig)
+	if len(optr.runLevelEndpoints) == 0 {
+		return builder
+	}
+	levels := make(map[string]payload.ResourceBuilder, len(optr.runLevelEndpoints))
+	for level, endpoint := range optr.runLevelEndpoints {
+		levels[level] = newBuilder(endpoint.Config, endpoint.BurstConfig)
+	}
+	return &runLevelResourceBuilder{defaultBuilder: builder, levels: levels}
 }
 
 // NewResourceBuilder creates the default resource builder implementation.
@@ -748,21 +1060,33 @@ func NewResourceBuilder(config, burstConfig *rest.Config, clusterOperators cvoin
 		config:           config,
 		burstConfig:      burstConfig,
 		clusterOperators: clusterOperators,
+		largeObjects:     make(chan struct{}, 1),
 	}
 }
 
-func (b *resourceBuilder) builderFor(m *manifest.Manifest, state payload.State) (resourcebuilder.Interface, error) {
+func (b *resourceBuilder) configFor(m *manifest.Manifest, state payload.State) *rest.Config {
 	config := b.config
 	if state == payload.InitializingPayload {
 		config = b.burstConfig
 	}
+	if payload.IsLargeManifest(m) && config != nil && config.Timeout < largeObjectRequestTimeout {
+		config = rest.CopyConfig(config)
+		config.Timeout = largeObjectRequestTimeout
+	}
+	if state == payload.PreflightPayload && config != nil {
+		config = resourcebuilder.DryRunConfig(config)
+	}
+	return config
+}
 
+func (b *resourceBuilder) builderFor(m *manifest.Manifest, state payload.State) (resourcebuilder.Interface, error) {
+	config := b.configFor(m, state)
 	if b.clusterOperators != nil && m.GVK == configv1.SchemeGroupVersion.WithKind("ClusterOperator") {
 		client, err := clientset.NewForConfig(config)
 		if err != nil {
 			return nil, err
 		}
-		return cvointernal.NewClusterOperatorBuilder(b.clusterOperators, client.ConfigV1().ClusterOperators(), *m), nil
+		return cvointernal.NewClusterOperatorBuilder(b.clusterOperators, client.ConfigV1().ClusterOperators(), b.operatorWaits, *m), nil
 	}
 	if resourcebuilder.Mapper.Exists(m.GVK) {
 		return resourcebuilder.New(resourcebuilder.Mapper, config, *m)
@@ -775,26 +1099,51 @@ func (b *resourceBuilder) builderFor(m *manifest.Manifest, state payload.State)
 }
 
 func (b *resourceBuilder) Apply(ctx context.Context, m *manifest.Manifest, state payload.State) error {
+	if payload.IsLargeManifest(m) && b.largeObjects != nil {
+		select {
+		case b.largeObjects <- struct{}{}:
+			defer func() { <-b.largeObjects }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 	builder, err := b.builderFor(m, state)
 	if err != nil {
 		return err
 	}
-	if b.modifier != nil {
+	var takeover bool
+	if b.ownership != nil {
+		if takeover, err = b.ownership.check(ctx, b.configFor(m, state), m); err != nil {
+			return err
+		}
+		builder = builder.WithModifier(b.ownership.stamp(m, b.modifier))
+	} else if b.modifier != nil {
 		builder = builder.WithModifier(b.modifier)
 	}
-	return builder.WithMode(stateToMode(state)).Do(ctx)
+	if err := builder.WithMode(stateToMode(state)).Do(ctx); err != nil {
+		return err
+	}
+	// the takeover is complete once the resource is stamped with this operator's ownership
+	if takeover && state != payload.PreflightPayload {
+		if err := b.ownership.clearTakeover(ctx, b.configFor(m, state), m); err != nil {
+			return fmt.Errorf("unable to remove the %s annotation from %s: %v", takeoverAnnotation, manifestDescription(m), err)
+		}
+	}
+	return nil
 }
 
 func stateToMode(state payload.State) resourcebuilder.Mode {
 	switch state {
 	case payload.InitializingPayload:
 		return resourcebuilder.InitializingMode
-	case payload.UpdatingPayload:
+	case payload.UpdatingPayload, payload.RollingBackPayload:
 		return resourcebuilder.UpdatingMode
 	case payload.ReconcilingPayload:
 		return resourcebuilder.ReconcilingMode
 	case payload.PrecreatingPayload:
 		return resourcebuilder.PrecreatingMode
+	case payload.PreflightPayload:
+		return resourcebuilder.DryRunMode
 	default:
 		panic(fmt.Sprintf("unexpected payload state %d", int(state)))
 	}
@@ -821,9 +1170,20 @@ func hasReachedLevel(cv *configv1.ClusterVersion, desired configv1.Update) bool
 }
 
 func (optr *Operator) defaultPreconditionChecks() precondition.List {
-	return []precondition.Precondition{
-		preconditioncv.NewUpgradeable(optr.cvLister),
+	checks := precondition.List{
+		preconditioncv.NewUpgradeableWithOverrides(optr.cvLister, optr.cmConfigLister),
+		preconditionco.NewHealth(optr.coLister, optr.toleratedClusterOperators),
+		preconditiongates.NewUpgradeGates(optr.cmConfigLister),
+	}
+	var kubeClient kubernetes.Interface
+	if optr.podDisruptionBudgets {
+		kubeClient = optr.kubeClient
+	}
+	checks = append(checks, preconditionmcp.NewNodeRollout(optr.dynamicClient, kubeClient))
+	if optr.releaseSignatureStores != nil {
+		checks = append(checks, preconditionsignature.NewReleaseSignature(optr.verifier.Verifiers(), optr.releaseSignatureStores, optr.cmConfigLister))
 	}
+	return checks
 }
 
 // HTTPClient provides a method for generating an HTTP client
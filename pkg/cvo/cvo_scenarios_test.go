@@ -16,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	clientgotesting "k8s.io/client-go/testing"
@@ -38,6 +39,22 @@ func setupCVOTest(payloadDir string) (*Operator, map[string]runtime.Object, *fak
 	cvs := make(map[string]runtime.Object)
 	client.AddReactor("*", "clusterversions", func(action clientgotesting.Action) (handled bool, ret runtime.Object, err error) {
 		switch a := action.(type) {
+		case clientgotesting.PatchAction:
+			existing := cvs[a.GetName()].DeepCopyObject().(*configv1.ClusterVersion)
+			if a.GetSubresource() != "status" || a.GetPatchType() != types.JSONPatchType {
+				return false, nil, fmt.Errorf("unexpected patch: %#v", action)
+			}
+			if err := applyStatusPatch(existing, a.GetPatch()); err != nil {
+				return true, nil, err
+			}
+			rv, _ := strconv.Atoi(existing.ResourceVersion)
+			existing.ResourceVersion = strconv.Itoa(rv + 1)
+			// objects seeded directly into cvs have not been through create, which sets the generation
+			if existing.Generation == 0 {
+				existing.Generation = 1
+			}
+			cvs[existing.Name] = existing
+			return true, existing, nil
 		case clientgotesting.GetAction:
 			obj, ok := cvs[a.GetName()]
 			if !ok {
@@ -1034,6 +1051,10 @@ func TestCVO_StartupAndSyncPreconditionFailing(t *testing.T) {
 	expectGet(t, actions[0], "clusterversions", "", "version")
 }
 
+// forcedUnverifiedUnsupported is the unsupported configuration reported while applying a forced,
+// unverified image/image:1.
+var forcedUnverifiedUnsupported = []string{"The release image image/image:1 could not be verified and is only being applied because the update was forced. Update to a signed release image to return to a supported configuration."}
+
 func TestCVO_UpgradeUnverifiedPayload(t *testing.T) {
 	o, cvs, client, _, shutdownFn := setupCVOTest("testdata/payloadtest-2")
 
@@ -1199,7 +1220,8 @@ func TestCVO_UpgradeUnverifiedPayload(t *testing.T) {
 				Image:   "image/image:1",
 				URL:     configv1.URL("https://example.com/v1.0.1-abc"),
 			},
-			Generation: 1,
+			Generation:  1,
+			Unsupported: forcedUnverifiedUnsupported,
 		},
 		SyncWorkerStatus{
 			Done:        1,
@@ -1213,6 +1235,7 @@ func TestCVO_UpgradeUnverifiedPayload(t *testing.T) {
 			},
 			LastProgress: time.Unix(1, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
 		},
 		SyncWorkerStatus{
 			Done:        2,
@@ -1226,6 +1249,7 @@ func TestCVO_UpgradeUnverifiedPayload(t *testing.T) {
 			},
 			LastProgress: time.Unix(2, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
 		},
 		SyncWorkerStatus{
 			Reconciling: true,
@@ -1240,6 +1264,7 @@ func TestCVO_UpgradeUnverifiedPayload(t *testing.T) {
 			},
 			LastProgress: time.Unix(3, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
 		},
 	)
 	client.ClearActions()
@@ -1280,6 +1305,7 @@ func TestCVO_UpgradeUnverifiedPayload(t *testing.T) {
 				{Type: ClusterStatusFailing, Status: configv1.ConditionFalse},
 				{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse, Message: "Cluster version is 1.0.1-abc"},
 				{Type: configv1.RetrievedUpdates, Status: configv1.ConditionFalse},
+				{Type: ClusterStatusUnsupportedConfiguration, Status: configv1.ConditionTrue, Reason: "UnsupportedConfigurationDetected", Message: forcedUnverifiedUnsupported[0]},
 			},
 		},
 	})
@@ -1450,7 +1476,8 @@ func TestCVO_UpgradeUnverifiedPayloadRetrieveOnce(t *testing.T) {
 				Image:   "image/image:1",
 				URL:     configv1.URL("https://example.com/v1.0.1-abc"),
 			},
-			Generation: 1,
+			Generation:  1,
+			Unsupported: forcedUnverifiedUnsupported,
 		},
 		SyncWorkerStatus{
 			Done:        1,
@@ -1464,6 +1491,7 @@ func TestCVO_UpgradeUnverifiedPayloadRetrieveOnce(t *testing.T) {
 			},
 			LastProgress: time.Unix(1, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
 		},
 		SyncWorkerStatus{
 			Done:        2,
@@ -1477,6 +1505,7 @@ func TestCVO_UpgradeUnverifiedPayloadRetrieveOnce(t *testing.T) {
 			},
 			LastProgress: time.Unix(2, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
 		},
 		SyncWorkerStatus{
 			Reconciling: true,
@@ -1491,6 +1520,7 @@ func TestCVO_UpgradeUnverifiedPayloadRetrieveOnce(t *testing.T) {
 			},
 			LastProgress: time.Unix(3, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
 		},
 	)
 	client.ClearActions()
@@ -1531,6 +1561,7 @@ func TestCVO_UpgradeUnverifiedPayloadRetrieveOnce(t *testing.T) {
 				{Type: ClusterStatusFailing, Status: configv1.ConditionFalse},
 				{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse, Message: "Cluster version is 1.0.1-abc"},
 				{Type: configv1.RetrievedUpdates, Status: configv1.ConditionFalse},
+				{Type: ClusterStatusUnsupportedConfiguration, Status: configv1.ConditionTrue, Reason: "UnsupportedConfigurationDetected", Message: forcedUnverifiedUnsupported[0]},
 			},
 		},
 	})
@@ -1548,7 +1579,8 @@ func TestCVO_UpgradeUnverifiedPayloadRetrieveOnce(t *testing.T) {
 				Image:   "image/image:1",
 				URL:     configv1.URL("https://example.com/v1.0.1-abc"),
 			},
-			Generation: 1,
+			Generation:  1,
+			Unsupported: forcedUnverifiedUnsupported,
 		},
 		SyncWorkerStatus{
 			Reconciling: true,
@@ -1561,7 +1593,8 @@ func TestCVO_UpgradeUnverifiedPayloadRetrieveOnce(t *testing.T) {
 				Image:   "image/image:1",
 				URL:     configv1.URL("https://example.com/v1.0.1-abc"),
 			},
-			Generation: 1,
+			Generation:  1,
+			Unsupported: forcedUnverifiedUnsupported,
 		},
 		SyncWorkerStatus{
 			Reconciling: true,
@@ -1574,7 +1607,8 @@ func TestCVO_UpgradeUnverifiedPayloadRetrieveOnce(t *testing.T) {
 				Image:   "image/image:1",
 				URL:     configv1.URL("https://example.com/v1.0.1-abc"),
 			},
-			Generation: 1,
+			Generation:  1,
+			Unsupported: forcedUnverifiedUnsupported,
 		},
 		SyncWorkerStatus{
 			Reconciling: true,
@@ -1589,6 +1623,7 @@ func TestCVO_UpgradeUnverifiedPayloadRetrieveOnce(t *testing.T) {
 			},
 			LastProgress: time.Unix(1, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
 		},
 	)
 }
@@ -1661,9 +1696,29 @@ func TestCVO_UpgradePreconditionFailing(t *testing.T) {
 			Actual: configv1.Release{Version: "1.0.1-abc", Image: "image/image:1"},
 		},
 		SyncWorkerStatus{
-			Step:    "PreconditionChecks",
-			Failure: &payload.UpdateError{Reason: "UpgradePreconditionCheckFailed", Message: "Precondition \"TestPrecondition SuccessAfter: 3\" failed because of \"CheckFailure\": failing, attempt: 1 will succeed after 3 attempt", Name: "PreconditionCheck"},
-			Actual:  configv1.Release{Version: "1.0.1-abc", Image: "image/image:1"},
+			Step: "PreconditionChecks",
+			Failure: &payload.UpdateError{
+				Nested: &precondition.Error{
+					Reason:  "CheckFailure",
+					Message: "failing, attempt: 1 will succeed after 3 attempt",
+					Name:    "TestPrecondition SuccessAfter: 3",
+				},
+				Reason:  "UpgradePreconditionCheckFailed",
+				Message: "Precondition \"TestPrecondition SuccessAfter: 3\" failed because of \"CheckFailure\": failing, attempt: 1 will succeed after 3 attempt",
+				Name:    "PreconditionCheck",
+			},
+			Actual: configv1.Release{Version: "1.0.1-abc", Image: "image/image:1"},
+			Preconditions: precondition.Results{{
+				Name:     "TestPrecondition SuccessAfter: 3",
+				Reason:   "CheckFailure",
+				Message:  "failing, attempt: 1 will succeed after 3 attempt",
+				Severity: precondition.SeverityBlocking,
+				Err: &precondition.Error{
+					Reason:  "CheckFailure",
+					Message: "failing, attempt: 1 will succeed after 3 attempt",
+					Name:    "TestPrecondition SuccessAfter: 3",
+				},
+			}},
 		},
 	)
 
@@ -1703,6 +1758,7 @@ func TestCVO_UpgradePreconditionFailing(t *testing.T) {
 				{Type: ClusterStatusFailing, Status: configv1.ConditionTrue, Reason: "UpgradePreconditionCheckFailed", Message: "Precondition \"TestPrecondition SuccessAfter: 3\" failed because of \"CheckFailure\": failing, attempt: 1 will succeed after 3 attempt"},
 				{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue, Reason: "UpgradePreconditionCheckFailed", Message: "Unable to apply 1.0.1-abc: it may not be safe to apply this update"},
 				{Type: configv1.RetrievedUpdates, Status: configv1.ConditionFalse},
+				{Type: ClusterStatusPreconditionChecks, Status: configv1.ConditionFalse, Reason: "CheckFailure", Message: "Precondition check results:\n* TestPrecondition SuccessAfter: 3: CheckFailure since 1970-01-01T00:00:00Z, last checked 1970-01-01T00:00:00Z: failing, attempt: 1 will succeed after 3 attempt"},
 			},
 		},
 	})
@@ -1741,6 +1797,17 @@ func TestCVO_UpgradePreconditionFailing(t *testing.T) {
 			t.Fatalf("saw too many sync events of the wrong form")
 		}
 	}
+	forcedPreconditions := precondition.Results{{
+		Name:     "TestPrecondition SuccessAfter: 3",
+		Reason:   "CheckFailure",
+		Message:  "failing, attempt: 2 will succeed after 3 attempt",
+		Severity: precondition.SeverityBlocking,
+		Err: &precondition.Error{
+			Reason:  "CheckFailure",
+			Message: "failing, attempt: 2 will succeed after 3 attempt",
+			Name:    "TestPrecondition SuccessAfter: 3",
+		},
+	}}
 	verifyAllStatus(t, worker.StatusCh(),
 		SyncWorkerStatus{
 			Step:       "PreconditionChecks",
@@ -1756,7 +1823,10 @@ func TestCVO_UpgradePreconditionFailing(t *testing.T) {
 				Image:   "image/image:1",
 				URL:     configv1.URL("https://example.com/v1.0.1-abc"),
 			},
-			Generation: 1,
+			Generation:  1,
+			Unsupported: forcedUnverifiedUnsupported,
+
+			Preconditions: forcedPreconditions,
 		},
 		SyncWorkerStatus{
 			Done:        1,
@@ -1770,6 +1840,9 @@ func TestCVO_UpgradePreconditionFailing(t *testing.T) {
 			},
 			LastProgress: time.Unix(1, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
+
+			Preconditions: forcedPreconditions,
 		},
 		SyncWorkerStatus{
 			Done:        2,
@@ -1783,6 +1856,9 @@ func TestCVO_UpgradePreconditionFailing(t *testing.T) {
 			},
 			LastProgress: time.Unix(2, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
+
+			Preconditions: forcedPreconditions,
 		},
 		SyncWorkerStatus{
 			Reconciling: true,
@@ -1797,6 +1873,9 @@ func TestCVO_UpgradePreconditionFailing(t *testing.T) {
 			},
 			LastProgress: time.Unix(3, 0),
 			Generation:   1,
+			Unsupported:  forcedUnverifiedUnsupported,
+
+			Preconditions: forcedPreconditions,
 		},
 	)
 	client.ClearActions()
@@ -1837,6 +1916,8 @@ func TestCVO_UpgradePreconditionFailing(t *testing.T) {
 				{Type: ClusterStatusFailing, Status: configv1.ConditionFalse},
 				{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse, Message: "Cluster version is 1.0.1-abc"},
 				{Type: configv1.RetrievedUpdates, Status: configv1.ConditionFalse},
+				{Type: ClusterStatusPreconditionChecks, Status: configv1.ConditionFalse, Reason: "CheckFailure", Message: "Precondition check results:\n* TestPrecondition SuccessAfter: 3: CheckFailure since 1970-01-01T00:00:00Z, last checked 1970-01-01T00:00:00Z: failing, attempt: 2 will succeed after 3 attempt"},
+				{Type: ClusterStatusUnsupportedConfiguration, Status: configv1.ConditionTrue, Reason: "UnsupportedConfigurationDetected", Message: forcedUnverifiedUnsupported[0]},
 			},
 		},
 	})
@@ -2858,6 +2939,14 @@ func verifyAllStatus(t *testing.T, ch <-chan SyncWorkerStatus, items ...SyncWork
 			actual.LastProgress = time.Unix(count, 0)
 		}
 
+		if len(actual.Preconditions) > 0 {
+			actual.Preconditions = append(precondition.Results(nil), actual.Preconditions...)
+			for i := range actual.Preconditions {
+				actual.Preconditions[i].LastProbeTime = time.Time{}
+				actual.Preconditions[i].Since = time.Time{}
+			}
+		}
+
 		if !reflect.DeepEqual(expect, actual) {
 			t.Fatalf("unexpected status item %d\nExpected: %#v\nActual: %#v", i, expect, actual)
 		}
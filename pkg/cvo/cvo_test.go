@@ -3,6 +3,7 @@ package cvo
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -10,11 +11,14 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/google/uuid"
 	corev1 "k8s.io/api/core/v1"
 	apiextv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
@@ -3281,6 +3285,8 @@ func TestOperator_upgradeableSync(t *testing.T) {
 
 			if optr.upgradeable != nil {
 				optr.upgradeable.At = time.Time{}
+				// operator health feeds the upgrade readiness score, see TestUpgradeReadiness
+				optr.upgradeable.OperatorHealth = nil
 				for i := range optr.upgradeable.Conditions {
 					optr.upgradeable.Conditions[i].LastTransitionTime = metav1.Time{}
 				}
@@ -3322,9 +3328,84 @@ func expectUpdate(t *testing.T, a ktesting.Action, resource, namespace string, o
 	expectMutation(t, a, "update", resource, "", namespace, obj)
 }
 
+// expectUpdateStatus verifies that a is a JSON patch setting the status fields it changes
+// to those of obj's status. Status patches do not change object metadata or spec, and
+// the fields they do not change are taken from obj.
 func expectUpdateStatus(t *testing.T, a ktesting.Action, resource, namespace string, obj interface{}) {
 	t.Helper()
-	expectMutation(t, a, "update", resource, "status", namespace, obj)
+	if a.GetVerb() != "patch" {
+		t.Fatalf("unexpected verb: %s", a.GetVerb())
+	}
+	pa, ok := a.(ktesting.PatchAction)
+	if !ok || pa.GetPatchType() != types.JSONPatchType {
+		t.Fatalf("unexpected patch action: %#v", a)
+	}
+	var ops []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(pa.GetPatch(), &ops); err != nil {
+		t.Fatalf("unable to decode patch: %v", err)
+	}
+	// round trip the expected status so that both sides are compared after serialization
+	expected := obj.(*configv1.ClusterVersion).DeepCopy()
+	data, err := json.Marshal(expected.Status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected.Status = configv1.ClusterVersionStatus{}
+	if err := json.Unmarshal(data, &expected.Status); err != nil {
+		t.Fatal(err)
+	}
+	// fields the patch does not change are taken from the expected status, where history
+	// that has not started is expected as starting at the epoch
+	base := expected.Status.DeepCopy()
+	for i, item := range base.History {
+		if item.StartedTime.Time.Equal(time.Unix(0, 0)) {
+			base.History[i].StartedTime = metav1.Time{}
+		}
+	}
+	if data, err = json.Marshal(base); err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, op := range ops {
+		name := strings.TrimPrefix(op.Path, "/status/")
+		switch {
+		case op.Op == "add" && op.Path == "/status":
+			fields = map[string]json.RawMessage{}
+			if err := json.Unmarshal(op.Value, &fields); err != nil {
+				t.Fatalf("unable to decode status: %v", err)
+			}
+			found = true
+		case op.Op == "add" && name != op.Path:
+			fields[name] = op.Value
+			found = true
+		case op.Op == "remove" && name != op.Path:
+			delete(fields, name)
+			found = true
+		case op.Op == "test" && (op.Path == "/metadata/uid" || name != op.Path):
+		default:
+			t.Fatalf("unexpected patch operation %s %s", op.Op, op.Path)
+		}
+	}
+	if !found {
+		t.Fatalf("patch does not set the status: %s", pa.GetPatch())
+	}
+	if data, err = json.Marshal(fields); err != nil {
+		t.Fatal(err)
+	}
+	actual := expected.DeepCopy()
+	actual.Status = configv1.ClusterVersionStatus{}
+	if err := json.Unmarshal(data, &actual.Status); err != nil {
+		t.Fatalf("unable to decode status: %v", err)
+	}
+	expectMutation(t, ktesting.NewUpdateSubresourceAction(pa.GetResource(), "status", pa.GetNamespace(), actual), "update", resource, "status", namespace, expected)
 }
 
 func expectMutation(t *testing.T, a ktesting.Action, verb string, resource, subresource, namespace string, obj interface{}) {
@@ -3352,6 +3433,9 @@ func expectMutation(t *testing.T, a ktesting.Action, verb string, resource, subr
 		if in, ok := actual.(*configv1.ClusterVersion); ok {
 			for i := range in.Status.Conditions {
 				in.Status.Conditions[i].LastTransitionTime.Time = time.Time{}
+				if in.Status.Conditions[i].Type == ClusterStatusPreconditionChecks {
+					in.Status.Conditions[i].Message = rfc3339Pattern.ReplaceAllString(in.Status.Conditions[i].Message, "1970-01-01T00:00:00Z")
+				}
 			}
 			for i, item := range in.Status.History {
 				if item.StartedTime.IsZero() {
@@ -3377,16 +3461,45 @@ func expectMutation(t *testing.T, a ktesting.Action, verb string, resource, subr
 	}
 }
 
+// rfc3339Pattern matches the times precondition check results are reported with.
+var rfc3339Pattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z`)
+
+// applyStatusPatch applies a JSON patch to obj, keeping only the resulting status as the
+// server does for the status subresource. Patches that do not apply are rejected as the
+// server rejects them.
+func applyStatusPatch(obj *configv1.ClusterVersion, data []byte) error {
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	patch, err := jsonpatch.DecodePatch(data)
+	if err != nil {
+		return err
+	}
+	modified, err := patch.Apply(original)
+	if err != nil {
+		// the server reports patches that do not apply as invalid
+		return errors.NewGenericServerResponse(http.StatusUnprocessableEntity, "patch", schema.GroupResource{}, "", err.Error(), 0, false)
+	}
+	update := &configv1.ClusterVersion{}
+	if err := json.Unmarshal(modified, update); err != nil {
+		return err
+	}
+	obj.Status = update.Status
+	return nil
+}
+
 func fakeClientsetWithUpdates(obj *configv1.ClusterVersion) *fake.Clientset {
 	client := &fake.Clientset{}
 	client.AddReactor("*", "*", func(action ktesting.Action) (handled bool, ret runtime.Object, err error) {
 		if action.GetVerb() == "get" {
 			return true, obj.DeepCopy(), nil
 		}
-		if action.GetVerb() == "update" && action.GetSubresource() == "status" {
-			update := action.(ktesting.UpdateAction).GetObject().(*configv1.ClusterVersion)
-			obj.Status = update.Status
-			rv, _ := strconv.Atoi(update.ResourceVersion)
+		if action.GetVerb() == "patch" && action.GetSubresource() == "status" {
+			if err := applyStatusPatch(obj, action.(ktesting.PatchAction).GetPatch()); err != nil {
+				return true, nil, err
+			}
+			rv, _ := strconv.Atoi(obj.ResourceVersion)
 			obj.ResourceVersion = strconv.Itoa(rv + 1)
 			klog.V(5).Infof("updated object to %#v", obj)
 			return true, obj.DeepCopy(), nil
@@ -3589,3 +3702,18 @@ func TestOperator_mergeReleaseMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestResourceBuilderLargeGenericManifest(t *testing.T) {
+	server := newFakeAPIServer(t)
+	builder := NewResourceBuilder(&rest.Config{Host: server.URL, Timeout: 10 * time.Second}, nil, nil)
+	m := &manifest.Manifest{}
+	if err := m.UnmarshalJSON([]byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"large"},"spec":{"data":"` + strings.Repeat("x", payload.LargeManifestSize) + `"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.Apply(context.Background(), m, payload.UpdatingPayload); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"GET /apis/example.com/v1/widgets/large?timeout=2m0s", "POST /apis/example.com/v1/widgets?timeout=2m0s"}; !reflect.DeepEqual(server.widgetRequests(), want) {
+		t.Errorf("unexpected requests for a large manifest:\n%v\n%v", server.widgetRequests(), want)
+	}
+}
@@ -0,0 +1,112 @@
+package cvo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/openshift/library-go/pkg/verify"
+	"k8s.io/klog/v2"
+)
+
+// RuntimeConfig is the effective configuration of a running operator, published so that
+// support can confirm how an instance is configured without exec'ing into its pod.
+type RuntimeConfig struct {
+	NodeName       string `json:"nodeName"`
+	Namespace      string `json:"namespace"`
+	Name           string `json:"name"`
+	ReleaseImage   string `json:"releaseImage"`
+	ReleaseVersion string `json:"releaseVersion,omitempty"`
+	PayloadDir     string `json:"payloadDir"`
+	Exclude        string `json:"exclude,omitempty"`
+	ClusterProfile string `json:"clusterProfile"`
+
+	DisabledCapabilities []string `json:"disabledCapabilities,omitempty"`
+
+	// Upstream is the update service in use: the ClusterVersion spec.upstream, or the
+	// default when that is unset.
+	Upstream        string `json:"upstream"`
+	UpstreamDefault bool   `json:"upstreamDefault"`
+	Channel         string `json:"channel,omitempty"`
+
+	MinimumReconcileInterval string `json:"minimumReconcileInterval"`
+	StatusInterval           string `json:"statusInterval"`
+	MaxSyncWorkers           int    `json:"maxSyncWorkers"`
+	Verification             string `json:"verification"`
+	DefaultClusterVersion    bool   `json:"enableDefaultClusterVersion"`
+	StatusWebhook            bool   `json:"statusWebhook"`
+
+	// Flags are the command line options the operator was started with, as supplied by
+	// the caller of RuntimeConfigHandler.
+	Flags map[string]string `json:"flags,omitempty"`
+}
+
+// RuntimeConfig returns the effective configuration of the operator.
+func (optr *Operator) RuntimeConfig() RuntimeConfig {
+	config := RuntimeConfig{
+		NodeName:                 optr.nodename,
+		Namespace:                optr.namespace,
+		Name:                     optr.name,
+		ReleaseImage:             optr.release.Image,
+		ReleaseVersion:           optr.release.Version,
+		PayloadDir:               optr.defaultPayloadDir(),
+		Exclude:                  optr.exclude,
+		ClusterProfile:           optr.clusterProfile,
+		DisabledCapabilities:     optr.disabledCapabilities,
+		Upstream:                 optr.defaultUpstreamServer,
+		UpstreamDefault:          true,
+		MinimumReconcileInterval: optr.minimumUpdateCheckInterval.String(),
+		StatusInterval:           optr.statusInterval.String(),
+		MaxSyncWorkers:           maxSyncWorkers,
+		Verification:             "none, all releases are considered unverified",
+		DefaultClusterVersion:    optr.enableDefaultClusterVersion,
+		StatusWebhook:            optr.statusWebhook != nil,
+	}
+	if optr.verifier != nil && optr.verifier != verify.Reject {
+		config.Verification = fmt.Sprint(optr.verifier)
+	}
+	if optr.cvLister != nil {
+		if cv, err := optr.cvLister.Get(optr.name); err == nil {
+			config.Channel = cv.Spec.Channel
+			if len(cv.Spec.Upstream) > 0 {
+				config.Upstream = RedactURL(string(cv.Spec.Upstream))
+				config.UpstreamDefault = false
+			}
+		}
+	}
+	return config
+}
+
+// RuntimeConfigHandler serves the operator's effective configuration, along with flags, as
+// JSON. Credentials embedded in flag URLs should be removed with RedactURL by the caller.
+func (optr *Operator) RuntimeConfigHandler(flags map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := optr.RuntimeConfig()
+		config.Flags = flags
+		data, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			klog.Errorf("Unable to encode the runtime configuration: %v", err)
+			http.Error(w, "unable to encode the runtime configuration", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
+
+// RedactURL removes user information and query parameters, which may hold credentials,
+// from a URL.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	if u.User != nil {
+		u.User = url.User("REDACTED")
+	}
+	if len(u.RawQuery) > 0 {
+		u.RawQuery = "REDACTED"
+	}
+	return u.String()
+}
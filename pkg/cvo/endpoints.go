@@ -0,0 +1,39 @@
+package cvo
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/manifest"
+	"k8s.io/client-go/rest"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// RunLevelEndpoint is an alternate API endpoint that the manifests of a run level are applied
+// through, such as a dedicated bootstrap endpoint during install.
+type RunLevelEndpoint struct {
+	// Config is used while updating and reconciling, and BurstConfig while initializing.
+	Config      *rest.Config
+	BurstConfig *rest.Config
+}
+
+// SetRunLevelEndpoints applies the manifests of each run level, the NN of manifest filenames
+// of the form 0000_NN_*, through the given endpoint instead of the cluster the operator runs
+// against. It must be called before InitializeFromPayload.
+func (optr *Operator) SetRunLevelEndpoints(endpoints map[string]RunLevelEndpoint) {
+	optr.runLevelEndpoints = endpoints
+}
+
+// runLevelResourceBuilder applies manifests with the builder for their run level, falling back
+// to a default builder.
+type runLevelResourceBuilder struct {
+	defaultBuilder payload.ResourceBuilder
+	levels         map[string]payload.ResourceBuilder
+}
+
+func (b *runLevelResourceBuilder) Apply(ctx context.Context, m *manifest.Manifest, state payload.State) error {
+	if builder, ok := b.levels[payload.ManifestRunLevel(m)]; ok {
+		return builder.Apply(ctx, m, state)
+	}
+	return b.defaultBuilder.Apply(ctx, m, state)
+}
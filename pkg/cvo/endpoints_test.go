@@ -0,0 +1,113 @@
+package cvo
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/manifest"
+	"k8s.io/client-go/rest"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func TestRunLevelResourceBuilder(t *testing.T) {
+	defaultBuilder := &recordingResourceBuilder{}
+	bootstrap := &recordingResourceBuilder{}
+	builder := &runLevelResourceBuilder{
+		defaultBuilder: defaultBuilder,
+		levels:         map[string]payload.ResourceBuilder{"05": bootstrap},
+	}
+	for _, filename := range []string{"0000_05_config-operator_01_crd.yaml", "0000_50_ingress_00_namespace.yaml", "0000_05_etcd_00_namespace.yaml", "manifest.yaml"} {
+		if err := builder.Apply(context.Background(), &manifest.Manifest{OriginalFilename: filename}, payload.UpdatingPayload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"0000_05_config-operator_01_crd.yaml", "0000_05_etcd_00_namespace.yaml"}; !reflect.DeepEqual(bootstrap.applied, want) {
+		t.Errorf("unexpected manifests applied through the run level endpoint:\n%v\n%v", bootstrap.applied, want)
+	}
+	if want := []string{"0000_50_ingress_00_namespace.yaml", "manifest.yaml"}; !reflect.DeepEqual(defaultBuilder.applied, want) {
+		t.Errorf("unexpected manifests applied through the default endpoint:\n%v\n%v", defaultBuilder.applied, want)
+	}
+}
+
+// fakeAPIServer serves discovery for the cluster-scoped example.com/v1 Widget kind, has no
+// widgets and accepts every widget written to it, recording the widget requests.
+type fakeAPIServer struct {
+	*httptest.Server
+	lock     sync.Mutex
+	requests []string
+}
+
+func newFakeAPIServer(t *testing.T) *fakeAPIServer {
+	s := &fakeAPIServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api":
+			fmt.Fprint(w, `{"kind":"APIVersions","versions":[]}`)
+		case r.URL.Path == "/apis":
+			fmt.Fprint(w, `{"kind":"APIGroupList","groups":[{"name":"example.com","versions":[{"groupVersion":"example.com/v1","version":"v1"}],"preferredVersion":{"groupVersion":"example.com/v1","version":"v1"}}]}`)
+		case r.URL.Path == "/apis/example.com/v1":
+			fmt.Fprint(w, `{"kind":"APIResourceList","groupVersion":"example.com/v1","resources":[{"name":"widgets","singularName":"widget","namespaced":false,"kind":"Widget","verbs":["get","create","update"]}]}`)
+		case strings.HasPrefix(r.URL.Path, "/apis/example.com/v1/widgets"):
+			s.lock.Lock()
+			s.requests = append(s.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+			s.lock.Unlock()
+			if r.Method == http.MethodGet {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// widgetRequests returns the widget requests the server received.
+func (s *fakeAPIServer) widgetRequests() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// newWidgetManifest returns a Widget manifest loaded from filename.
+func newWidgetManifest(t *testing.T, filename, name string) *manifest.Manifest {
+	m := &manifest.Manifest{OriginalFilename: filename}
+	if err := m.UnmarshalJSON([]byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"` + name + `"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRunLevelEndpointsGenericManifests(t *testing.T) {
+	primary, bootstrap := newFakeAPIServer(t), newFakeAPIServer(t)
+	optr := &Operator{runLevelEndpoints: map[string]RunLevelEndpoint{"05": {Config: &rest.Config{Host: bootstrap.URL}}}}
+	builder := optr.newResourceBuilder(&rest.Config{Host: primary.URL}, &rest.Config{Host: primary.URL})
+	for _, m := range []*manifest.Manifest{
+		newWidgetManifest(t, "0000_05_config-operator_01_widget.yaml", "bootstrap"),
+		newWidgetManifest(t, "0000_50_ingress_01_widget.yaml", "ingress"),
+	} {
+		if err := builder.Apply(context.Background(), m, payload.UpdatingPayload); err != nil {
+			t.Fatalf("%s: %v", m.OriginalFilename, err)
+		}
+	}
+	if want := []string{"GET /apis/example.com/v1/widgets/bootstrap?", "POST /apis/example.com/v1/widgets?"}; !reflect.DeepEqual(bootstrap.widgetRequests(), want) {
+		t.Errorf("unexpected requests to the run level endpoint:\n%v\n%v", bootstrap.widgetRequests(), want)
+	}
+	if want := []string{"GET /apis/example.com/v1/widgets/ingress?", "POST /apis/example.com/v1/widgets?"}; !reflect.DeepEqual(primary.widgetRequests(), want) {
+		t.Errorf("unexpected requests to the default endpoint:\n%v\n%v", primary.widgetRequests(), want)
+	}
+}
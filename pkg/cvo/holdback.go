@@ -0,0 +1,171 @@
+package cvo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	"github.com/openshift/cluster-version-operator/pkg/internal"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// holdBackWindowsKey is the key in the hold-back ConfigMap holding a JSON list of windows.
+const holdBackWindowsKey = "windows"
+
+// holdBackWindow names components whose manifests the CVO must not reconcile during a
+// recurring daily time window, for example to avoid ingress changes during business hours.
+// Windows are read from the cluster-version-hold-back ConfigMap in openshift-config and only
+// apply while reconciling; updates and installs are never held back.
+type holdBackWindow struct {
+	// Components are matched against the NAME in manifest filenames of the form
+	// 0000_NN_NAME_* and against manifest namespaces.
+	Components []string `json:"components"`
+	// Days restricts the window to the given weekdays (Mon, Tue, ...). Every day if empty.
+	Days []string `json:"days,omitempty"`
+	// Start and End are the HH:MM bounds of the window. If End is before Start the
+	// window spans midnight.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// TimeZone is the IANA time zone the window is defined in, UTC if empty.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	start, end time.Duration
+	location   *time.Location
+}
+
+// parseHoldBackWindows reads and validates the windows in the hold-back ConfigMap.
+func parseHoldBackWindows(cm *corev1.ConfigMap) ([]holdBackWindow, error) {
+	data, ok := cm.Data[holdBackWindowsKey]
+	if !ok || len(strings.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var windows []holdBackWindow
+	if err := json.Unmarshal([]byte(data), &windows); err != nil {
+		return nil, fmt.Errorf("%s/%s key %s is not a valid list of windows: %v", cm.Namespace, cm.Name, holdBackWindowsKey, err)
+	}
+	for i := range windows {
+		w := &windows[i]
+		if len(w.Components) == 0 {
+			return nil, fmt.Errorf("hold-back window %d must list at least one component", i)
+		}
+		var err error
+		if w.start, err = parseClock(w.Start); err != nil {
+			return nil, fmt.Errorf("hold-back window %d has an invalid start: %v", i, err)
+		}
+		if w.end, err = parseClock(w.End); err != nil {
+			return nil, fmt.Errorf("hold-back window %d has an invalid end: %v", i, err)
+		}
+		for _, day := range w.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return nil, fmt.Errorf("hold-back window %d has an invalid day %q", i, day)
+			}
+		}
+		w.location = time.UTC
+		if len(w.TimeZone) > 0 {
+			if w.location, err = time.LoadLocation(w.TimeZone); err != nil {
+				return nil, fmt.Errorf("hold-back window %d has an invalid time zone: %v", i, err)
+			}
+		}
+	}
+	return windows, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not of the form HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// active returns true if now falls within the window.
+func (w *holdBackWindow) active(now time.Time) bool {
+	now = now.In(w.location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, w.location)
+	offset := now.Sub(midnight)
+	day := now.Weekday()
+	switch {
+	case w.start == w.end:
+		return false
+	case w.start < w.end:
+		if offset < w.start || offset >= w.end {
+			return false
+		}
+	case offset >= w.start:
+		// before midnight in a window spanning midnight
+	case offset < w.end:
+		// after midnight, the window started the previous day
+		day = (day + 6) % 7
+	default:
+		return false
+	}
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if the window covers the task's component or namespace.
+func (w *holdBackWindow) matches(task *payload.Task) bool {
+	return taskMatchesComponent(task, w.Components)
+}
+
+// taskMatchesComponent returns true if one of components is the NAME in the task's manifest
+// filename of the form 0000_NN_NAME_*, or the manifest's namespace.
+func taskMatchesComponent(task *payload.Task, components []string) bool {
+	component := payload.TaskComponent(task)
+	namespace := task.Manifest.Obj.GetNamespace()
+	for _, c := range components {
+		if (len(component) > 0 && c == component) || (len(namespace) > 0 && c == namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+// heldBack returns the first window active at now that covers task, or nil.
+func heldBack(windows []holdBackWindow, task *payload.Task, now time.Time) *holdBackWindow {
+	for i := range windows {
+		if windows[i].matches(task) && windows[i].active(now) {
+			return &windows[i]
+		}
+	}
+	return nil
+}
+
+// holdBackWindows returns the administrator configured hold-back windows. Invalid
+// configuration is reported and ignored, so that a typo cannot stop reconciliation.
+func (optr *Operator) holdBackWindows() []holdBackWindow {
+	if optr.cmConfigLister == nil {
+		return nil
+	}
+	cm, err := optr.cmConfigLister.Get(internal.HoldBackConfigMap)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to read hold-back windows: %v", err))
+		return nil
+	}
+	windows, err := parseHoldBackWindows(cm)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("ignoring invalid hold-back windows: %v", err))
+		return nil
+	}
+	return windows
+}
@@ -0,0 +1,84 @@
+package cvo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/manifest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func Test_parseHoldBackWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "missing key"},
+		{name: "valid", data: map[string]string{"windows": `[{"components": ["ingress"], "days": ["Mon", "tue"], "start": "09:00", "end": "17:00", "timeZone": "UTC"}]`}, want: 1},
+		{name: "invalid json", data: map[string]string{"windows": `{`}, wantErr: true},
+		{name: "no components", data: map[string]string{"windows": `[{"start": "09:00", "end": "17:00"}]`}, wantErr: true},
+		{name: "invalid start", data: map[string]string{"windows": `[{"components": ["ingress"], "start": "9am", "end": "17:00"}]`}, wantErr: true},
+		{name: "invalid day", data: map[string]string{"windows": `[{"components": ["ingress"], "days": ["Someday"], "start": "09:00", "end": "17:00"}]`}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: "cluster-version-hold-back"}, Data: tt.data}
+			windows, err := parseHoldBackWindows(cm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(windows) != tt.want {
+				t.Fatalf("unexpected windows: %#v", windows)
+			}
+		})
+	}
+}
+
+func Test_heldBack(t *testing.T) {
+	cm := &corev1.ConfigMap{Data: map[string]string{"windows": `[
+		{"components": ["ingress"], "days": ["Mon"], "start": "09:00", "end": "17:00"},
+		{"components": ["openshift-monitoring"], "start": "22:00", "end": "02:00"}
+	]`}}
+	windows, err := parseHoldBackWindows(cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTask := func(filename, namespace string) *payload.Task {
+		obj := &unstructured.Unstructured{}
+		obj.SetNamespace(namespace)
+		return &payload.Task{Manifest: &manifest.Manifest{OriginalFilename: filename, Obj: obj}}
+	}
+	ingress := newTask("0000_50_ingress_00_deployment.yaml", "openshift-ingress-operator")
+	monitoring := newTask("0000_90_cluster-monitoring-operator_00_deployment.yaml", "openshift-monitoring")
+	// 2021-03-01 is a Monday
+	monday := func(hour, minute int) time.Time { return time.Date(2021, 3, 1, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		task *payload.Task
+		now  time.Time
+		want bool
+	}{
+		{name: "component in window", task: ingress, now: monday(10, 0), want: true},
+		{name: "component at window end", task: ingress, now: monday(17, 0)},
+		{name: "component before window", task: ingress, now: monday(8, 59)},
+		{name: "component on another day", task: ingress, now: monday(10, 0).AddDate(0, 0, 1)},
+		{name: "other component", task: monitoring, now: monday(10, 0)},
+		{name: "namespace in window before midnight", task: monitoring, now: monday(23, 0), want: true},
+		{name: "namespace in window after midnight", task: monitoring, now: monday(1, 30), want: true},
+		{name: "namespace after window", task: monitoring, now: monday(2, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := heldBack(windows, tt.task, tt.now) != nil; got != tt.want {
+				t.Fatalf("heldBack() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,45 +16,68 @@ import (
 	"k8s.io/client-go/restmapper"
 )
 
-type resourceClientFactory struct {
+// Factory returns resource clients that share one dynamic client and the discovery of the
+// API server it points to.
+type Factory struct {
 	dynamicClient dynamic.Interface
 	restMapper    *restmapper.DeferredDiscoveryRESTMapper
 }
 
 var (
-	// this stores the singleton in a package local
-	singletonFactory *resourceClientFactory
-	once             sync.Once
+	// restMappers caches the discovery of each API server, by host, for every config
+	// pointing to it
+	lock        sync.Mutex
+	restMappers = map[string]*restmapper.DeferredDiscoveryRESTMapper{}
 )
 
-// Private constructor for once.Do
-func newSingletonFactory(config *rest.Config) func() {
-	return func() {
-		cachedDiscoveryClient := memory.NewMemCacheClient(kubernetes.NewForConfigOrDie(config).Discovery())
-		restMapper := restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscoveryClient)
-		restMapper.Reset()
-
-		dynamicClient, err := dynamic.NewForConfig(config)
-		if err != nil {
-			panic(err)
-		}
+// restMapperFor returns the cached REST mapper of the API server config points to.
+func restMapperFor(config *rest.Config) (*restmapper.DeferredDiscoveryRESTMapper, error) {
+	key := config.Host + config.APIPath
+	lock.Lock()
+	defer lock.Unlock()
+	if restMapper, ok := restMappers[key]; ok {
+		return restMapper, nil
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kubeClient.Discovery()))
+	restMapper.Reset()
+	runBackgroundCacheReset(restMapper, 1*time.Minute)
+	restMappers[key] = restMapper
+	return restMapper, nil
+}
 
-		singletonFactory = &resourceClientFactory{
-			dynamicClient: dynamicClient,
-			restMapper:    restMapper,
-		}
-		singletonFactory.runBackgroundCacheReset(1 * time.Minute)
+// NewFactory returns a factory whose resource clients make their requests with config,
+// honoring its host, timeout and transport wrappers, while the discovery of each API server
+// is shared by every config pointing to it.
+func NewFactory(config *rest.Config) (*Factory, error) {
+	restMapper, err := restMapperFor(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
 	}
+	return &Factory{
+		dynamicClient: dynamicClient,
+		restMapper:    restMapper,
+	}, nil
 }
 
-// New returns the resource client using a singleton factory
+// New returns the resource client for gvk in namespace, made as NewFactory does.
 func New(config *rest.Config, gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
-	once.Do(newSingletonFactory(config))
-	return singletonFactory.getResourceClient(gvk, namespace)
+	factory, err := NewFactory(config)
+	if err != nil {
+		return nil, err
+	}
+	return factory.ResourceClient(gvk, namespace)
 }
 
-// getResourceClient returns the dynamic client for the resource specified by the gvk.
-func (c *resourceClientFactory) getResourceClient(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+// ResourceClient returns the dynamic client for the resource specified by the gvk.
+func (c *Factory) ResourceClient(gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
 	var (
 		gvr        *schema.GroupVersionResource
 		namespaced bool
@@ -94,11 +117,11 @@ func gvkToGVR(gvk schema.GroupVersionKind, restMapper *restmapper.DeferredDiscov
 
 // runBackgroundCacheReset - Starts the rest mapper cache reseting
 // at a duration given.
-func (c *resourceClientFactory) runBackgroundCacheReset(duration time.Duration) {
+func runBackgroundCacheReset(restMapper *restmapper.DeferredDiscoveryRESTMapper, duration time.Duration) {
 	ticker := time.NewTicker(duration)
 	go func() {
 		for range ticker.C {
-			c.restMapper.Reset()
+			restMapper.Reset()
 		}
 	}()
 }
@@ -29,13 +29,15 @@ func readUnstructuredV1OrDie(objBytes []byte) *unstructured.Unstructured {
 	return udi.(*unstructured.Unstructured)
 }
 
-func applyUnstructured(ctx context.Context, client dynamic.ResourceInterface, required *unstructured.Unstructured) (*unstructured.Unstructured, bool, error) {
+// applyUnstructured creates or updates required. Writes are submitted with the dryRun
+// options, such as metav1.DryRunAll, if they are set.
+func applyUnstructured(ctx context.Context, client dynamic.ResourceInterface, required *unstructured.Unstructured, dryRun []string) (*unstructured.Unstructured, bool, error) {
 	if required.GetName() == "" {
 		return nil, false, fmt.Errorf("invalid object: name cannot be empty")
 	}
 	existing, err := client.Get(ctx, required.GetName(), metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
-		actual, err := client.Create(ctx, required, metav1.CreateOptions{})
+		actual, err := client.Create(ctx, required, metav1.CreateOptions{DryRun: dryRun})
 		return actual, true, err
 	}
 	if err != nil {
@@ -57,7 +59,7 @@ func applyUnstructured(ctx context.Context, client dynamic.ResourceInterface, re
 		existing.Object[k] = v
 	}
 
-	actual, err := client.Update(ctx, existing, metav1.UpdateOptions{})
+	actual, err := client.Update(ctx, existing, metav1.UpdateOptions{DryRun: dryRun})
 	if err != nil {
 		return nil, false, err
 	}
@@ -68,6 +70,7 @@ type genericBuilder struct {
 	client   dynamic.ResourceInterface
 	raw      []byte
 	modifier resourcebuilder.MetaV1ObjectModifierFunc
+	mode     resourcebuilder.Mode
 }
 
 // NewGenericBuilder returns an implentation of resourcebuilder.Interface that
@@ -80,6 +83,7 @@ func NewGenericBuilder(client dynamic.ResourceInterface, m manifest.Manifest) (r
 }
 
 func (b *genericBuilder) WithMode(m resourcebuilder.Mode) resourcebuilder.Interface {
+	b.mode = m
 	return b
 }
 
@@ -94,8 +98,27 @@ func (b *genericBuilder) Do(ctx context.Context) error {
 		b.modifier(ud)
 	}
 
-	_, _, err := applyUnstructured(ctx, b.client, ud)
-	return err
+	// the dry-run is requested explicitly, rather than relying on the client's transport
+	var dryRun []string
+	if b.mode == resourcebuilder.DryRunMode {
+		dryRun = []string{metav1.DryRunAll}
+	}
+	if _, _, err := applyUnstructured(ctx, b.client, ud, dryRun); err != nil {
+		return err
+	}
+	return b.checkWaitConditions(ctx, ud)
+}
+
+// checkWaitConditions enforces any readiness expectations declared on the manifest.
+func (b *genericBuilder) checkWaitConditions(ctx context.Context, required *unstructured.Unstructured) error {
+	if b.mode == resourcebuilder.InitializingMode || b.mode == resourcebuilder.DryRunMode || !resourcebuilder.HasWaitConditions(required) {
+		return nil
+	}
+	actual, err := b.client.Get(ctx, required.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return resourcebuilder.CheckWaitConditions(required, actual)
 }
 
 func createPatch(original, modified runtime.Object) ([]byte, error) {
@@ -2,15 +2,22 @@ package internal
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"k8s.io/apimachinery/pkg/runtime"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
+	"github.com/openshift/library-go/pkg/manifest"
 )
 
 func TestCreateOnlyCreate(t *testing.T) {
@@ -34,7 +41,8 @@ func TestCreateOnlyCreate(t *testing.T) {
 	_, modified, err := applyUnstructured(
 		ctx,
 		fakeClient.Resource(schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "featuregates"}),
-		obj.(*unstructured.Unstructured))
+		obj.(*unstructured.Unstructured),
+		nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -79,7 +87,8 @@ func TestCreateOnlyUpdate(t *testing.T) {
 	_, modified, err := applyUnstructured(
 		ctx,
 		fakeClient.Resource(schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "featuregates"}),
-		obj.(*unstructured.Unstructured))
+		obj.(*unstructured.Unstructured),
+		nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -87,3 +96,50 @@ func TestCreateOnlyUpdate(t *testing.T) {
 		t.Error("should not have updated")
 	}
 }
+
+// dryRunRecordingClient records the dry-run options of each write to its resource.
+type dryRunRecordingClient struct {
+	dynamic.ResourceInterface
+	writes []string
+}
+
+func (c *dryRunRecordingClient) Create(ctx context.Context, obj *unstructured.Unstructured, opts metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	c.writes = append(c.writes, fmt.Sprintf("create %s dryRun=%v", obj.GetName(), opts.DryRun))
+	return c.ResourceInterface.Create(ctx, obj, opts, subresources...)
+}
+
+func (c *dryRunRecordingClient) Update(ctx context.Context, obj *unstructured.Unstructured, opts metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	c.writes = append(c.writes, fmt.Sprintf("update %s dryRun=%v", obj.GetName(), opts.DryRun))
+	return c.ResourceInterface.Update(ctx, obj, opts, subresources...)
+}
+
+func TestGenericBuilderDryRun(t *testing.T) {
+	existing := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "config.openshift.io/v1",
+		"kind":       "FeatureGate",
+		"metadata":   map[string]interface{}{"name": "existing"},
+	}}
+	gvr := schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "featuregates"}
+	for _, test := range []struct {
+		mode     resourcebuilder.Mode
+		expected []string
+	}{
+		{mode: resourcebuilder.DryRunMode, expected: []string{"create new dryRun=[All]", "update existing dryRun=[All]"}},
+		{mode: resourcebuilder.UpdatingMode, expected: []string{"create new dryRun=[]", "update existing dryRun=[]"}},
+	} {
+		client := &dryRunRecordingClient{ResourceInterface: fake.NewSimpleDynamicClient(runtime.NewScheme(), existing.DeepCopy()).Resource(gvr)}
+		for _, name := range []string{"new", "existing"} {
+			m := manifest.Manifest{Raw: []byte(`{"apiVersion":"config.openshift.io/v1","kind":"FeatureGate","metadata":{"name":"` + name + `"},"spec":{"featureSet":"TechPreviewNoUpgrade"}}`)}
+			builder, err := NewGenericBuilder(client, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := builder.WithMode(test.mode).Do(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !reflect.DeepEqual(client.writes, test.expected) {
+			t.Errorf("mode %d: unexpected writes %v, expected %v", test.mode, client.writes, test.expected)
+		}
+	}
+}
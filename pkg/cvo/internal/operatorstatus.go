@@ -4,23 +4,32 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"reflect"
 	"sort"
+	"sync"
 	"time"
 	"unicode"
 
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 
 	configv1 "github.com/openshift/api/config/v1"
 	configclientv1 "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+	configinformersv1 "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
 
 	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
+	"github.com/openshift/cluster-version-operator/pkg/operatorversions"
 	"github.com/openshift/cluster-version-operator/pkg/payload"
 	"github.com/openshift/library-go/pkg/manifest"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
@@ -28,12 +37,23 @@ var (
 	osCodecs = serializer.NewCodecFactory(osScheme)
 
 	osMapper = resourcebuilder.NewResourceMapper()
+
+	metricClusterOperatorWaitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "cluster_version_cluster_operator_wait_duration_seconds",
+		Help:    "Reports how long each wait for a cluster operator to reach the versions of the release took, by outcome, which is Done or the reason it was not done.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+	}, []string{"name", "outcome"})
+	metricClusterOperatorsWaiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cluster_version_cluster_operators_waiting",
+		Help: "Reports the cluster operators currently being waited on. The value is always 1.",
+	}, []string{"name"})
 )
 
 func init() {
 	if err := configv1.AddToScheme(osScheme); err != nil {
 		panic(err)
 	}
+	prometheus.MustRegister(metricClusterOperatorWaitDuration, metricClusterOperatorsWaiting)
 
 	osMapper.RegisterGVK(configv1.SchemeGroupVersion.WithKind("ClusterOperator"), newClusterOperatorBuilder)
 	osMapper.AddToMap(resourcebuilder.Mapper)
@@ -54,11 +74,12 @@ type clusterOperatorBuilder struct {
 	raw          []byte
 	modifier     resourcebuilder.MetaV1ObjectModifierFunc
 	mode         resourcebuilder.Mode
+	waits        *ClusterOperatorWaits
 }
 
 func newClusterOperatorBuilder(config *rest.Config, m manifest.Manifest) resourcebuilder.Interface {
 	client := configclientv1.NewForConfigOrDie(config).ClusterOperators()
-	return NewClusterOperatorBuilder(clientClusterOperatorsGetter{getter: client}, client, m)
+	return NewClusterOperatorBuilder(clientClusterOperatorsGetter{getter: client}, client, nil, m)
 }
 
 // ClusterOperatorsGetter abstracts object access with a client or a cache lister.
@@ -66,6 +87,78 @@ type ClusterOperatorsGetter interface {
 	Get(ctx context.Context, name string) (*configv1.ClusterOperator, error)
 }
 
+// ClusterOperatorWatcher is implemented by ClusterOperatorsGetters that can notify waiters when
+// a ClusterOperator changes, so they need not poll.
+type ClusterOperatorWatcher interface {
+	// Watch returns a channel that receives after the named ClusterOperator changes, and a
+	// function to call once the caller stops watching.
+	Watch(name string) (<-chan struct{}, func())
+}
+
+// InformerClusterOperatorsGetter gets ClusterOperators from an informer cache, notifying
+// watchers of changes to them.
+type InformerClusterOperatorsGetter struct {
+	lister configlistersv1.ClusterOperatorLister
+
+	lock     sync.Mutex
+	watchers map[string]map[chan struct{}]struct{}
+}
+
+// NewInformerClusterOperatorsGetter returns a ClusterOperatorsGetter backed by the informer. It
+// must be called before the informer is started.
+func NewInformerClusterOperatorsGetter(informer configinformersv1.ClusterOperatorInformer) *InformerClusterOperatorsGetter {
+	g := &InformerClusterOperatorsGetter{
+		lister:   informer.Lister(),
+		watchers: map[string]map[chan struct{}]struct{}{},
+	}
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    g.notify,
+		UpdateFunc: func(old, new interface{}) { g.notify(new) },
+		DeleteFunc: g.notify,
+	})
+	return g
+}
+
+// Get returns the named ClusterOperator from the cache.
+func (g *InformerClusterOperatorsGetter) Get(ctx context.Context, name string) (*configv1.ClusterOperator, error) {
+	return g.lister.Get(name)
+}
+
+// Watch returns a channel that receives after the named ClusterOperator changes. Changes made
+// while a receive is pending are coalesced.
+func (g *InformerClusterOperatorsGetter) Watch(name string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.watchers[name] == nil {
+		g.watchers[name] = map[chan struct{}]struct{}{}
+	}
+	g.watchers[name][ch] = struct{}{}
+	return ch, func() {
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		delete(g.watchers[name], ch)
+		if len(g.watchers[name]) == 0 {
+			delete(g.watchers, name)
+		}
+	}
+}
+
+func (g *InformerClusterOperatorsGetter) notify(obj interface{}) {
+	name, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for ch := range g.watchers[name] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
 type clientClusterOperatorsGetter struct {
 	getter configclientv1.ClusterOperatorInterface
 }
@@ -75,12 +168,13 @@ func (g clientClusterOperatorsGetter) Get(ctx context.Context, name string) (*co
 }
 
 // NewClusterOperatorBuilder accepts the ClusterOperatorsGetter interface which may be implemented by a
-// client or a lister cache.
-func NewClusterOperatorBuilder(client ClusterOperatorsGetter, createClient configclientv1.ClusterOperatorInterface, m manifest.Manifest) resourcebuilder.Interface {
+// client or a lister cache. Waits, if set, tracks how long updates wait for the operator.
+func NewClusterOperatorBuilder(client ClusterOperatorsGetter, createClient configclientv1.ClusterOperatorInterface, waits *ClusterOperatorWaits, m manifest.Manifest) resourcebuilder.Interface {
 	return &clusterOperatorBuilder{
 		client:       client,
 		createClient: createClient,
 		raw:          m.Raw,
+		waits:        waits,
 	}
 }
 
@@ -97,6 +191,11 @@ func (b *clusterOperatorBuilder) WithModifier(f resourcebuilder.MetaV1ObjectModi
 func (b *clusterOperatorBuilder) Do(ctx context.Context) error {
 	os := readClusterOperatorV1OrDie(b.raw)
 
+	// ClusterOperators are written by their operators, so there is nothing to dry-run
+	if b.mode == resourcebuilder.DryRunMode {
+		return nil
+	}
+
 	// add cluster operator's start time if not already there
 	payload.COUpdateStartTimesEnsureName(os.Name)
 
@@ -123,14 +222,33 @@ func (b *clusterOperatorBuilder) Do(ctx context.Context) error {
 		return nil
 	}
 
-	return waitForOperatorStatusToBeDone(ctx, 1*time.Second, b.client, os, b.mode)
+	return waitForOperatorStatusToBeDone(ctx, b.waits.backoff(), b.client, os, b.mode, b.waits)
+}
+
+// DefaultClusterOperatorBackoff is how often ClusterOperators are checked while waiting for
+// them, unless ClusterOperatorWaits sets a Backoff: after a second at first, then less often,
+// up to every 30 seconds, while the operator's generation and conditions stay the same.
+var DefaultClusterOperatorBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    math.MaxInt32,
+	Cap:      30 * time.Second,
 }
 
-func waitForOperatorStatusToBeDone(ctx context.Context, interval time.Duration, client ClusterOperatorsGetter, expected *configv1.ClusterOperator, mode resourcebuilder.Mode) error {
-	var lastErr error
-	err := wait.PollImmediateUntil(interval, func() (bool, error) {
-		actual, err := client.Get(ctx, expected.Name)
+// waitForOperatorStatusToBeDone checks the ClusterOperator, at intervals from backoff, until it
+// reports the expected versions and conditions. The intervals start over from backoff whenever
+// the operator's generation or conditions change. ClusterOperators are also checked whenever
+// they change if client is a ClusterOperatorWatcher. If waits is set, the error returned once
+// ctx is done reports operators that are stuck or have been waited on for too long as failing.
+func waitForOperatorStatusToBeDone(ctx context.Context, backoff wait.Backoff, client ClusterOperatorsGetter, expected *configv1.ClusterOperator, mode resourcebuilder.Mode, waits *ClusterOperatorWaits) error {
+	var lastErr *payload.UpdateError
+	var actual *configv1.ClusterOperator
+	check := func() (bool, error) {
+		var err error
+		actual, err = client.Get(ctx, expected.Name)
 		if err != nil {
+			actual = nil
 			lastErr = &payload.UpdateError{
 				Nested:       err,
 				UpdateEffect: payload.UpdateEffectNone,
@@ -143,19 +261,7 @@ func waitForOperatorStatusToBeDone(ctx context.Context, interval time.Duration,
 
 		// undone is map of operand to tuple of (expected version, actual version)
 		// for incomplete operands.
-		undone := map[string][]string{}
-		for _, expOp := range expected.Status.Versions {
-			undone[expOp.Name] = []string{expOp.Version}
-			for _, actOp := range actual.Status.Versions {
-				if actOp.Name == expOp.Name {
-					undone[expOp.Name] = append(undone[expOp.Name], actOp.Version)
-					if actOp.Version == expOp.Version {
-						delete(undone, expOp.Name)
-					}
-					break
-				}
-			}
-		}
+		undone := operatorversions.Undone(expected.Status.Versions, actual.Status.Versions)
 		if len(undone) > 0 {
 			var keys []string
 			for k := range undone {
@@ -200,6 +306,10 @@ func waitForOperatorStatusToBeDone(ctx context.Context, interval time.Duration,
 		if degradedCondition != nil {
 			degraded = degradedValue
 		}
+		// the administrator may tolerate operators they knowingly run degraded
+		if degraded && waits.toleratesDegraded(actual, degradedCondition) {
+			degraded = false
+		}
 
 		switch mode {
 		case resourcebuilder.InitializingMode:
@@ -256,14 +366,132 @@ func waitForOperatorStatusToBeDone(ctx context.Context, interval time.Duration,
 			Name:         actual.Name,
 		}
 		return false, nil
-	}, ctx.Done())
-	if err != nil {
-		if err == wait.ErrWaitTimeout && lastErr != nil {
-			return lastErr
+	}
+	var history waitErrorHistory
+	started := time.Now()
+	waited := false
+	done := func() (bool, error) {
+		ok, err := check()
+		if ok {
+			waits.done(expected.Name)
+			if waited {
+				waits.eventf(expected, actual, corev1.EventTypeNormal, "ClusterOperatorWaitSucceeded", "cluster operator %s reached the versions and conditions of the update after %s", expected.Name, time.Since(started).Round(time.Second))
+			}
+		} else if err == nil {
+			lastErr = waits.escalate(expected, actual, mode, lastErr)
+			history.observe(time.Now(), lastErr)
+			if !waited {
+				waited = true
+				waits.eventf(expected, actual, corev1.EventTypeNormal, "ClusterOperatorWaitStarted", "waiting for cluster operator %s: %s", expected.Name, lastErr.Message)
+			}
 		}
+		return ok, err
+	}
+	var observed bool
+	var generation int64
+	var conditions []configv1.ClusterOperatorStatusCondition
+	changed := func() bool {
+		var g int64
+		var c []configv1.ClusterOperatorStatusCondition
+		if actual != nil {
+			g, c = actual.Generation, actual.Status.Conditions
+		}
+		if observed && g == generation && reflect.DeepEqual(c, conditions) {
+			return false
+		}
+		observed, generation, conditions = true, g, c
+		return true
+	}
+	metricClusterOperatorsWaiting.WithLabelValues(expected.Name).Set(1)
+	var notifications <-chan struct{}
+	if watcher, ok := client.(ClusterOperatorWatcher); ok {
+		var stop func()
+		notifications, stop = watcher.Watch(expected.Name)
+		defer stop()
+	}
+	err := waitWithBackoff(ctx, backoff, notifications, done, changed)
+	if err == wait.ErrWaitTimeout && lastErr != nil {
+		err = history.attach(lastErr)
+		waits.eventf(expected, actual, corev1.EventTypeWarning, "ClusterOperatorWaitFailed", "stopped waiting for cluster operator %s after %s: %s", expected.Name, time.Since(started).Round(time.Second), lastErr.Message)
+	}
+	observeClusterOperatorWait(expected.Name, started, err)
+	return err
+}
+
+// clusterOperatorWaitHistory is how many distinct errors are retained while waiting for a
+// ClusterOperator.
+const clusterOperatorWaitHistory = 5
+
+// waitErrorHistory retains the most recent distinct errors observed while waiting for a
+// ClusterOperator, so that a failed wait explains more than the last thing that went wrong.
+type waitErrorHistory []payload.ErrorObservation
+
+// observe records err, observed at now, unless it repeats the most recently observed error.
+func (h *waitErrorHistory) observe(now time.Time, err *payload.UpdateError) {
+	if err == nil {
+		return
+	}
+	observation := payload.ErrorObservation{Time: now, Reason: err.Reason, Message: err.Message}
+	if err.Nested != nil {
+		observation.Message = fmt.Sprintf("%s: %v", err.Message, err.Nested)
+	}
+	if last := len(*h) - 1; last >= 0 && (*h)[last].Reason == observation.Reason && (*h)[last].Message == observation.Message {
+		return
+	}
+	if len(*h) >= clusterOperatorWaitHistory {
+		*h = append((*h)[:0], (*h)[len(*h)-clusterOperatorWaitHistory+1:]...)
+	}
+	*h = append(*h, observation)
+}
+
+// attach returns err with the observed history, if more than one distinct error was observed.
+func (h waitErrorHistory) attach(err *payload.UpdateError) *payload.UpdateError {
+	if len(h) < 2 {
 		return err
 	}
-	return nil
+	withHistory := *err
+	withHistory.History = append([]payload.ErrorObservation(nil), h...)
+	return &withHistory
+}
+
+// observeClusterOperatorWait records that the wait for the named operator, begun at started,
+// finished with err.
+func observeClusterOperatorWait(name string, started time.Time, err error) {
+	metricClusterOperatorsWaiting.DeleteLabelValues(name)
+	outcome := "Done"
+	if err != nil {
+		outcome = "Cancelled"
+		var uErr *payload.UpdateError
+		if errors.As(err, &uErr) {
+			outcome = uErr.Reason
+		}
+	}
+	metricClusterOperatorWaitDuration.WithLabelValues(name, outcome).Observe(time.Since(started).Seconds())
+}
+
+// waitWithBackoff calls done immediately, then whenever notifications receives or the next
+// interval from backoff passes, until done returns true. The intervals start over whenever
+// changed returns true after a call to done. It returns wait.ErrWaitTimeout if ctx is done
+// first.
+func waitWithBackoff(ctx context.Context, backoff wait.Backoff, notifications <-chan struct{}, done wait.ConditionFunc, changed func() bool) error {
+	current := backoff
+	for {
+		if ok, err := done(); err != nil || ok {
+			return err
+		}
+		if changed() {
+			current = backoff
+		}
+		timer := time.NewTimer(current.Step())
+		select {
+		case <-notifications:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return wait.ErrWaitTimeout
+		}
+		timer.Stop()
+	}
 }
 
 func lowerFirst(str string) string {
@@ -2,19 +2,24 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	clientgotesting "k8s.io/client-go/testing"
 
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/client-go/config/clientset/versioned/fake"
+	"github.com/openshift/client-go/config/informers/externalversions"
 	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
 	"github.com/openshift/cluster-version-operator/pkg/payload"
 )
@@ -493,7 +498,7 @@ func Test_waitForOperatorStatusToBeDone(t *testing.T) {
 
 			ctxWithTimeout, cancel := context.WithTimeout(context.TODO(), 1*time.Millisecond)
 			defer cancel()
-			err := waitForOperatorStatusToBeDone(ctxWithTimeout, 1*time.Millisecond, clientClusterOperatorsGetter{getter: client.ConfigV1().ClusterOperators()}, test.exp, test.mode)
+			err := waitForOperatorStatusToBeDone(ctxWithTimeout, wait.Backoff{Duration: time.Millisecond}, clientClusterOperatorsGetter{getter: client.ConfigV1().ClusterOperators()}, test.exp, test.mode, nil)
 			if (test.expErr == nil) != (err == nil) {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -504,3 +509,145 @@ func Test_waitForOperatorStatusToBeDone(t *testing.T) {
 	}
 
 }
+
+func Test_observeClusterOperatorWait(t *testing.T) {
+	metricClusterOperatorsWaiting.WithLabelValues("metrics-co").Set(1)
+	observeClusterOperatorWait("metrics-co", time.Now().Add(-time.Minute), &payload.UpdateError{Reason: "ClusterOperatorDegraded", Name: "metrics-co"})
+	observeClusterOperatorWait("metrics-co", time.Now().Add(-time.Minute), context.Canceled)
+	observeClusterOperatorWait("metrics-co", time.Now().Add(-2*time.Minute), nil)
+
+	for outcome, expected := range map[string]uint64{"ClusterOperatorDegraded": 1, "Cancelled": 1, "Done": 1} {
+		m := &dto.Metric{}
+		if err := metricClusterOperatorWaitDuration.WithLabelValues("metrics-co", outcome).(prometheus.Histogram).Write(m); err != nil {
+			t.Fatal(err)
+		}
+		if count := m.GetHistogram().GetSampleCount(); count != expected {
+			t.Errorf("unexpected %s count %d, expected %d", outcome, count, expected)
+		}
+		if sum := m.GetHistogram().GetSampleSum(); sum < 60 {
+			t.Errorf("unexpected %s duration %v", outcome, sum)
+		}
+	}
+	if deleted := metricClusterOperatorsWaiting.DeleteLabelValues("metrics-co"); deleted {
+		t.Error("expected the operator to no longer be waited on")
+	}
+}
+
+func Test_waitErrorHistory(t *testing.T) {
+	var history waitErrorHistory
+	now := time.Unix(0, 0)
+	notFound := &payload.UpdateError{Reason: "ClusterOperatorNotAvailable", Message: "Cluster operator ingress has not yet reported success", Nested: errors.New("not found"), Name: "ingress"}
+	degraded := &payload.UpdateError{Reason: "ClusterOperatorDegraded", Message: "Cluster operator ingress is degraded", Name: "ingress"}
+
+	history.observe(now, notFound)
+	if err := history.attach(notFound); err != notFound {
+		t.Fatalf("unexpected history for a single observation: %#v", err.History)
+	}
+	history.observe(now.Add(time.Minute), notFound)
+	history.observe(now.Add(2*time.Minute), degraded)
+	expected := waitErrorHistory{
+		{Time: now, Reason: "ClusterOperatorNotAvailable", Message: "Cluster operator ingress has not yet reported success: not found"},
+		{Time: now.Add(2 * time.Minute), Reason: "ClusterOperatorDegraded", Message: "Cluster operator ingress is degraded"},
+	}
+	if !reflect.DeepEqual(history, expected) {
+		t.Fatalf("unexpected history:\n%#v\nexpected:\n%#v", history, expected)
+	}
+	if err := history.attach(degraded); !reflect.DeepEqual(err.History, []payload.ErrorObservation(expected)) || degraded.History != nil {
+		t.Fatalf("unexpected attached history: %#v", err.History)
+	}
+
+	// only the most recent distinct errors are retained
+	for i := 0; i < clusterOperatorWaitHistory; i++ {
+		history.observe(now.Add(time.Duration(3+i)*time.Minute), notFound)
+		history.observe(now.Add(time.Duration(3+i)*time.Minute), degraded)
+	}
+	if len(history) != clusterOperatorWaitHistory || history[len(history)-1].Reason != "ClusterOperatorDegraded" || !history[0].Time.Equal(now.Add(5*time.Minute)) {
+		t.Fatalf("unexpected retained history: %#v", history)
+	}
+}
+
+func TestInformerClusterOperatorsGetter(t *testing.T) {
+	co := &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: "test-co"}}
+	client := fake.NewSimpleClientset(co)
+	informers := externalversions.NewSharedInformerFactory(client, 0)
+	getter := NewInformerClusterOperatorsGetter(informers.Config().V1().ClusterOperators())
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	informers.Start(ctx.Done())
+	informers.WaitForCacheSync(ctx.Done())
+
+	expected := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-co"},
+		Status:     configv1.ClusterOperatorStatus{Versions: []configv1.OperandVersion{{Name: "operator", Version: "v1"}}},
+	}
+	result := make(chan error, 1)
+	go func() {
+		// the interval is long enough that only a change notification ends the wait
+		result <- waitForOperatorStatusToBeDone(ctx, wait.Backoff{Duration: time.Hour}, getter, expected, resourcebuilder.UpdatingMode, nil)
+	}()
+
+	select {
+	case err := <-result:
+		t.Fatalf("unexpected result before the operator updated: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	co = co.DeepCopy()
+	co.Status = configv1.ClusterOperatorStatus{
+		Versions: []configv1.OperandVersion{{Name: "operator", Version: "v1"}},
+		Conditions: []configv1.ClusterOperatorStatusCondition{
+			{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+			{Type: configv1.OperatorDegraded, Status: configv1.ConditionFalse},
+		},
+	}
+	if _, err := client.ConfigV1().ClusterOperators().UpdateStatus(ctx, co, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	getter.lock.Lock()
+	defer getter.lock.Unlock()
+	if len(getter.watchers) != 0 {
+		t.Fatalf("expected watchers to be removed: %v", getter.watchers)
+	}
+}
+
+func Test_waitWithBackoff(t *testing.T) {
+	backoff := wait.Backoff{Duration: 10 * time.Millisecond, Factor: 2, Steps: 100, Cap: time.Second}
+	count := func(changed bool) int {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		calls := 0
+		done := func() (bool, error) {
+			calls++
+			return false, nil
+		}
+		if err := waitWithBackoff(ctx, backoff, nil, done, func() bool { return changed }); err != wait.ErrWaitTimeout {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return calls
+	}
+
+	// checks at 0, 10, 30, 70, and 150ms
+	if calls := count(false); calls < 3 || calls > 8 {
+		t.Fatalf("unexpected %d checks of an operator that does not change", calls)
+	}
+	// every change starts the intervals over
+	if calls := count(true); calls < 12 {
+		t.Fatalf("unexpected %d checks of an operator that keeps changing", calls)
+	}
+
+	// notifications check the operator without waiting for the interval
+	notifications := make(chan struct{}, 1)
+	notifications <- struct{}{}
+	calls := 0
+	done := func() (bool, error) {
+		calls++
+		return calls == 2, nil
+	}
+	if err := waitWithBackoff(context.Background(), wait.Backoff{Duration: time.Hour}, notifications, done, func() bool { return false }); err != nil || calls != 2 {
+		t.Fatalf("unexpected %d checks: %v", calls, err)
+	}
+}
@@ -0,0 +1,339 @@
+package internal
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	coreclientsetv1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
+	"github.com/openshift/cluster-version-operator/pkg/internal"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// WaitTimeoutAnnotation on a ClusterOperator manifest overrides how long updates wait for
+// that operator before the update is reported as failing, as a duration such as "90m".
+const WaitTimeoutAnnotation = "release.openshift.io/wait-timeout"
+
+// ClusterOperatorWaits tracks how long updates have been waiting for each ClusterOperator
+// across sync attempts, so that operators which take too long, or which stop changing their
+// versions and conditions altogether, are reported as failing instead of as still updating.
+// A nil ClusterOperatorWaits tracks nothing.
+type ClusterOperatorWaits struct {
+	// Timeout, if positive, is how long a ClusterOperator may be waited on before the
+	// update is reported as failing. The WaitTimeoutAnnotation overrides it.
+	Timeout time.Duration
+
+	// StuckAfter, if positive, is how long a ClusterOperator may go without changing its
+	// versions or conditions during an update before it is reported as stuck.
+	StuckAfter time.Duration
+
+	// Backoff, if it has a Duration, is how often ClusterOperators are checked while waiting
+	// for them, instead of DefaultClusterOperatorBackoff.
+	Backoff wait.Backoff
+
+	// Recorder, if set, records events about the waits: ClusterOperatorWaitStarted,
+	// ClusterOperatorWaitSucceeded and ClusterOperatorWaitFailed for waits that were not
+	// done at once, and ClusterOperatorStuck when an operator is first found to be stuck.
+	// Events records them on Ref unless it routes them to the ClusterOperator.
+	Recorder record.EventRecorder
+	Ref      *corev1.ObjectReference
+	Events   EventRouting
+
+	// now returns the current time, and is replaced in tests.
+	now func() time.Time
+
+	lock  sync.Mutex
+	waits map[string]*operatorWait
+
+	// resumed are when an earlier operator began waiting for each ClusterOperator, which the
+	// next wait for it counts from.
+	resumed map[string]time.Time
+
+	// degradedTolerated are the ClusterOperators whose Degraded=True condition does not
+	// block the update, and tolerated the Degraded message last recorded for each of them.
+	degradedTolerated map[string]struct{}
+	tolerated         map[string]string
+}
+
+// EventRouting selects the objects that the events of ClusterOperator waits are recorded on.
+type EventRouting string
+
+const (
+	// EventsOnClusterVersion records wait events on the ClusterVersion. It is the default.
+	EventsOnClusterVersion EventRouting = "ClusterVersion"
+	// EventsOnClusterOperator records wait events on the ClusterOperator waited on, so
+	// they are shown with the operator by oc describe.
+	EventsOnClusterOperator EventRouting = "ClusterOperator"
+	// EventsInOperatorNamespace records wait events on the ClusterOperator, and again in
+	// the operator's namespace, the first namespace among its related objects.
+	EventsInOperatorNamespace EventRouting = "OperatorNamespace"
+)
+
+// ParseEventRouting parses value, which must be ClusterVersion, ClusterOperator or
+// OperatorNamespace. An empty value is ClusterVersion.
+func ParseEventRouting(value string) (EventRouting, error) {
+	switch routing := EventRouting(value); routing {
+	case "":
+		return EventsOnClusterVersion, nil
+	case EventsOnClusterVersion, EventsOnClusterOperator, EventsInOperatorNamespace:
+		return routing, nil
+	}
+	return "", fmt.Errorf("cluster operator wait events must be recorded on %s, %s or %s, not %q", EventsOnClusterVersion, EventsOnClusterOperator, EventsInOperatorNamespace, value)
+}
+
+// operatorWait is the progress of an operator towards the versions an update expects.
+type operatorWait struct {
+	expected []configv1.OperandVersion
+	since    time.Time
+
+	observed   configv1.ClusterOperatorStatus
+	lastChange time.Time
+	reported   bool
+}
+
+// backoff returns how often ClusterOperators are checked while waiting for them.
+func (w *ClusterOperatorWaits) backoff() wait.Backoff {
+	if w == nil || w.Backoff.Duration <= 0 {
+		return DefaultClusterOperatorBackoff
+	}
+	return w.Backoff
+}
+
+// timeoutFor returns how long the expected ClusterOperator may be waited on.
+func (w *ClusterOperatorWaits) timeoutFor(expected *configv1.ClusterOperator) time.Duration {
+	value, ok := expected.Annotations[WaitTimeoutAnnotation]
+	if !ok {
+		return w.Timeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		klog.Warningf("Ignoring invalid %s annotation %q on cluster operator %s", WaitTimeoutAnnotation, value, expected.Name)
+		return w.Timeout
+	}
+	return timeout
+}
+
+// escalate records what the sync in mode has observed of the expected ClusterOperator, which
+// is nil if it could not be retrieved, and returns err, the reason the operator is not done,
+// or a failing error if the operator has been waited on for longer than its timeout or, while
+// updating, is stuck.
+func (w *ClusterOperatorWaits) escalate(expected, actual *configv1.ClusterOperator, mode resourcebuilder.Mode, err *payload.UpdateError) *payload.UpdateError {
+	if w == nil || err == nil {
+		return err
+	}
+	now := time.Now()
+	if w.now != nil {
+		now = w.now()
+	}
+	timeout := w.timeoutFor(expected)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.waits == nil {
+		w.waits = map[string]*operatorWait{}
+	}
+	wait, ok := w.waits[expected.Name]
+	if !ok || !reflect.DeepEqual(wait.expected, expected.Status.Versions) {
+		wait = &operatorWait{expected: expected.Status.Versions, since: now, lastChange: now}
+		if since, ok := w.resumed[expected.Name]; ok && since.Before(now) {
+			wait.since = since
+		}
+		delete(w.resumed, expected.Name)
+		w.waits[expected.Name] = wait
+	}
+	var observed configv1.ClusterOperatorStatus
+	if actual != nil {
+		observed.Versions = actual.Status.Versions
+		observed.Conditions = actual.Status.Conditions
+	}
+	if !reflect.DeepEqual(wait.observed, observed) {
+		wait.observed = *observed.DeepCopy()
+		wait.lastChange = now
+		wait.reported = false
+	}
+
+	// operators are only expected to keep changing while they are updated
+	if unchanged := now.Sub(wait.lastChange); mode == resourcebuilder.UpdatingMode && w.StuckAfter > 0 && unchanged >= w.StuckAfter {
+		message := fmt.Sprintf("Cluster operator %s has not changed its versions or conditions for %s", expected.Name, unchanged.Round(time.Second))
+		if !wait.reported {
+			w.eventf(expected, actual, corev1.EventTypeWarning, "ClusterOperatorStuck", "%s: %s", lowerFirst(message), err.Message)
+		}
+		wait.reported = true
+		return &payload.UpdateError{
+			Nested:       err.Nested,
+			UpdateEffect: payload.UpdateEffectFail,
+			Reason:       "ClusterOperatorStuck",
+			Message:      message,
+			Name:         expected.Name,
+		}
+	}
+	if waited := now.Sub(wait.since); timeout > 0 && waited >= timeout {
+		escalated := *err
+		escalated.UpdateEffect = payload.UpdateEffectFail
+		escalated.Message = fmt.Sprintf("%s after waiting %s", err.Message, waited.Round(time.Second))
+		return &escalated
+	}
+	return err
+}
+
+// done forgets the named operator, which has reached the versions the update expects.
+func (w *ClusterOperatorWaits) done(name string) {
+	if w == nil {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	delete(w.waits, name)
+}
+
+// Started returns when the update began waiting for each ClusterOperator it is waiting on.
+func (w *ClusterOperatorWaits) Started() map[string]time.Time {
+	if w == nil {
+		return nil
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	started := make(map[string]time.Time, len(w.waits))
+	for name, wait := range w.waits {
+		started[name] = wait.since
+	}
+	return started
+}
+
+// Resume makes the next wait for each named ClusterOperator count from when an earlier
+// operator began waiting for it, as returned by its Started, so that a restarted operator
+// does not extend the Timeout of the waits it takes over. Operators already being waited on
+// are not changed.
+func (w *ClusterOperatorWaits) Resume(started map[string]time.Time) {
+	if w == nil {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.resumed = make(map[string]time.Time, len(started))
+	for name, since := range started {
+		if _, ok := w.waits[name]; !ok {
+			w.resumed[name] = since
+		}
+	}
+}
+
+// TolerateDegraded makes Degraded=True on the named ClusterOperators no longer block the
+// update, replacing any operators previously tolerated.
+func (w *ClusterOperatorWaits) TolerateDegraded(names []string) {
+	if w == nil {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.degradedTolerated = make(map[string]struct{}, len(names))
+	for _, name := range names {
+		w.degradedTolerated[name] = struct{}{}
+	}
+	for name := range w.tolerated {
+		if _, ok := w.degradedTolerated[name]; !ok {
+			delete(w.tolerated, name)
+		}
+	}
+}
+
+// toleratesDegraded returns true if the update should proceed although the ClusterOperator
+// reports condition, its Degraded=True condition. The tolerance is recorded as a
+// DegradedClusterOperatorTolerated event whenever the condition's message changes.
+func (w *ClusterOperatorWaits) toleratesDegraded(actual *configv1.ClusterOperator, condition *configv1.ClusterOperatorStatusCondition) bool {
+	if w == nil || condition == nil {
+		return false
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.degradedTolerated[actual.Name]; !ok {
+		return false
+	}
+	message := fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+	if reported, ok := w.tolerated[actual.Name]; !ok || reported != message {
+		klog.Warningf("Tolerating degraded cluster operator %s (%s)", actual.Name, message)
+		w.eventf(actual, actual, corev1.EventTypeWarning, "DegradedClusterOperatorTolerated", "cluster operator %s is degraded, which the %s annotation tolerates: %s", actual.Name, internal.ToleratedDegradedOperatorsAnnotation, message)
+		if w.tolerated == nil {
+			w.tolerated = map[string]string{}
+		}
+		w.tolerated[actual.Name] = message
+	}
+	return true
+}
+
+// eventf records an event about the wait for the expected ClusterOperator, which is actual if
+// it could be retrieved, on the objects Events selects.
+func (w *ClusterOperatorWaits) eventf(expected, actual *configv1.ClusterOperator, eventType, reason, messageFmt string, args ...interface{}) {
+	if w == nil || w.Recorder == nil {
+		return
+	}
+	switch w.Events {
+	case EventsOnClusterOperator, EventsInOperatorNamespace:
+		co := expected
+		if actual != nil {
+			co = actual
+		}
+		ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterOperator", Name: co.Name, UID: co.UID}
+		w.Recorder.Eventf(ref, eventType, reason, messageFmt, args...)
+		if w.Events == EventsInOperatorNamespace {
+			if namespace := operatorNamespace(co); len(namespace) > 0 {
+				namespaced := *ref
+				namespaced.Namespace = namespace
+				w.Recorder.Eventf(&namespaced, eventType, reason, messageFmt, args...)
+			}
+		}
+	default:
+		if w.Ref != nil {
+			w.Recorder.Eventf(w.Ref, eventType, reason, messageFmt, args...)
+		}
+	}
+}
+
+// operatorNamespace returns the first namespace among the related objects of the
+// ClusterOperator, which is conventionally the namespace its operator runs in.
+func operatorNamespace(co *configv1.ClusterOperator) string {
+	for _, related := range co.Status.RelatedObjects {
+		if related.Group == "" && related.Resource == "namespaces" {
+			return related.Name
+		}
+	}
+	return ""
+}
+
+// NewEventRecorder returns a recorder for the events of ClusterOperator waits, which may be
+// recorded outside the operator's namespace: in the namespaces of the operators waited on, and
+// in the default namespace for the cluster-scoped ClusterOperators themselves.
+func NewEventRecorder(client coreclientsetv1.EventsGetter, component string) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(klog.Infof)
+	broadcaster.StartRecordingToSink(&eventSink{client: client})
+	return broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component})
+}
+
+// eventSink writes each event in the namespace of the event, rather than through a client
+// scoped to a single namespace, which rejects events for other namespaces.
+type eventSink struct {
+	client coreclientsetv1.EventsGetter
+}
+
+func (s *eventSink) Create(event *corev1.Event) (*corev1.Event, error) {
+	return s.client.Events(event.Namespace).CreateWithEventNamespace(event)
+}
+
+func (s *eventSink) Update(event *corev1.Event) (*corev1.Event, error) {
+	return s.client.Events(event.Namespace).UpdateWithEventNamespace(event)
+}
+
+func (s *eventSink) Patch(event *corev1.Event, data []byte) (*corev1.Event, error) {
+	return s.client.Events(event.Namespace).PatchWithEventNamespace(event, data)
+}
@@ -0,0 +1,322 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilwait "k8s.io/apimachinery/pkg/util/wait"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/client-go/config/clientset/versioned/fake"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func TestClusterOperatorWaits(t *testing.T) {
+	now := time.Unix(0, 0)
+	recorder := record.NewFakeRecorder(10)
+	waits := &ClusterOperatorWaits{
+		Timeout:    time.Hour,
+		StuckAfter: 20 * time.Minute,
+		Recorder:   recorder,
+		Ref:        &corev1.ObjectReference{Kind: "ClusterVersion", Name: "version"},
+		now:        func() time.Time { return now },
+	}
+	expected := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "network"},
+		Status:     configv1.ClusterOperatorStatus{Versions: []configv1.OperandVersion{{Name: "operator", Version: "v2"}}},
+	}
+	actual := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "network"},
+		Status: configv1.ClusterOperatorStatus{
+			Versions:   []configv1.OperandVersion{{Name: "operator", Version: "v1"}},
+			Conditions: []configv1.ClusterOperatorStatusCondition{{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue, Message: "rolling out 1 of 3"}},
+		},
+	}
+	updating := &payload.UpdateError{
+		Nested:       errors.New("cluster operator network is still updating"),
+		UpdateEffect: payload.UpdateEffectNone,
+		Reason:       "ClusterOperatorNotAvailable",
+		Message:      "Cluster operator network is still updating",
+		Name:         "network",
+	}
+
+	// an operator that keeps changing is only waited on
+	if err := waits.escalate(expected, actual, resourcebuilder.UpdatingMode, updating); err != updating {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	now = now.Add(15 * time.Minute)
+	actual.Status.Conditions[0].Message = "rolling out 2 of 3"
+	if err := waits.escalate(expected, actual, resourcebuilder.UpdatingMode, updating); err != updating {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	// an operator that stops changing is stuck, and reported by event once
+	for i := 0; i < 2; i++ {
+		now = now.Add(20 * time.Minute)
+		err := waits.escalate(expected, actual.DeepCopy(), resourcebuilder.UpdatingMode, updating)
+		if err.Reason != "ClusterOperatorStuck" || err.UpdateEffect != payload.UpdateEffectFail || err.Name != "network" {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	}
+	if message, expected := waits.escalate(expected, actual, resourcebuilder.UpdatingMode, updating).Message, "Cluster operator network has not changed its versions or conditions for 40m0s"; message != expected {
+		t.Fatalf("unexpected message %q, expected %q", message, expected)
+	}
+	if event, expected := <-recorder.Events, "Warning ClusterOperatorStuck cluster operator network has not changed its versions or conditions for 20m0s: Cluster operator network is still updating"; event != expected {
+		t.Fatalf("unexpected event:\n%s\nexpected:\n%s", event, expected)
+	}
+	select {
+	case event := <-recorder.Events:
+		t.Fatalf("unexpected repeated event: %s", event)
+	default:
+	}
+
+	// once it changes again, it has only been waited on too long
+	now = now.Add(21 * time.Minute)
+	actual.Status.Conditions[0].Message = "rolling out 3 of 3"
+	err := waits.escalate(expected, actual, resourcebuilder.UpdatingMode, updating)
+	if err.Reason != "ClusterOperatorNotAvailable" || err.UpdateEffect != payload.UpdateEffectFail || err.Message != "Cluster operator network is still updating after waiting 1h16m0s" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if updating.UpdateEffect != payload.UpdateEffectNone {
+		t.Fatalf("escalation modified the original error: %#v", updating)
+	}
+
+	// operators are not expected to change while the payload is reconciled or initialized
+	for _, mode := range []resourcebuilder.Mode{resourcebuilder.ReconcilingMode, resourcebuilder.InitializingMode} {
+		waits.done("network")
+		waits.Timeout = 0
+		waits.escalate(expected, actual, mode, updating)
+		now = now.Add(time.Hour)
+		if err := waits.escalate(expected, actual, mode, updating); err != updating {
+			t.Fatalf("unexpected escalation in mode %d: %#v", mode, err)
+		}
+		select {
+		case event := <-recorder.Events:
+			t.Fatalf("unexpected event in mode %d: %s", mode, event)
+		default:
+		}
+	}
+	waits.Timeout = time.Hour
+
+	// completing forgets the wait, so the next update starts over
+	waits.done("network")
+	if err := waits.escalate(expected, actual, resourcebuilder.UpdatingMode, updating); err != updating {
+		t.Fatalf("unexpected error after completion: %#v", err)
+	}
+}
+
+func TestClusterOperatorWaitsTimeoutAnnotation(t *testing.T) {
+	waits := &ClusterOperatorWaits{Timeout: time.Hour}
+	for _, test := range []struct {
+		annotation string
+		expected   time.Duration
+	}{
+		{annotation: "", expected: time.Hour},
+		{annotation: "90m", expected: 90 * time.Minute},
+		{annotation: "0s", expected: 0},
+		{annotation: "soon", expected: time.Hour},
+		{annotation: "-1h", expected: time.Hour},
+	} {
+		co := &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: "network"}}
+		if len(test.annotation) > 0 {
+			co.Annotations = map[string]string{WaitTimeoutAnnotation: test.annotation}
+		}
+		if timeout := waits.timeoutFor(co); timeout != test.expected {
+			t.Errorf("annotation %q: expected timeout %s, got %s", test.annotation, test.expected, timeout)
+		}
+	}
+}
+
+func TestClusterOperatorWaitsResume(t *testing.T) {
+	now := time.Unix(0, 0).Add(time.Hour)
+	waits := &ClusterOperatorWaits{Timeout: 90 * time.Minute, now: func() time.Time { return now }}
+	expected := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "network"},
+		Status:     configv1.ClusterOperatorStatus{Versions: []configv1.OperandVersion{{Name: "operator", Version: "v2"}}},
+	}
+	updating := &payload.UpdateError{Reason: "ClusterOperatorNotAvailable", Message: "Cluster operator network is still updating", Name: "network"}
+
+	// a resumed wait counts from when the earlier operator began it
+	waits.Resume(map[string]time.Time{"network": time.Unix(0, 0)})
+	if err := waits.escalate(expected, nil, resourcebuilder.UpdatingMode, updating); err != updating {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if started, expected := waits.Started(), map[string]time.Time{"network": time.Unix(0, 0)}; !reflect.DeepEqual(started, expected) {
+		t.Fatalf("unexpected started %v, expected %v", started, expected)
+	}
+	now = now.Add(30 * time.Minute)
+	if err := waits.escalate(expected, nil, resourcebuilder.UpdatingMode, updating); err.UpdateEffect != payload.UpdateEffectFail || err.Message != "Cluster operator network is still updating after waiting 1h30m0s" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	// once done, the next wait starts over
+	waits.done("network")
+	if err := waits.escalate(expected, nil, resourcebuilder.UpdatingMode, updating); err != updating {
+		t.Fatalf("unexpected error after completion: %#v", err)
+	}
+	if started := waits.Started(); !started["network"].Equal(now) {
+		t.Fatalf("unexpected started %v", started)
+	}
+}
+
+func TestClusterOperatorWaitsTolerateDegraded(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	waits := &ClusterOperatorWaits{Recorder: recorder, Ref: &corev1.ObjectReference{Kind: "ClusterVersion", Name: "version"}}
+	expected := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "image-registry"},
+		Status:     configv1.ClusterOperatorStatus{Versions: []configv1.OperandVersion{{Name: "operator", Version: "v2"}}},
+	}
+	actual := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "image-registry"},
+		Status: configv1.ClusterOperatorStatus{
+			Versions: []configv1.OperandVersion{{Name: "operator", Version: "v2"}},
+			Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+				{Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue, Reason: "StorageNotConfigured", Message: "storage backend not configured"},
+			},
+		},
+	}
+	getter := clientClusterOperatorsGetter{getter: fake.NewSimpleClientset(actual).ConfigV1().ClusterOperators()}
+	wait := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		return waitForOperatorStatusToBeDone(ctx, utilwait.Backoff{Duration: time.Millisecond}, getter, expected, resourcebuilder.UpdatingMode, waits)
+	}
+
+	if err := wait(); !errors.Is(err, payload.ErrClusterOperatorDegraded) {
+		t.Fatalf("expected the degraded operator to block the update, got %v", err)
+	}
+	for _, prefix := range []string{
+		"Normal ClusterOperatorWaitStarted waiting for cluster operator image-registry: Cluster operator image-registry is degraded",
+		"Warning ClusterOperatorWaitFailed stopped waiting for cluster operator image-registry after ",
+	} {
+		if event := <-recorder.Events; !strings.HasPrefix(event, prefix) {
+			t.Fatalf("unexpected event:\n%s\nexpected:\n%s", event, prefix)
+		}
+	}
+
+	waits.TolerateDegraded([]string{"image-registry"})
+	for i := 0; i < 2; i++ {
+		if err := wait(); err != nil {
+			t.Fatalf("unexpected error for a tolerated operator: %v", err)
+		}
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected the tolerance to be recorded once, got %d events", len(recorder.Events))
+	}
+	if event, expected := <-recorder.Events, "Warning DegradedClusterOperatorTolerated cluster operator image-registry is degraded, which the release.openshift.io/tolerated-degraded-operators annotation tolerates: StorageNotConfigured: storage backend not configured"; event != expected {
+		t.Fatalf("unexpected event:\n%s\nexpected:\n%s", event, expected)
+	}
+
+	waits.TolerateDegraded(nil)
+	if err := wait(); !errors.Is(err, payload.ErrClusterOperatorDegraded) {
+		t.Fatalf("expected the degraded operator to block the update once no longer tolerated, got %v", err)
+	}
+}
+
+func TestClusterOperatorWaitsEvents(t *testing.T) {
+	co := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "network", UID: "uid"},
+		Status: configv1.ClusterOperatorStatus{
+			RelatedObjects: []configv1.ObjectReference{
+				{Group: "operator.openshift.io", Resource: "networks", Name: "cluster"},
+				{Resource: "namespaces", Name: "openshift-network-operator"},
+			},
+		},
+	}
+	tests := []struct {
+		routing  EventRouting
+		expected []corev1.ObjectReference
+	}{
+		{
+			expected: []corev1.ObjectReference{{Kind: "ClusterVersion", Name: "version"}},
+		},
+		{
+			routing:  EventsOnClusterOperator,
+			expected: []corev1.ObjectReference{{APIVersion: "config.openshift.io/v1", Kind: "ClusterOperator", Name: "network", UID: "uid"}},
+		},
+		{
+			routing: EventsInOperatorNamespace,
+			expected: []corev1.ObjectReference{
+				{APIVersion: "config.openshift.io/v1", Kind: "ClusterOperator", Name: "network", UID: "uid"},
+				{APIVersion: "config.openshift.io/v1", Kind: "ClusterOperator", Name: "network", Namespace: "openshift-network-operator", UID: "uid"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.routing), func(t *testing.T) {
+			recorder := &referenceRecorder{}
+			waits := &ClusterOperatorWaits{Recorder: recorder, Ref: &corev1.ObjectReference{Kind: "ClusterVersion", Name: "version"}, Events: tc.routing}
+			waits.eventf(co, nil, corev1.EventTypeNormal, "ClusterOperatorWaitStarted", "waiting for cluster operator %s", co.Name)
+			if !reflect.DeepEqual(recorder.refs, tc.expected) {
+				t.Fatalf("unexpected event references %#v, expected %#v", recorder.refs, tc.expected)
+			}
+		})
+	}
+
+	if _, err := ParseEventRouting("Namespace"); err == nil {
+		t.Fatal("expected an unknown event routing to be rejected")
+	}
+}
+
+func TestClusterOperatorWaitsEventsRecorded(t *testing.T) {
+	co := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "network", UID: "uid"},
+		Status: configv1.ClusterOperatorStatus{
+			RelatedObjects: []configv1.ObjectReference{{Resource: "namespaces", Name: "openshift-network-operator"}},
+		},
+	}
+	kubeClient := kfake.NewSimpleClientset()
+	waits := &ClusterOperatorWaits{
+		Recorder: NewEventRecorder(kubeClient.CoreV1(), "openshift-cluster-version"),
+		Ref:      &corev1.ObjectReference{Kind: "ClusterVersion", Name: "version", Namespace: "openshift-cluster-version"},
+		Events:   EventsInOperatorNamespace,
+	}
+	waits.eventf(co, nil, corev1.EventTypeNormal, "ClusterOperatorWaitStarted", "waiting for cluster operator %s", co.Name)
+	waits.Events = EventsOnClusterVersion
+	waits.eventf(co, nil, corev1.EventTypeNormal, "ClusterOperatorWaitStarted", "waiting for cluster operator %s", co.Name)
+
+	expected := map[string]string{
+		"default":                    "ClusterOperator",
+		"openshift-network-operator": "ClusterOperator",
+		"openshift-cluster-version":  "ClusterVersion",
+	}
+	var recorded map[string]string
+	err := utilwait.PollImmediate(10*time.Millisecond, utilwait.ForeverTestTimeout, func() (bool, error) {
+		events, err := kubeClient.CoreV1().Events("").List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		recorded = map[string]string{}
+		for _, event := range events.Items {
+			if event.Reason == "ClusterOperatorWaitStarted" {
+				recorded[event.Namespace] = event.InvolvedObject.Kind
+			}
+		}
+		return reflect.DeepEqual(recorded, expected), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected events by namespace %v, expected %v: %v", recorded, expected, err)
+	}
+}
+
+// referenceRecorder records the objects events are recorded on.
+type referenceRecorder struct {
+	record.FakeRecorder
+	refs []corev1.ObjectReference
+}
+
+func (r *referenceRecorder) Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	r.refs = append(r.refs, *object.(*corev1.ObjectReference))
+}
@@ -44,6 +44,8 @@ type operatorMetrics struct {
 	clusterOperatorConditionTransitions                   *prometheus.GaugeVec
 	clusterInstaller                                      *prometheus.GaugeVec
 	clusterVersionOperatorUpdateRetrievalTimestampSeconds *prometheus.GaugeVec
+	upgradeReadiness                                      *prometheus.GaugeVec
+	upgradeReadinessFactor                                *prometheus.GaugeVec
 }
 
 func newOperatorMetrics(optr *Operator) *operatorMetrics {
@@ -97,6 +99,14 @@ version for 'cluster', or empty for 'initial'.
 			Name: "cluster_version_operator_update_retrieval_timestamp_seconds",
 			Help: "Reports when updates were last succesfully retrieved.",
 		}, []string{"name"}),
+		upgradeReadiness: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cluster_version_upgrade_readiness_score",
+			Help: "Reports how ready the cluster is to update, from 0 to 100. Contributing factors are reported by cluster_version_upgrade_readiness_factor.",
+		}, []string{}),
+		upgradeReadinessFactor: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cluster_version_upgrade_readiness_factor",
+			Help: "Reports how much each factor lowers the upgrade readiness score.",
+		}, []string{"factor"}),
 	}
 }
 
@@ -107,13 +117,20 @@ type asyncResult struct {
 
 // RunMetrics launches an server bound to listenAddress serving
 // Prometheus metrics at /metrics over HTTP, and, if tlsConfig is
-// non-nil, also over HTTPS.  Continues serving until runContext.Done()
+// non-nil, also over HTTPS.  If configHandler is non-nil, it serves
+// /debug/config, and if progressHandler is non-nil, it serves /progress, both over HTTPS only.  Continues serving until runContext.Done()
 // and then attempts a clean shutdown limited by shutdownContext.Done().
 // Assumes runContext.Done() occurs before or simultaneously with
 // shutdownContext.Done().
-func RunMetrics(runContext context.Context, shutdownContext context.Context, listenAddress string, tlsConfig *tls.Config) error {
+func RunMetrics(runContext context.Context, shutdownContext context.Context, listenAddress string, tlsConfig *tls.Config, configHandler, progressHandler http.Handler) error {
 	handler := http.NewServeMux()
 	handler.Handle("/metrics", promhttp.Handler())
+	if configHandler != nil {
+		handler.Handle("/debug/config", requireTLS(configHandler))
+	}
+	if progressHandler != nil {
+		handler.Handle("/progress", requireTLS(progressHandler))
+	}
 	server := &http.Server{
 		Handler: handler,
 	}
@@ -198,6 +215,18 @@ func RunMetrics(runContext context.Context, shutdownContext context.Context, lis
 	return loopError
 }
 
+// requireTLS serves requests with handler only if they were received over TLS, so that
+// the plain HTTP listener kept for legacy metrics scrapers does not expose them.
+func requireTLS(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			http.Error(w, "only served over HTTPS", http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
 type conditionKey struct {
 	Name string
 	Type string
@@ -246,6 +275,8 @@ func (m *operatorMetrics) Describe(ch chan<- *prometheus.Desc) {
 	ch <- m.clusterOperatorConditionTransitions.WithLabelValues("", "").Desc()
 	ch <- m.clusterInstaller.WithLabelValues("", "", "").Desc()
 	ch <- m.clusterVersionOperatorUpdateRetrievalTimestampSeconds.WithLabelValues("").Desc()
+	ch <- m.upgradeReadiness.WithLabelValues().Desc()
+	ch <- m.upgradeReadinessFactor.WithLabelValues("").Desc()
 }
 
 func (m *operatorMetrics) Collect(ch chan<- prometheus.Metric) {
@@ -340,6 +371,19 @@ func (m *operatorMetrics) Collect(ch chan<- prometheus.Metric) {
 			ch <- g
 		}
 
+		// answers "which clusters are most at risk when updated"
+		if u := m.optr.getUpgradeable(); u != nil && u.OperatorHealth != nil {
+			score, factors := upgradeReadiness(cv.Status.Conditions, u.OperatorHealth)
+			g := m.upgradeReadiness.WithLabelValues()
+			g.Set(float64(score))
+			ch <- g
+			for _, factor := range factors {
+				g := m.upgradeReadinessFactor.WithLabelValues(factor.name)
+				g.Set(float64(factor.penalty))
+				ch <- g
+			}
+		}
+
 		for _, condition := range cv.Status.Conditions {
 			if condition.Status == configv1.ConditionUnknown {
 				continue
@@ -2,6 +2,9 @@ package cvo
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sort"
 	"strings"
 	"testing"
@@ -712,3 +715,21 @@ func metricParts(t *testing.T, metric prometheus.Metric, labels ...string) strin
 	}
 	return strings.Join(parts, " ")
 }
+
+func Test_requireTLS(t *testing.T) {
+	handler := requireTLS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "served")
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "http://localhost:9099/debug/config", nil))
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("unexpected status %d over HTTP", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "https://localhost:9099/debug/config", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "served" {
+		t.Fatalf("unexpected response %d %q over HTTPS", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,80 @@
+package cvo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	configv1 "github.com/openshift/api/config/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// operatorWaits waits for the ClusterOperators of a run level in parallel, so that waiting for
+// one operator to finish updating does not hold a sync worker that could be initiating the
+// next component of the run level.
+type operatorWaits struct {
+	wg sync.WaitGroup
+
+	lock  sync.Mutex
+	total int
+	errs  []error
+}
+
+// isClusterOperatorTask returns true if the task waits for a ClusterOperator.
+func isClusterOperatorTask(task *payload.Task) bool {
+	return task.Manifest.GVK == configv1.SchemeGroupVersion.WithKind("ClusterOperator")
+}
+
+// start runs the task in the background, calling done if it succeeds.
+func (o *operatorWaits) start(ctx context.Context, task *payload.Task, run func(context.Context) error, done func()) {
+	o.lock.Lock()
+	o.total++
+	o.lock.Unlock()
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		defer utilruntime.HandleCrash()
+		if err := run(ctx); err != nil {
+			o.lock.Lock()
+			o.errs = append(o.errs, err)
+			o.lock.Unlock()
+			return
+		}
+		done()
+	}()
+}
+
+// wait waits for every started task and returns their failures. When every failure is an
+// operator that has not finished updating, they are combined into a single error that counts
+// the operators still updating.
+func (o *operatorWaits) wait() []error {
+	o.wg.Wait()
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if len(o.errs) == 0 {
+		return o.errs
+	}
+	err := newClusterOperatorsNotAvailable(o.errs)
+	var uErr *payload.UpdateError
+	if err == nil || !errors.As(err, &uErr) {
+		return o.errs
+	}
+	uErr.Message = fmt.Sprintf("%d of %d cluster operators are still updating: %s", len(o.errs), o.total, uErr.Name)
+	return []error{uErr}
+}
+
+// splitByRunLevel splits tasks, in payload order, into consecutive groups that share a run level.
+func splitByRunLevel(tasks []*payload.Task) [][]*payload.Task {
+	var levels [][]*payload.Task
+	for i, task := range tasks {
+		if i == 0 || payload.TaskRunLevel(task) != payload.TaskRunLevel(tasks[i-1]) {
+			levels = append(levels, nil)
+		}
+		levels[len(levels)-1] = append(levels[len(levels)-1], task)
+	}
+	return levels
+}
@@ -0,0 +1,73 @@
+package cvo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func TestOperatorWaits(t *testing.T) {
+	task := func(filename string) *payload.Task {
+		return &payload.Task{Manifest: &manifest.Manifest{OriginalFilename: filename, GVK: configv1.SchemeGroupVersion.WithKind("ClusterOperator")}}
+	}
+	notAvailable := func(name string) error {
+		return &payload.UpdateError{Reason: "ClusterOperatorNotAvailable", Message: "Cluster operator " + name + " is still updating", Name: name}
+	}
+
+	waits := &operatorWaits{}
+	var done []string
+	for _, name := range []string{"dns", "ingress", "network", "console"} {
+		name := name
+		waits.start(context.Background(), task("0000_50_"+name+"_00_clusteroperator.yaml"), func(context.Context) error {
+			if name == "console" {
+				return nil
+			}
+			return notAvailable(name)
+		}, func() { done = append(done, name) })
+	}
+	errs := waits.wait()
+	if len(errs) != 1 || errs[0].Error() != "3 of 4 cluster operators are still updating: dns, ingress, network" {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !reflect.DeepEqual(done, []string{"console"}) {
+		t.Fatalf("unexpected completed operators: %v", done)
+	}
+
+	// other failures are not combined
+	waits = &operatorWaits{}
+	degraded := &payload.UpdateError{Reason: "ClusterOperatorDegraded", Message: "Cluster operator dns is degraded", Name: "dns"}
+	waits.start(context.Background(), task("0000_50_dns_00_clusteroperator.yaml"), func(context.Context) error { return degraded }, func() {})
+	waits.start(context.Background(), task("0000_50_ingress_00_clusteroperator.yaml"), func(context.Context) error { return notAvailable("ingress") }, func() {})
+	if errs := waits.wait(); len(errs) != 2 || !(errors.Is(errs[0], degraded) || errors.Is(errs[1], degraded)) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if errs := (&operatorWaits{}).wait(); len(errs) != 0 {
+		t.Fatalf("unexpected errors with nothing to wait for: %v", errs)
+	}
+}
+
+func TestSplitByRunLevel(t *testing.T) {
+	var tasks []*payload.Task
+	for _, filename := range []string{"0000_05_a_00.yaml", "0000_05_b_00.yaml", "0000_10_a_00.yaml", "0000_50_a_00.yaml", "0000_50_c_00.yaml"} {
+		tasks = append(tasks, &payload.Task{Manifest: &manifest.Manifest{OriginalFilename: filename}})
+	}
+	var levels [][]string
+	for _, level := range splitByRunLevel(tasks) {
+		var filenames []string
+		for _, task := range level {
+			filenames = append(filenames, task.Manifest.OriginalFilename)
+		}
+		levels = append(levels, filenames)
+	}
+	want := [][]string{{"0000_05_a_00.yaml", "0000_05_b_00.yaml"}, {"0000_10_a_00.yaml"}, {"0000_50_a_00.yaml", "0000_50_c_00.yaml"}}
+	if !reflect.DeepEqual(levels, want) {
+		t.Fatalf("unexpected run levels:\n%v\n%v", levels, want)
+	}
+}
@@ -0,0 +1,140 @@
+package cvo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
+	"github.com/openshift/cluster-version-operator/pkg/cvo/internal/dynamicclient"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+	"github.com/openshift/library-go/pkg/manifest"
+)
+
+const (
+	// ownerAnnotation identifies the cluster-version operator that manages a resource.
+	ownerAnnotation = "release.openshift.io/owner"
+	// ownerPayloadVersionAnnotation is the version of the release the resource was last applied from.
+	ownerPayloadVersionAnnotation = "release.openshift.io/owner-payload-version"
+	// ownerComponentAnnotation is the component, the NAME of manifest filenames of the form
+	// 0000_NN_NAME_*, that the resource belongs to.
+	ownerComponentAnnotation = "release.openshift.io/owner-component"
+	// takeoverAnnotation on a resource names a cluster-version operator that may take the
+	// resource over from its current owner.
+	takeoverAnnotation = "release.openshift.io/takeover"
+)
+
+// ownership stamps applied resources with the identity of the operator, and protects
+// resources owned by another operator, so that two cluster-version operators applying to the
+// same cluster, as during a hosted cluster migration, do not fight over the same objects.
+type ownership struct {
+	identity string
+	// version returns the version of the release being applied.
+	version func() string
+	// get returns the existing resource for a manifest, or nil if it does not exist.
+	get func(ctx context.Context, config *rest.Config, m *manifest.Manifest) (metav1.Object, error)
+	// clearTakeover removes the takeover annotation from the existing resource for a manifest.
+	clearTakeover func(ctx context.Context, config *rest.Config, m *manifest.Manifest) error
+}
+
+// EnableOwnership stamps every applied resource with the given identity, and refuses to
+// overwrite resources owned by a different identity unless they carry a takeover annotation
+// naming this one. It must be called before InitializeFromPayload.
+func (optr *Operator) EnableOwnership(identity string) {
+	optr.ownership = &ownership{
+		identity: identity,
+		version: func() string {
+			if worker, ok := optr.configSync.(*SyncWorker); ok {
+				return worker.Status().Actual.Version
+			}
+			return ""
+		},
+		get:           getExistingResource,
+		clearTakeover: clearTakeoverAnnotation,
+	}
+}
+
+func getExistingResource(ctx context.Context, config *rest.Config, m *manifest.Manifest) (metav1.Object, error) {
+	client, err := dynamicclient.New(config, m.GVK, m.Obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	existing, err := client.Get(ctx, m.Obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func clearTakeoverAnnotation(ctx context.Context, config *rest.Config, m *manifest.Manifest) error {
+	client, err := dynamicclient.New(config, m.GVK, m.Obj.GetNamespace())
+	if err != nil {
+		return err
+	}
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`, takeoverAnnotation))
+	_, err = client.Patch(ctx, m.Obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// check returns an error if the existing resource for the manifest is owned by a different
+// operator that has not allowed a takeover. Resources without an owner are taken over. It
+// returns true if the resource carries a takeover annotation naming this operator, which
+// should be removed once the resource is stamped.
+func (o *ownership) check(ctx context.Context, config *rest.Config, m *manifest.Manifest) (bool, error) {
+	existing, err := o.get(ctx, config, m)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	annotations := existing.GetAnnotations()
+	owner := annotations[ownerAnnotation]
+	takeover := annotations[takeoverAnnotation] == o.identity
+	if len(owner) == 0 || owner == o.identity || takeover {
+		return takeover, nil
+	}
+	return false, &payload.UpdateError{
+		UpdateEffect: payload.UpdateEffectFail,
+		Reason:       "ResourceOwnedByAnotherManager",
+		Message:      fmt.Sprintf("%s is owned by %s; annotate it with %s=%s to allow this cluster-version operator to take it over", manifestDescription(m), owner, takeoverAnnotation, o.identity),
+		Name:         m.Obj.GetName(),
+	}
+}
+
+func manifestDescription(m *manifest.Manifest) string {
+	name := m.Obj.GetName()
+	if ns := m.Obj.GetNamespace(); len(ns) > 0 {
+		name = fmt.Sprintf("%s/%s", ns, name)
+	}
+	return fmt.Sprintf("%s %q", strings.ToLower(m.GVK.Kind), name)
+}
+
+// stamp returns a modifier that records the operator's ownership of the manifest's resource,
+// after applying the given modifier, if any.
+func (o *ownership) stamp(m *manifest.Manifest, modifier resourcebuilder.MetaV1ObjectModifierFunc) resourcebuilder.MetaV1ObjectModifierFunc {
+	component := payload.ManifestComponent(m)
+	version := o.version()
+	return func(obj metav1.Object) {
+		if modifier != nil {
+			modifier(obj)
+		}
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[ownerAnnotation] = o.identity
+		if len(version) > 0 {
+			annotations[ownerPayloadVersionAnnotation] = version
+		}
+		if len(component) > 0 {
+			annotations[ownerComponentAnnotation] = component
+		}
+		obj.SetAnnotations(annotations)
+	}
+}
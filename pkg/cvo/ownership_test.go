@@ -0,0 +1,128 @@
+package cvo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/manifest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func TestOwnership(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetName("config")
+	obj.SetNamespace("openshift-ingress")
+	m := &manifest.Manifest{OriginalFilename: "0000_50_ingress_01_config.yaml", GVK: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, Obj: obj}
+
+	var existing metav1.Object
+	o := &ownership{
+		identity: "hosted-a",
+		version:  func() string { return "4.8.2" },
+		get: func(ctx context.Context, config *rest.Config, m *manifest.Manifest) (metav1.Object, error) {
+			return existing, nil
+		},
+	}
+
+	for _, tt := range []struct {
+		name         string
+		existing     metav1.Object
+		wantTakeover bool
+		wantErr      string
+	}{
+		{name: "missing"},
+		{name: "unowned", existing: &metav1.ObjectMeta{}},
+		{name: "owned", existing: &metav1.ObjectMeta{Annotations: map[string]string{ownerAnnotation: "hosted-a"}}},
+		{
+			name:     "owned by another manager",
+			existing: &metav1.ObjectMeta{Annotations: map[string]string{ownerAnnotation: "hosted-b"}},
+			wantErr:  `configmap "openshift-ingress/config" is owned by hosted-b; annotate it with release.openshift.io/takeover=hosted-a to allow this cluster-version operator to take it over`,
+		},
+		{
+			name:         "takeover allowed",
+			existing:     &metav1.ObjectMeta{Annotations: map[string]string{ownerAnnotation: "hosted-b", takeoverAnnotation: "hosted-a"}},
+			wantTakeover: true,
+		},
+		{
+			name:         "stale takeover",
+			existing:     &metav1.ObjectMeta{Annotations: map[string]string{ownerAnnotation: "hosted-a", takeoverAnnotation: "hosted-a"}},
+			wantTakeover: true,
+		},
+		{
+			name:     "takeover allowed for another manager",
+			existing: &metav1.ObjectMeta{Annotations: map[string]string{ownerAnnotation: "hosted-b", takeoverAnnotation: "hosted-c"}},
+			wantErr:  `configmap "openshift-ingress/config" is owned by hosted-b; annotate it with release.openshift.io/takeover=hosted-a to allow this cluster-version operator to take it over`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			existing = tt.existing
+			takeover, err := o.check(context.Background(), nil, m)
+			if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Fatalf("unexpected error %v, want %q", err, tt.wantErr)
+			}
+			if takeover != tt.wantTakeover {
+				t.Fatalf("unexpected takeover %t", takeover)
+			}
+		})
+	}
+
+	modified := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"include.release.openshift.io/self-managed-high-availability": "true"}}}
+	o.stamp(m, func(obj metav1.Object) { obj.SetLabels(map[string]string{"modified": "true"}) })(modified)
+	want := map[string]string{
+		"include.release.openshift.io/self-managed-high-availability": "true",
+		ownerAnnotation:               "hosted-a",
+		ownerPayloadVersionAnnotation: "4.8.2",
+		ownerComponentAnnotation:      "ingress",
+	}
+	if !reflect.DeepEqual(modified.Annotations, want) || modified.Labels["modified"] != "true" {
+		t.Fatalf("unexpected stamped object: %v %v", modified.Annotations, modified.Labels)
+	}
+}
+
+func TestOwnershipClearsTakeover(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetName("testa")
+	obj.SetNamespace("default")
+	m := &manifest.Manifest{OriginalFilename: "0000_50_test_01_testa.yaml", GVK: schema.GroupVersionKind{Group: "test.cvo.io", Version: "v1", Kind: "TestA"}, Obj: obj}
+
+	r := &recorder{}
+	testMapper := resourcebuilder.NewResourceMapper()
+	testMapper.RegisterGVK(m.GVK, newTestBuilder(r, map[action]error{}))
+	testMapper.AddToMap(resourcebuilder.Mapper)
+
+	var cleared int
+	builder := &resourceBuilder{ownership: &ownership{
+		identity: "hosted-a",
+		version:  func() string { return "4.8.2" },
+		get: func(ctx context.Context, config *rest.Config, m *manifest.Manifest) (metav1.Object, error) {
+			return &metav1.ObjectMeta{Annotations: map[string]string{ownerAnnotation: "hosted-b", takeoverAnnotation: "hosted-a"}}, nil
+		},
+		clearTakeover: func(ctx context.Context, config *rest.Config, m *manifest.Manifest) error {
+			if len(r.actions) == 0 {
+				t.Fatal("the takeover annotation was removed before the resource was stamped")
+			}
+			cleared++
+			return nil
+		},
+	}}
+
+	if err := builder.Apply(context.Background(), m, payload.PreflightPayload); err != nil {
+		t.Fatal(err)
+	}
+	if cleared != 0 {
+		t.Fatal("a dry run removed the takeover annotation")
+	}
+	if err := builder.Apply(context.Background(), m, payload.UpdatingPayload); err != nil {
+		t.Fatal(err)
+	}
+	if cleared != 1 {
+		t.Fatalf("expected the takeover annotation to be removed once, removed %d times", cleared)
+	}
+}
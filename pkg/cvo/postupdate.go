@@ -0,0 +1,273 @@
+package cvo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/operatorversions"
+)
+
+// postUpdateVerificationDelay is how long after an update completes the verification sweep
+// runs, giving operators and workloads time to settle.
+const postUpdateVerificationDelay = 5 * time.Minute
+
+// postUpdateVerificationConfigMap in the operator's namespace records the result of the most
+// recent verification sweep, so that a restarted operator keeps reporting it.
+const postUpdateVerificationConfigMap = "cluster-version-post-update-verification"
+
+// postUpdateVerification is the result of the verification sweep after an update to image.
+type postUpdateVerification struct {
+	image    string
+	version  string
+	findings []string
+}
+
+// postUpdateReporter schedules a verification sweep when the sync worker reports completing
+// an update. Installs and reconcile passes are not verified.
+type postUpdateReporter struct {
+	last     SyncWorkerStatus
+	schedule func(image string)
+}
+
+func (r *postUpdateReporter) Report(status SyncWorkerStatus) {
+	last := r.last
+	r.last = status
+	if status.Completed > 0 && status.Reconciling && !last.Reconciling && !last.Initial && last.Total > 0 && last.Actual.Image == status.Actual.Image {
+		r.schedule(status.Actual.Image)
+	}
+}
+
+// schedulePostUpdateVerification queues a verification sweep of image. It does not block.
+func (optr *Operator) schedulePostUpdateVerification(image string) {
+	optr.postUpdateLock.Lock()
+	optr.postUpdatePending = image
+	optr.postUpdateLock.Unlock()
+	optr.postUpdateQueue.AddAfter(optr.queueKey(), postUpdateVerificationDelay)
+}
+
+// getPostUpdateVerification returns the result of the most recent verification sweep, if any,
+// loading the result recorded by an earlier operator the first time it is called.
+func (optr *Operator) getPostUpdateVerification(ctx context.Context) *postUpdateVerification {
+	optr.loadPostUpdateVerification(ctx)
+	optr.postUpdateLock.Lock()
+	defer optr.postUpdateLock.Unlock()
+	return optr.postUpdateResult
+}
+
+// formatPostUpdateVerification formats result as the data of the verification ConfigMap.
+func formatPostUpdateVerification(result *postUpdateVerification) (map[string]string, error) {
+	findings, err := json.Marshal(result.findings)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"image":    result.image,
+		"version":  result.version,
+		"findings": string(findings),
+	}, nil
+}
+
+// parsePostUpdateVerification parses the data of the verification ConfigMap, returning nil if
+// it does not describe a verification sweep.
+func parsePostUpdateVerification(data map[string]string) *postUpdateVerification {
+	if len(data["image"]) == 0 {
+		klog.Warningf("Ignoring %s, which does not name the image that was verified", postUpdateVerificationConfigMap)
+		return nil
+	}
+	result := &postUpdateVerification{image: data["image"], version: data["version"]}
+	if err := json.Unmarshal([]byte(data["findings"]), &result.findings); err != nil {
+		klog.Warningf("Ignoring %s, which has invalid findings: %v", postUpdateVerificationConfigMap, err)
+		return nil
+	}
+	return result
+}
+
+// loadPostUpdateVerification loads the result recorded by an earlier operator once, unless a
+// sweep has already run.
+func (optr *Operator) loadPostUpdateVerification(ctx context.Context) {
+	optr.postUpdateLock.Lock()
+	loaded := optr.postUpdateLoaded
+	optr.postUpdateLock.Unlock()
+	if loaded || optr.kubeClient == nil {
+		return
+	}
+	cm, err := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace).Get(ctx, postUpdateVerificationConfigMap, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		utilruntime.HandleError(fmt.Errorf("unable to load the post-update verification: %v", err))
+		return
+	}
+	optr.postUpdateLock.Lock()
+	defer optr.postUpdateLock.Unlock()
+	if err == nil && optr.postUpdateResult == nil {
+		optr.postUpdateResult = parsePostUpdateVerification(cm.Data)
+	}
+	optr.postUpdateLoaded = true
+}
+
+// recordPostUpdateVerification records result in the verification ConfigMap.
+func (optr *Operator) recordPostUpdateVerification(ctx context.Context, result *postUpdateVerification) {
+	if optr.kubeClient == nil {
+		return
+	}
+	data, err := formatPostUpdateVerification(result)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to record the post-update verification of %s: %v", result.version, err))
+		return
+	}
+	client := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace)
+	cm, err := client.Get(ctx, postUpdateVerificationConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: optr.namespace, Name: postUpdateVerificationConfigMap},
+			Data:       data,
+		}, metav1.CreateOptions{})
+	} else if err == nil {
+		cm = cm.DeepCopy()
+		cm.Data = data
+		_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to record the post-update verification of %s: %v", result.version, err))
+	}
+}
+
+// postUpdateVerificationSync runs a pending verification sweep once, recording the result for
+// the ClusterVersion status, in the verification ConfigMap, and as an event. Failures to verify
+// are logged rather than retried.
+func (optr *Operator) postUpdateVerificationSync(ctx context.Context, key string) error {
+	optr.postUpdateLock.Lock()
+	image := optr.postUpdatePending
+	optr.postUpdatePending = ""
+	optr.postUpdateLock.Unlock()
+	if len(image) == 0 {
+		return nil
+	}
+	// the sweep compares against the manifests of the operator's own release, which the
+	// cluster-version operator deployment is moved to early in every update
+	if optr.conformance == nil || optr.conformance.update.Release.Image != image {
+		klog.Warningf("Skipping post-update verification of %s, the operator is running release %s", image, optr.release.Image)
+		return nil
+	}
+	config, err := optr.cvLister.Get(optr.name)
+	if err != nil {
+		klog.Warningf("Skipping post-update verification of %s, unable to read the ClusterVersion: %v", image, err)
+		return nil
+	}
+	findings, err := optr.postUpdateFindings(ctx, config.Spec.Overrides)
+	if err != nil {
+		klog.Warningf("Post-update verification of %s did not finish: %v", image, err)
+		return nil
+	}
+
+	result := &postUpdateVerification{image: image, version: versionString(optr.release), findings: findings}
+	optr.postUpdateLock.Lock()
+	optr.postUpdateResult = result
+	optr.postUpdateLoaded = true
+	optr.postUpdateLock.Unlock()
+	optr.recordPostUpdateVerification(ctx, result)
+
+	ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: optr.name, Namespace: optr.namespace}
+	if len(findings) == 0 {
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeNormal, "PostUpdateVerificationPassed", "post-update verification of %s found no problems", result.version)
+	} else {
+		for _, finding := range findings {
+			klog.Infof("Post-update verification of %s: %s", result.version, finding)
+		}
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "PostUpdateVerificationFailed", "post-update verification of %s found %d problems, including: %s", result.version, len(findings), findings[0])
+	}
+	optr.queue.Add(optr.queueKey())
+	return nil
+}
+
+// postUpdateFindings describes every ClusterOperator not at its release version, every
+// managed manifest that does not conform to the cluster, and every workload still rolling out.
+func (optr *Operator) postUpdateFindings(ctx context.Context, overrides []configv1.ComponentOverride) ([]string, error) {
+	var findings []string
+	for i := range optr.conformance.update.Manifests {
+		m := &optr.conformance.update.Manifests[i]
+		if m.GVK != configv1.SchemeGroupVersion.WithKind("ClusterOperator") {
+			continue
+		}
+		if ov, ok := getOverrideForManifest(overrides, m); ok && ov.Unmanaged {
+			continue
+		}
+		findings = append(findings, optr.clusterOperatorVersionFindings(m)...)
+	}
+	conformance, err := optr.conformance.verify(ctx, overrides, workloadRollout)
+	if err != nil {
+		return nil, err
+	}
+	for _, finding := range conformance {
+		findings = append(findings, finding.String())
+	}
+	return findings, nil
+}
+
+func (optr *Operator) clusterOperatorVersionFindings(m *manifest.Manifest) []string {
+	var expected configv1.ClusterOperator
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m.Obj.Object, &expected); err != nil {
+		return []string{fmt.Sprintf("clusteroperator %q manifest is invalid: %v", m.Obj.GetName(), err)}
+	}
+	actual, err := optr.coLister.Get(expected.Name)
+	if apierrors.IsNotFound(err) {
+		return []string{fmt.Sprintf("clusteroperator %q does not exist", expected.Name)}
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("clusteroperator %q could not be read: %v", expected.Name, err)}
+	}
+	undone := operatorversions.Undone(expected.Status.Versions, actual.Status.Versions)
+	operands := make([]string, 0, len(undone))
+	for operand := range undone {
+		operands = append(operands, operand)
+	}
+	sort.Strings(operands)
+	var findings []string
+	for _, operand := range operands {
+		versions := undone[operand]
+		if len(versions) == 1 {
+			findings = append(findings, fmt.Sprintf("clusteroperator %q does not report %s, expected %s", expected.Name, operand, versions[0]))
+			continue
+		}
+		findings = append(findings, fmt.Sprintf("clusteroperator %q reports %s at %s, expected %s", expected.Name, operand, versions[1], versions[0]))
+	}
+	return findings
+}
+
+// workloadRollout describes a Deployment or DaemonSet that still has pods from before the
+// update, or returns an empty string.
+func workloadRollout(m *manifest.Manifest, actual *unstructured.Unstructured) string {
+	generation := actual.GetGeneration()
+	observed, _, _ := unstructured.NestedInt64(actual.Object, "status", "observedGeneration")
+	switch m.GVK.GroupKind() {
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+		replicas, ok, _ := unstructured.NestedInt64(actual.Object, "spec", "replicas")
+		if !ok {
+			replicas = 1
+		}
+		updated, _, _ := unstructured.NestedInt64(actual.Object, "status", "updatedReplicas")
+		if observed < generation || updated < replicas {
+			return fmt.Sprintf("has not finished rolling out, %d of %d replicas are updated", updated, replicas)
+		}
+	case schema.GroupKind{Group: "apps", Kind: "DaemonSet"}:
+		desired, _, _ := unstructured.NestedInt64(actual.Object, "status", "desiredNumberScheduled")
+		updated, _, _ := unstructured.NestedInt64(actual.Object, "status", "updatedNumberScheduled")
+		if observed < generation || updated < desired {
+			return fmt.Sprintf("has not finished rolling out, %d of %d pods are updated", updated, desired)
+		}
+	}
+	return ""
+}
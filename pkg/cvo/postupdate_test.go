@@ -0,0 +1,188 @@
+package cvo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func Test_postUpdateReporter(t *testing.T) {
+	release := configv1.Release{Version: "4.8.1", Image: "image/image:1"}
+	applying := SyncWorkerStatus{Actual: release, Step: "ApplyResources", Done: 1, Total: 2}
+	completed := SyncWorkerStatus{Actual: release, Done: 2, Total: 2, Completed: 1, Reconciling: true}
+	installing := applying
+	installing.Initial = true
+	reconciling := applying
+	reconciling.Reconciling = true
+	reconciling.Completed = 1
+	reconciled := completed
+	reconciled.Completed = 2
+
+	tests := []struct {
+		name     string
+		statuses []SyncWorkerStatus
+		want     []string
+	}{
+		{name: "update completes", statuses: []SyncWorkerStatus{applying, completed}, want: []string{"image/image:1"}},
+		{name: "install completes", statuses: []SyncWorkerStatus{installing, completed}},
+		{name: "reconcile completes", statuses: []SyncWorkerStatus{completed, reconciling, reconciled}},
+		{name: "restarted after the update completed", statuses: []SyncWorkerStatus{completed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			r := &postUpdateReporter{schedule: func(image string) { got = append(got, image) }}
+			for _, status := range tt.statuses {
+				r.Report(status)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("scheduled %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperator_postUpdateVerificationSync(t *testing.T) {
+	clusterOperator := func(name, version string) manifest.Manifest {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "config.openshift.io/v1",
+			"kind":       "ClusterOperator",
+			"metadata":   map[string]interface{}{"name": name},
+			"status":     map[string]interface{}{"versions": []interface{}{map[string]interface{}{"name": "operator", "version": version}}},
+		}}
+		return manifest.Manifest{GVK: configv1.SchemeGroupVersion.WithKind("ClusterOperator"), Obj: obj}
+	}
+	deployment := func(name string, generation, observed, replicas, updated int64) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec":   map[string]interface{}{"replicas": replicas},
+			"status": map[string]interface{}{"observedGeneration": observed, "updatedReplicas": updated},
+		}}
+		obj.SetNamespace("ns")
+		obj.SetName(name)
+		obj.SetGeneration(generation)
+		return obj
+	}
+	deploymentGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	cluster := map[string]*unstructured.Unstructured{
+		"done":     deployment("done", 2, 2, 3, 3),
+		"rolling":  deployment("rolling", 2, 2, 3, 1),
+		"observed": deployment("observed", 3, 2, 3, 3),
+	}
+	update := &payload.Update{
+		Release: configv1.Release{Version: "4.8.1", Image: "image/image:1"},
+		Manifests: []manifest.Manifest{
+			clusterOperator("ingress", "4.8.1"),
+			clusterOperator("dns", "4.8.1"),
+			clusterOperator("missing", "4.8.1"),
+			{GVK: deploymentGVK, Obj: deployment("done", 0, 0, 3, 0)},
+			{GVK: deploymentGVK, Obj: deployment("rolling", 0, 0, 3, 0)},
+			{GVK: deploymentGVK, Obj: deployment("observed", 0, 0, 3, 0)},
+		},
+	}
+	for i := range update.Manifests[3:] {
+		unstructured.RemoveNestedField(update.Manifests[3+i].Obj.Object, "status")
+	}
+	reporting := func(name, version string) *configv1.ClusterOperator {
+		return &configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     configv1.ClusterOperatorStatus{Versions: []configv1.OperandVersion{{Name: "operator", Version: version}}},
+		}
+	}
+	kubeClient := kfake.NewSimpleClientset()
+	optr := &Operator{
+		name:          "version",
+		namespace:     "openshift-cluster-version",
+		release:       update.Release,
+		kubeClient:    kubeClient,
+		eventRecorder: record.NewFakeRecorder(10),
+		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test"),
+		cvLister:      &cvLister{Items: []*configv1.ClusterVersion{{ObjectMeta: metav1.ObjectMeta{Name: "version"}}}},
+		coLister:      &coLister{Items: []*configv1.ClusterOperator{reporting("ingress", "4.8.1"), reporting("dns", "4.8.0")}},
+		conformance: &conformanceVerifier{
+			update: update,
+			get: func(ctx context.Context, gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, error) {
+				if obj, ok := cluster[name]; ok {
+					return obj, nil
+				}
+				return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "deployments"}, name)
+			},
+		},
+		postUpdatePending: "image/image:1",
+	}
+	defer optr.queue.ShutDown()
+
+	if err := optr.postUpdateVerificationSync(context.Background(), optr.queueKey()); err != nil {
+		t.Fatal(err)
+	}
+	want := &postUpdateVerification{
+		image:   "image/image:1",
+		version: "4.8.1",
+		findings: []string{
+			`clusteroperator "dns" reports operator at 4.8.0, expected 4.8.1`,
+			`clusteroperator "missing" does not exist`,
+			`deployment "ns/rolling" has not finished rolling out, 1 of 3 replicas are updated`,
+			`deployment "ns/observed" has not finished rolling out, 3 of 3 replicas are updated`,
+		},
+	}
+	if got := optr.getPostUpdateVerification(context.Background()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected result:\n%#v\n%#v", got, want)
+	}
+	if len(optr.postUpdatePending) > 0 || optr.queue.Len() != 1 {
+		t.Fatalf("expected the pending verification to be consumed and status to be queued")
+	}
+	if event := <-optr.eventRecorder.(*record.FakeRecorder).Events; event != `Warning PostUpdateVerificationFailed post-update verification of 4.8.1 found 4 problems, including: clusteroperator "dns" reports operator at 4.8.0, expected 4.8.1` {
+		t.Fatalf("unexpected event: %s", event)
+	}
+
+	// a restarted operator reports the recorded result
+	restarted := &Operator{namespace: "openshift-cluster-version", kubeClient: kubeClient}
+	if got := restarted.getPostUpdateVerification(context.Background()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected recorded result:\n%#v\n%#v", got, want)
+	}
+
+	// sweeps of a release the operator is not running are skipped
+	optr.postUpdatePending = "image/image:2"
+	if err := optr.postUpdateVerificationSync(context.Background(), optr.queueKey()); err != nil {
+		t.Fatal(err)
+	}
+	if got := optr.getPostUpdateVerification(context.Background()); got.image != "image/image:1" {
+		t.Fatalf("unexpected result for a different release: %#v", got)
+	}
+}
+
+func Test_parsePostUpdateVerification(t *testing.T) {
+	for _, data := range []map[string]string{
+		{},
+		{"image": "image/image:1", "findings": "not json"},
+	} {
+		if result := parsePostUpdateVerification(data); result != nil {
+			t.Errorf("unexpected result from %v: %#v", data, result)
+		}
+	}
+
+	// formatted results parse back to themselves
+	for _, expected := range []*postUpdateVerification{
+		{image: "image/image:1", version: "4.8.1"},
+		{image: "image/image:1", version: "4.8.1", findings: []string{`clusteroperator "missing" does not exist`}},
+	} {
+		data, err := formatPostUpdateVerification(expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := parsePostUpdateVerification(data); !reflect.DeepEqual(result, expected) {
+			t.Fatalf("unexpected result %#v, expected %#v", result, expected)
+		}
+	}
+}
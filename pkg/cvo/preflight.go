@@ -0,0 +1,94 @@
+package cvo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	configv1 "github.com/openshift/api/config/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// preflightWorkers is how many manifests are submitted with server-side dry-run at once.
+const preflightWorkers = 8
+
+// EnablePreflight makes the sync worker submit the manifests of a release with server-side
+// dry-run before beginning an update to it, refusing the update, unless it is forced, if the
+// server rejects any of them. It must be called before InitializeFromPayload.
+func (optr *Operator) EnablePreflight() {
+	optr.preflight = true
+}
+
+// runPreflight submits every managed manifest of payloadUpdate with server-side dry-run,
+// without waiting for ClusterOperators, and returns an error describing the manifests the
+// server rejected. Failures that may only be caused by objects the payload has yet to create,
+// such as a namespace or a custom resource definition, are logged but not reported, because
+// dry-run requests are not persisted for later manifests to build on.
+func (w *SyncWorker) runPreflight(ctx context.Context, payloadUpdate *payload.Update, overrides []configv1.ComponentOverride) error {
+	var tasks []*payload.Task
+	for i := range payloadUpdate.Manifests {
+		m := &payloadUpdate.Manifests[i]
+		if ov, ok := getOverrideForManifest(overrides, m); ok && ov.Unmanaged {
+			continue
+		}
+		tasks = append(tasks, &payload.Task{Index: i + 1, Total: len(payloadUpdate.Manifests), Manifest: m})
+	}
+	graph := payload.NewTaskGraph(tasks)
+	graph.Parallelize(payload.FlattenByNumberAndComponent)
+
+	var lock sync.Mutex
+	var rejected []string
+	errs := payload.RunGraph(ctx, graph, preflightWorkers, func(ctx context.Context, tasks []*payload.Task) error {
+		for _, task := range tasks {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			err := w.builder.Apply(ctx, task.Manifest, payload.PreflightPayload)
+			if err == nil {
+				continue
+			}
+			if !isPreflightRejection(err) {
+				klog.V(2).Infof("Ignoring preflight failure for %s, which may depend on earlier manifests: %v", task, err)
+				continue
+			}
+			lock.Lock()
+			rejected = append(rejected, fmt.Sprintf("%s: %v", manifestDescription(task.Manifest), err))
+			lock.Unlock()
+		}
+		return nil
+	})
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	if len(rejected) == 0 {
+		return nil
+	}
+	sort.Strings(rejected)
+	message := fmt.Sprintf("The server rejected a dry-run of %s", rejected[0])
+	if len(rejected) > 1 {
+		message = fmt.Sprintf("The server rejected a dry-run of %d of %d manifests:\n* %s", len(rejected), len(tasks), strings.Join(rejected, "\n* "))
+	}
+	return &payload.UpdateError{
+		UpdateEffect: payload.UpdateEffectFail,
+		Reason:       "PreflightFailed",
+		Message:      message,
+		Name:         "preflight",
+	}
+}
+
+// isPreflightRejection returns true if err is the server refusing a manifest, such as an
+// admission rejection, a change to an immutable field, or a schema violation, or the manifest
+// being owned by another cluster-version operator.
+func isPreflightRejection(err error) bool {
+	if apierrors.IsInvalid(err) || apierrors.IsForbidden(err) || apierrors.IsBadRequest(err) {
+		return true
+	}
+	var uErr *payload.UpdateError
+	return errors.As(err, &uErr) && uErr.Reason == "ResourceOwnedByAnotherManager"
+}
@@ -0,0 +1,81 @@
+package cvo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/rest"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+	"github.com/openshift/library-go/pkg/manifest"
+)
+
+func TestRunPreflight(t *testing.T) {
+	newManifest := func(filename, kind, namespace, name string) manifest.Manifest {
+		obj := &unstructured.Unstructured{}
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		return manifest.Manifest{OriginalFilename: filename, GVK: schema.GroupVersionKind{Version: "v1", Kind: kind}, Obj: obj}
+	}
+	update := &payload.Update{Manifests: []manifest.Manifest{
+		newManifest("0000_10_a_namespace.yaml", "Namespace", "", "a"),
+		newManifest("0000_10_a_deployment.yaml", "Deployment", "a", "operator"),
+		newManifest("0000_20_b_configmap.yaml", "ConfigMap", "b", "config"),
+		newManifest("0000_30_c_service.yaml", "Service", "c", "metrics"),
+		newManifest("0000_40_d_widget.yaml", "Widget", "d", "widget"),
+	}}
+
+	invalid := apierrors.NewInvalid(schema.GroupKind{Kind: "Deployment"}, "operator", field.ErrorList{field.Invalid(field.NewPath("spec", "selector"), "", "field is immutable")})
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "services"}, "metrics", errors.New("denied by admission webhook"))
+	w := &SyncWorker{builder: &errorResourceBuilder{errors: map[string]error{
+		"0000_10_a_namespace.yaml":  nil,
+		"0000_10_a_deployment.yaml": invalid,
+		"0000_20_b_configmap.yaml":  apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "b"),
+		"0000_30_c_service.yaml":    forbidden,
+		"0000_40_d_widget.yaml":     nil,
+	}}}
+
+	err := w.runPreflight(context.Background(), update, nil)
+	var uErr *payload.UpdateError
+	if !errors.As(err, &uErr) || uErr.Reason != "PreflightFailed" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "The server rejected a dry-run of 2 of 5 manifests:\n* deployment \"a/operator\": " + invalid.Error() + "\n* service \"c/metrics\": " + forbidden.Error()
+	if uErr.Message != expected {
+		t.Fatalf("unexpected message:\n%s\nexpected:\n%s", uErr.Message, expected)
+	}
+
+	// unmanaged manifests are not submitted
+	overrides := []configv1.ComponentOverride{
+		{Kind: "Deployment", Namespace: "a", Name: "operator", Unmanaged: true},
+		{Kind: "Service", Namespace: "c", Name: "metrics", Unmanaged: true},
+	}
+	if err := w.runPreflight(context.Background(), update, overrides); err != nil {
+		t.Fatalf("unexpected error with the rejected manifests unmanaged: %v", err)
+	}
+}
+
+func TestRunPreflightGenericManifestsDryRun(t *testing.T) {
+	server := newFakeAPIServer(t)
+	w := &SyncWorker{builder: NewResourceBuilder(&rest.Config{Host: server.URL}, nil, nil)}
+	update := &payload.Update{Manifests: []manifest.Manifest{*newWidgetManifest(t, "0000_40_d_widget.yaml", "widget")}}
+	if err := w.runPreflight(context.Background(), update, nil); err != nil {
+		t.Fatal(err)
+	}
+	requests := server.widgetRequests()
+	for _, request := range requests {
+		if !strings.HasPrefix(request, "GET ") && !strings.Contains(request, "dryRun=All") {
+			t.Errorf("preflight wrote without dry-run: %s", request)
+		}
+	}
+	if len(requests) != 2 {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
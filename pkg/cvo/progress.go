@@ -0,0 +1,270 @@
+package cvo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+// progressFeedCapacity is how many progress events are retained for clients that poll.
+const progressFeedCapacity = 1000
+
+const (
+	// maxProgressWatchers is how many clients may watch progress events at once.
+	maxProgressWatchers = 10
+	// progressWatchTimeout is how long a watch of progress events is kept open. Clients
+	// resume after it ends by passing the sequence of the last event they saw.
+	progressWatchTimeout = 30 * time.Minute
+)
+
+// ProgressEvent is a structured record of one step of a sync, published for automation that
+// cannot rely on the human-oriented events recorded against the ClusterVersion.
+type ProgressEvent struct {
+	// Sequence increases by one with each event, so clients can resume after the last
+	// event they saw.
+	Sequence int64     `json:"sequence"`
+	Time     time.Time `json:"time"`
+
+	// Type is one of TaskStarted, TaskSucceeded, TaskFailed, ClusterOperatorWaitStarted,
+	// ClusterOperatorWaitSucceeded, ClusterOperatorWaitFailed, PreconditionPassed,
+	// PreconditionFailed, PreconditionAdvisory or PreconditionWaived.
+	Type string `json:"type"`
+
+	// Version and Image identify the release being applied.
+	Version string `json:"version,omitempty"`
+	Image   string `json:"image,omitempty"`
+	State   string `json:"state,omitempty"`
+
+	// Kind, Namespace, Index and Total identify the manifest of a task. Name is the name
+	// of its object, or of the precondition.
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Index     int    `json:"index,omitempty"`
+	Total     int    `json:"total,omitempty"`
+
+	// DurationSeconds is how long a task took, for tasks that finished.
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// progressFeed retains the most recent progress events and wakes clients waiting for more.
+// A nil progressFeed records nothing.
+type progressFeed struct {
+	lock     sync.Mutex
+	capacity int
+	sequence int64
+	events   []ProgressEvent
+	changed  chan struct{}
+
+	// watchers is how many clients are watching, of at most maxWatchers, each for at most
+	// watchTimeout.
+	watchers     int
+	maxWatchers  int
+	watchTimeout time.Duration
+}
+
+func newProgressFeed(capacity int) *progressFeed {
+	return &progressFeed{capacity: capacity, changed: make(chan struct{}), maxWatchers: maxProgressWatchers, watchTimeout: progressWatchTimeout}
+}
+
+func (f *progressFeed) record(event ProgressEvent) {
+	if f == nil {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.sequence++
+	event.Sequence = f.sequence
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if len(f.events) >= f.capacity {
+		f.events = append(f.events[:0], f.events[len(f.events)-f.capacity+1:]...)
+	}
+	f.events = append(f.events, event)
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// since returns the retained events after sequence, and a channel that is closed once more
+// are recorded.
+func (f *progressFeed) since(sequence int64) ([]ProgressEvent, <-chan struct{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var events []ProgressEvent
+	for _, event := range f.events {
+		if event.Sequence > sequence {
+			events = append(events, event)
+		}
+	}
+	return events, f.changed
+}
+
+// startWatch registers a client watching for events, and returns false if as many clients
+// as the feed allows are already watching.
+func (f *progressFeed) startWatch() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.watchers >= f.maxWatchers {
+		return false
+	}
+	f.watchers++
+	return true
+}
+
+// stopWatch unregisters a client registered by startWatch.
+func (f *progressFeed) stopWatch() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.watchers--
+}
+
+// taskStarted records that the sync began applying task. While reconciling, only failed tasks
+// are recorded, so the feed is not flooded by every pass over the payload.
+func (f *progressFeed) taskStarted(release configv1.Release, state payload.State, task *payload.Task) {
+	if f == nil || state == payload.ReconcilingPayload {
+		return
+	}
+	eventType := "TaskStarted"
+	if isClusterOperatorTask(task) {
+		eventType = "ClusterOperatorWaitStarted"
+	}
+	f.record(newTaskProgressEvent(eventType, release, state, task))
+}
+
+// taskFinished records that task, started at started, succeeded or failed with err.
+func (f *progressFeed) taskFinished(release configv1.Release, state payload.State, task *payload.Task, started time.Time, err error) {
+	if f == nil || (err == nil && state == payload.ReconcilingPayload) {
+		return
+	}
+	prefix := "Task"
+	if isClusterOperatorTask(task) {
+		prefix = "ClusterOperatorWait"
+	}
+	event := newTaskProgressEvent(prefix+"Succeeded", release, state, task)
+	event.Time = time.Now()
+	event.DurationSeconds = event.Time.Sub(started).Seconds()
+	if err != nil {
+		event.Type = prefix + "Failed"
+		event.Message = err.Error()
+		var uErr *payload.UpdateError
+		if errors.As(err, &uErr) {
+			event.Reason = uErr.Reason
+		}
+	}
+	f.record(event)
+}
+
+func newTaskProgressEvent(eventType string, release configv1.Release, state payload.State, task *payload.Task) ProgressEvent {
+	return ProgressEvent{
+		Type:      eventType,
+		Version:   release.Version,
+		Image:     release.Image,
+		State:     state.String(),
+		Kind:      task.Manifest.GVK.Kind,
+		Namespace: task.Manifest.Obj.GetNamespace(),
+		Name:      task.Manifest.Obj.GetName(),
+		Index:     task.Index,
+		Total:     task.Total,
+	}
+}
+
+// preconditions records the outcome of each precondition check, given the results of their
+// RunAll.
+func (f *progressFeed) preconditions(release configv1.Release, results precondition.Results) {
+	if f == nil {
+		return
+	}
+	for _, result := range results {
+		event := ProgressEvent{Time: result.LastProbeTime, Type: "PreconditionFailed", Version: release.Version, Image: release.Image, Name: result.Name, Reason: result.Reason, Message: result.Message}
+		var waiver *precondition.Waiver
+		switch {
+		case result.Err == nil:
+			event.Type = "PreconditionPassed"
+			event.Reason = ""
+			event.Message = ""
+		case errors.As(result.Err, &waiver):
+			event.Type = "PreconditionWaived"
+			event.Reason = ""
+			var pferr *precondition.Error
+			if errors.As(waiver.Failure, &pferr) {
+				event.Reason = pferr.Reason
+			}
+		case !result.Blocking():
+			event.Type = "PreconditionAdvisory"
+		}
+		f.record(event)
+	}
+}
+
+// ProgressHandler serves the operator's progress events as newline-delimited JSON. Clients may
+// pass since, the sequence of the last event they saw, and watch=true to keep the response
+// open and receive events as they are recorded. A limited number of clients may watch at
+// once, and each watch ends after progressWatchTimeout.
+func (optr *Operator) ProgressHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed := optr.progress
+		if feed == nil {
+			http.Error(w, "progress events are not being recorded", http.StatusNotFound)
+			return
+		}
+		var sequence int64
+		if value := r.URL.Query().Get("since"); len(value) > 0 {
+			var err error
+			if sequence, err = strconv.ParseInt(value, 10, 64); err != nil {
+				http.Error(w, "since must be the sequence of a progress event", http.StatusBadRequest)
+				return
+			}
+		}
+		watch := r.URL.Query().Get("watch") == "true"
+		ctx := r.Context()
+		if watch {
+			if !feed.startWatch() {
+				http.Error(w, "too many clients are watching progress events", http.StatusTooManyRequests)
+				return
+			}
+			defer feed.stopWatch()
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, feed.watchTimeout)
+			defer cancel()
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		flusher, _ := w.(http.Flusher)
+		for {
+			events, changed := feed.since(sequence)
+			for _, event := range events {
+				if err := encoder.Encode(event); err != nil {
+					klog.V(4).Infof("Unable to send progress events: %v", err)
+					return
+				}
+				sequence = event.Sequence
+			}
+			if !watch {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package cvo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+func TestProgressFeed(t *testing.T) {
+	feed := newProgressFeed(3)
+	release := configv1.Release{Version: "4.1.0", Image: "image/image:1"}
+	newTask := func(kind, name string) *payload.Task {
+		obj := &unstructured.Unstructured{}
+		obj.SetName(name)
+		m := &manifest.Manifest{GVK: configv1.SchemeGroupVersion.WithKind(kind), Obj: obj}
+		return &payload.Task{Index: 2, Total: 5, Manifest: m}
+	}
+
+	started := time.Now().Add(-time.Minute)
+	feed.taskStarted(release, payload.UpdatingPayload, newTask("ClusterOperator", "network"))
+	feed.taskFinished(release, payload.UpdatingPayload, newTask("ClusterOperator", "network"), started, &payload.UpdateError{Reason: "ClusterOperatorNotAvailable", Message: "Cluster operator network is still updating"})
+	feed.taskStarted(release, payload.UpdatingPayload, newTask("ConfigMap", "config"))
+	feed.taskFinished(release, payload.UpdatingPayload, newTask("ConfigMap", "config"), started, nil)
+
+	// reconciling only records failures
+	feed.taskStarted(release, payload.ReconcilingPayload, newTask("ConfigMap", "config"))
+	feed.taskFinished(release, payload.ReconcilingPayload, newTask("ConfigMap", "config"), started, nil)
+
+	events, _ := feed.since(0)
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	if expected := []string{"ClusterOperatorWaitFailed", "TaskStarted", "TaskSucceeded"}; !reflect.DeepEqual(types, expected) {
+		t.Fatalf("unexpected retained events %v, expected %v", types, expected)
+	}
+	failed := events[0]
+	if failed.Sequence != 2 || failed.Name != "network" || failed.Kind != "ClusterOperator" || failed.Index != 2 || failed.Total != 5 || failed.State != "Updating" ||
+		failed.Reason != "ClusterOperatorNotAvailable" || failed.Message != "Cluster operator network is still updating" || failed.DurationSeconds < 60 {
+		t.Fatalf("unexpected event: %#v", failed)
+	}
+
+	if events, _ := feed.since(3); len(events) != 1 || events[0].Sequence != 4 {
+		t.Fatalf("unexpected events since 3: %#v", events)
+	}
+}
+
+func TestProgressFeedPreconditions(t *testing.T) {
+	feed := newProgressFeed(progressFeedCapacity)
+	failing := &testPrecondition{SuccessAfter: 2}
+	passing := &testPrecondition{}
+	checks := precondition.List{failing, passing}
+	results := checks.RunAll(context.Background(), precondition.ReleaseContext{}, &configv1.ClusterVersion{})
+	feed.preconditions(configv1.Release{Version: "4.1.0"}, results)
+
+	events, _ := feed.since(0)
+	if len(events) != 2 {
+		t.Fatalf("unexpected events: %#v", events)
+	}
+	if events[0].Type != "PreconditionFailed" || events[0].Name != failing.Name() || events[0].Reason != "CheckFailure" {
+		t.Fatalf("unexpected failure event: %#v", events[0])
+	}
+	if events[1].Type != "PreconditionPassed" || events[1].Name != passing.Name() {
+		t.Fatalf("unexpected passed event: %#v", events[1])
+	}
+}
+
+func TestOperator_ProgressHandler(t *testing.T) {
+	optr := &Operator{progress: newProgressFeed(progressFeedCapacity)}
+	optr.progress.maxWatchers = 1
+	optr.progress.record(ProgressEvent{Type: "TaskStarted", Name: "first"})
+	server := httptest.NewServer(optr.ProgressHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?since=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event ProgressEvent
+	err = json.NewDecoder(resp.Body).Decode(&event)
+	resp.Body.Close()
+	if err != nil || event.Name != "first" || event.Sequence != 1 {
+		t.Fatalf("unexpected event %#v: %v", event, err)
+	}
+
+	// watching streams events recorded after since
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?since=1&watch=true", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+	optr.progress.record(ProgressEvent{Type: "TaskSucceeded", Name: "second"})
+	scanner := bufio.NewScanner(resp.Body)
+	if !scanner.Scan() {
+		t.Fatalf("no event streamed: %v", scanner.Err())
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Name != "second" || event.Sequence != 2 {
+		t.Fatalf("unexpected streamed event %#v: %v", event, err)
+	}
+
+	// only so many clients may watch at once
+	watching, err := http.Get(server.URL + "?since=2&watch=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	watching.Body.Close()
+	if watching.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("unexpected status for a watch beyond the limit: %s", watching.Status)
+	}
+
+	resp, err = http.Get(server.URL + "?since=recent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unexpected status for an invalid since: %s", resp.Status)
+	}
+}
+
+func TestOperator_ProgressHandlerWatchTimeout(t *testing.T) {
+	optr := &Operator{progress: newProgressFeed(progressFeedCapacity)}
+	optr.progress.watchTimeout = 10 * time.Millisecond
+	server := httptest.NewServer(optr.ProgressHandler())
+	defer server.Close()
+
+	// watches end at their deadline even if no events are recorded
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?watch=true", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ioutil.ReadAll(resp.Body); err != nil {
+			t.Fatalf("unexpected error reading a watch to its deadline: %v", err)
+		}
+		resp.Body.Close()
+	}
+	optr.progress.lock.Lock()
+	defer optr.progress.lock.Unlock()
+	if optr.progress.watchers != 0 {
+		t.Fatalf("expected finished watches to be unregistered, %d remain", optr.progress.watchers)
+	}
+}
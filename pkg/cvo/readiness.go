@@ -0,0 +1,119 @@
+package cvo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+)
+
+// readinessFactor is a risk to updating the cluster, which lowers its upgrade readiness score
+// by penalty.
+type readinessFactor struct {
+	name    string
+	penalty int
+	message string
+}
+
+// upgradeReadiness scores how ready the cluster is to update from 0 to 100, along with the
+// factors that lowered the score, most severe first. The score summarizes, for ranking a fleet
+// of clusters, conditions that are reported in more detail elsewhere.
+func upgradeReadiness(conditions []configv1.ClusterOperatorStatusCondition, health *operatorHealth) (int, []readinessFactor) {
+	var factors []readinessFactor
+	if c := resourcemerge.FindOperatorStatusCondition(conditions, configv1.OperatorUpgradeable); c != nil && c.Status == configv1.ConditionFalse {
+		factors = append(factors, readinessFactor{name: "NotUpgradeable", penalty: 40, message: fmt.Sprintf("minor version updates are blocked: %s", c.Message)})
+	}
+	if c := resourcemerge.FindOperatorStatusCondition(conditions, ClusterStatusPreconditionChecks); c != nil && c.Status == configv1.ConditionFalse {
+		factors = append(factors, readinessFactor{name: "PreconditionChecksFailed", penalty: 30, message: fmt.Sprintf("precondition checks that block the update are failing: %s", c.Reason)})
+	}
+	if resourcemerge.IsOperatorStatusConditionTrue(conditions, ClusterStatusPreconditionWarnings) {
+		factors = append(factors, readinessFactor{name: "PreconditionWarnings", penalty: 5, message: "precondition checks are failing with warnings"})
+	}
+	if resourcemerge.IsOperatorStatusConditionTrue(conditions, ClusterStatusFailing) {
+		factors = append(factors, readinessFactor{name: "Failing", penalty: 20, message: "the cluster-version operator is failing to apply the current release"})
+	}
+	if resourcemerge.IsOperatorStatusConditionTrue(conditions, ClusterStatusSyncWorkerStalled) {
+		factors = append(factors, readinessFactor{name: "SyncWorkerStalled", penalty: 20, message: "the cluster-version operator has stopped making progress"})
+	}
+	if resourcemerge.IsOperatorStatusConditionFalse(conditions, ClusterStatusPostUpdateVerified) {
+		factors = append(factors, readinessFactor{name: "PostUpdateVerificationFailed", penalty: 10, message: "the cluster did not fully reach the last release it updated to"})
+	}
+	if resourcemerge.IsOperatorStatusConditionTrue(conditions, ClusterStatusRunLevelBudgetExceeded) {
+		factors = append(factors, readinessFactor{name: "RunLevelBudgetExceeded", penalty: 10, message: "the update in progress is taking longer than expected"})
+	}
+
+	if len(health.unavailable) > 0 {
+		factors = append(factors, readinessFactor{name: "ClusterOperatorsUnavailable", penalty: cappedPenalty(15, len(health.unavailable), 30), message: fmt.Sprintf("cluster operators are not available: %s", strings.Join(health.unavailable, ", "))})
+	}
+	if len(health.degraded) > 0 {
+		factors = append(factors, readinessFactor{name: "ClusterOperatorsDegraded", penalty: cappedPenalty(10, len(health.degraded), 30), message: fmt.Sprintf("cluster operators are degraded: %s", strings.Join(health.degraded, ", "))})
+	}
+
+	sort.SliceStable(factors, func(i, j int) bool { return factors[i].penalty > factors[j].penalty })
+	score := 100
+	for _, factor := range factors {
+		score -= factor.penalty
+	}
+	if score < 0 {
+		score = 0
+	}
+	return score, factors
+}
+
+func cappedPenalty(each, count, max int) int {
+	if penalty := each * count; penalty < max {
+		return penalty
+	}
+	return max
+}
+
+// operatorHealth lists, by name, the ClusterOperators that are not available or are degraded.
+type operatorHealth struct {
+	unavailable []string
+	degraded    []string
+}
+
+func newOperatorHealth(operators []*configv1.ClusterOperator) *operatorHealth {
+	health := &operatorHealth{}
+	for _, co := range operators {
+		if !resourcemerge.IsOperatorStatusConditionTrue(co.Status.Conditions, configv1.OperatorAvailable) {
+			health.unavailable = append(health.unavailable, co.Name)
+		}
+		if resourcemerge.IsOperatorStatusConditionTrue(co.Status.Conditions, configv1.OperatorDegraded) {
+			health.degraded = append(health.degraded, co.Name)
+		}
+	}
+	sort.Strings(health.unavailable)
+	sort.Strings(health.degraded)
+	return health
+}
+
+// setUpgradeReadinessCondition sets the UpgradeReadiness condition while any factor lowers the
+// upgrade readiness score, and removes it otherwise. Nothing is set until the upgradeable
+// checks have recorded the health of the cluster operators.
+func (optr *Operator) setUpgradeReadinessCondition(config *configv1.ClusterVersion, now metav1.Time) {
+	u := optr.getUpgradeable()
+	if u == nil || u.OperatorHealth == nil {
+		return
+	}
+	score, factors := upgradeReadiness(config.Status.Conditions, u.OperatorHealth)
+	if len(factors) == 0 {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusUpgradeReadiness)
+		return
+	}
+	messages := make([]string, 0, len(factors))
+	for _, factor := range factors {
+		messages = append(messages, fmt.Sprintf("%s (-%d)", factor.message, factor.penalty))
+	}
+	resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+		Type:               ClusterStatusUpgradeReadiness,
+		Status:             configv1.ConditionFalse,
+		Reason:             factors[0].name,
+		Message:            fmt.Sprintf("Upgrade readiness score %d/100:\n* %s", score, strings.Join(messages, "\n* ")),
+		LastTransitionTime: now,
+	})
+}
@@ -0,0 +1,139 @@
+package cvo
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+)
+
+func TestUpgradeReadiness(t *testing.T) {
+	operator := func(name string, available, degraded configv1.ConditionStatus) *configv1.ClusterOperator {
+		return &configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: configv1.ClusterOperatorStatus{Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: available},
+				{Type: configv1.OperatorDegraded, Status: degraded},
+			}},
+		}
+	}
+	healthy := []*configv1.ClusterOperator{operator("dns", configv1.ConditionTrue, configv1.ConditionFalse)}
+
+	tests := []struct {
+		name       string
+		conditions []configv1.ClusterOperatorStatusCondition
+		operators  []*configv1.ClusterOperator
+		wantScore  int
+		want       []readinessFactor
+	}{
+		{
+			name:      "healthy",
+			operators: healthy,
+			wantScore: 100,
+		},
+		{
+			name: "not upgradeable and failing",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorUpgradeable, Status: configv1.ConditionFalse, Message: "overrides are set"},
+				{Type: ClusterStatusFailing, Status: configv1.ConditionTrue},
+			},
+			operators: healthy,
+			wantScore: 40,
+			want: []readinessFactor{
+				{name: "NotUpgradeable", penalty: 40, message: "minor version updates are blocked: overrides are set"},
+				{name: "Failing", penalty: 20, message: "the cluster-version operator is failing to apply the current release"},
+			},
+		},
+		{
+			name: "failing preconditions",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: ClusterStatusPreconditionChecks, Status: configv1.ConditionFalse, Reason: "UpgradeGateNotAcknowledged"},
+				{Type: ClusterStatusPreconditionWarnings, Status: configv1.ConditionTrue},
+			},
+			operators: healthy,
+			wantScore: 65,
+			want: []readinessFactor{
+				{name: "PreconditionChecksFailed", penalty: 30, message: "precondition checks that block the update are failing: UpgradeGateNotAcknowledged"},
+				{name: "PreconditionWarnings", penalty: 5, message: "precondition checks are failing with warnings"},
+			},
+		},
+		{
+			name: "passing preconditions",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: ClusterStatusPreconditionChecks, Status: configv1.ConditionTrue, Reason: "PreconditionChecksPassed"},
+			},
+			operators: healthy,
+			wantScore: 100,
+		},
+		{
+			name: "unhealthy operators are capped",
+			operators: []*configv1.ClusterOperator{
+				operator("network", configv1.ConditionFalse, configv1.ConditionTrue),
+				operator("ingress", configv1.ConditionFalse, configv1.ConditionFalse),
+				operator("dns", configv1.ConditionTrue, configv1.ConditionFalse),
+				operator("console", configv1.ConditionUnknown, configv1.ConditionFalse),
+			},
+			wantScore: 60,
+			want: []readinessFactor{
+				{name: "ClusterOperatorsUnavailable", penalty: 30, message: "cluster operators are not available: console, ingress, network"},
+				{name: "ClusterOperatorsDegraded", penalty: 10, message: "cluster operators are degraded: network"},
+			},
+		},
+		{
+			name: "score does not go below zero",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorUpgradeable, Status: configv1.ConditionFalse, Message: "operators are not upgradeable"},
+				{Type: ClusterStatusFailing, Status: configv1.ConditionTrue},
+				{Type: ClusterStatusSyncWorkerStalled, Status: configv1.ConditionTrue},
+				{Type: ClusterStatusPostUpdateVerified, Status: configv1.ConditionFalse},
+			},
+			operators: []*configv1.ClusterOperator{
+				operator("network", configv1.ConditionFalse, configv1.ConditionTrue),
+				operator("ingress", configv1.ConditionFalse, configv1.ConditionTrue),
+			},
+			wantScore: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, factors := upgradeReadiness(tt.conditions, newOperatorHealth(tt.operators))
+			if score != tt.wantScore {
+				t.Errorf("unexpected score %d, want %d", score, tt.wantScore)
+			}
+			if tt.want != nil && !reflect.DeepEqual(factors, tt.want) {
+				t.Errorf("unexpected factors:\n%#v\n%#v", factors, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperator_setUpgradeReadinessCondition(t *testing.T) {
+	config := &configv1.ClusterVersion{Status: configv1.ClusterVersionStatus{Conditions: []configv1.ClusterOperatorStatusCondition{
+		{Type: ClusterStatusFailing, Status: configv1.ConditionTrue},
+	}}}
+	optr := &Operator{}
+
+	// nothing is reported until the cluster operators have been checked
+	optr.setUpgradeReadinessCondition(config, metav1.Now())
+	if c := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusUpgradeReadiness); c != nil {
+		t.Fatalf("unexpected condition before the upgradeable checks ran: %#v", c)
+	}
+
+	optr.upgradeable = &upgradeable{OperatorHealth: &operatorHealth{degraded: []string{"ingress"}}}
+	optr.setUpgradeReadinessCondition(config, metav1.Now())
+	c := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusUpgradeReadiness)
+	if c == nil || c.Status != configv1.ConditionFalse || c.Reason != "Failing" ||
+		c.Message != "Upgrade readiness score 70/100:\n* the cluster-version operator is failing to apply the current release (-20)\n* cluster operators are degraded: ingress (-10)" {
+		t.Fatalf("unexpected condition: %#v", c)
+	}
+
+	config.Status.Conditions[0].Status = configv1.ConditionFalse
+	optr.upgradeable.OperatorHealth = &operatorHealth{}
+	optr.setUpgradeReadinessCondition(config, metav1.Now())
+	if c := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusUpgradeReadiness); c != nil {
+		t.Fatalf("expected the condition to be removed: %#v", c)
+	}
+}
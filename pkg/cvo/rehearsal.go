@@ -0,0 +1,302 @@
+package cvo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/lib/validation"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+	"github.com/openshift/library-go/pkg/manifest"
+)
+
+const (
+	// rehearsalConfigMap in the operator's namespace proposes an update to rehearse in its
+	// version, image, and force keys, like spec.desiredUpdate. The operator records its
+	// RehearsalVerdict in the verdict key.
+	rehearsalConfigMap  = "cluster-version-rehearsal"
+	rehearsalVerdictKey = "verdict"
+
+	// rehearsalInterval is how often the rehearsal ConfigMap is checked for a new proposal.
+	rehearsalInterval = time.Minute
+
+	// rehearsalRefreshInterval is how long a verdict stands before the proposal is rehearsed
+	// again against the current state of the cluster.
+	rehearsalRefreshInterval = 15 * time.Minute
+)
+
+// RehearsalVerdict is the outcome of rehearsing a proposed update: what the operator would
+// have done had the proposal been set as spec.desiredUpdate, along with the risks of
+// updating and the plan for applying the release.
+type RehearsalVerdict struct {
+	Proposal    configv1.Update `json:"proposal"`
+	EvaluatedAt time.Time       `json:"evaluatedAt"`
+
+	// Release is the release the proposal resolved to.
+	Release configv1.Release `json:"release,omitempty"`
+	// Accepted is true if the operator would begin updating to the release.
+	Accepted bool `json:"accepted"`
+	// Verification is Signature if the release signature was verified, Local if the release
+	// is the operator's own, or None.
+	Verification  string              `json:"verification,omitempty"`
+	Preconditions PreconditionSummary `json:"preconditions"`
+	// Failures are the reasons the update would not begin, or would only begin because it
+	// was forced.
+	Failures []RehearsalFailure `json:"failures,omitempty"`
+	// Warnings are failures that would not stop the update.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// ReadinessScore is the upgrade readiness score of the cluster from 0 to 100, and Risks
+	// are the factors that lowered it.
+	ReadinessScore int      `json:"readinessScore,omitempty"`
+	Risks          []string `json:"risks,omitempty"`
+
+	// Plan lists, in the order they would be applied, the run levels of the release.
+	Plan []RehearsalRunLevel `json:"plan,omitempty"`
+}
+
+// RehearsalFailure is a step of a rehearsed update that failed.
+type RehearsalFailure struct {
+	Step    string `json:"step"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+}
+
+// RehearsalRunLevel is a run level of a rehearsed release, the NN of manifest filenames of
+// the form 0000_NN_*.
+type RehearsalRunLevel struct {
+	RunLevel   string   `json:"runLevel"`
+	Components []string `json:"components,omitempty"`
+	Manifests  int      `json:"manifests"`
+}
+
+// rehearsal retrieves and checks the releases of proposed updates, independently of the
+// sync worker.
+type rehearsal struct {
+	retriever     PayloadRetriever
+	preconditions precondition.List
+}
+
+// EnableUpdateRehearsal makes the operator rehearse updates proposed in the
+// cluster-version-rehearsal ConfigMap, running the checks that would precede the update
+// without changing the ClusterVersion. It must be called before InitializeFromPayload.
+func (optr *Operator) EnableUpdateRehearsal() {
+	optr.rehearsal = &rehearsal{}
+}
+
+// runRehearsals rehearses proposed updates until ctx is cancelled.
+func (optr *Operator) runRehearsals(ctx context.Context, interval time.Duration) {
+	if !cache.WaitForCacheSync(ctx.Done(), optr.cacheSynced...) {
+		return
+	}
+	klog.Infof("Starting update rehearsals every %s", interval)
+	defer klog.Info("Stopping update rehearsals")
+	wait.UntilWithContext(ctx, optr.syncRehearsal, interval)
+}
+
+// syncRehearsal rehearses the proposed update if it has no current verdict.
+func (optr *Operator) syncRehearsal(ctx context.Context) {
+	client := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace)
+	cm, err := client.Get(ctx, rehearsalConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return
+	}
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to read the update rehearsal proposal: %v", err))
+		return
+	}
+	proposal, err := parseRehearsalProposal(cm.Data)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid update rehearsal proposal in %s: %v", rehearsalConfigMap, err))
+		return
+	}
+	if value, ok := cm.Data[rehearsalVerdictKey]; ok {
+		var previous RehearsalVerdict
+		if err := json.Unmarshal([]byte(value), &previous); err == nil && previous.Proposal == proposal && time.Since(previous.EvaluatedAt) < rehearsalRefreshInterval {
+			return
+		}
+	}
+	config, err := optr.cvLister.Get(optr.name)
+	if err != nil {
+		klog.V(2).Infof("Update rehearsal unable to read the ClusterVersion: %v", err)
+		return
+	}
+
+	verdict := optr.rehearse(ctx, config, proposal)
+	data, err := json.Marshal(verdict)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to encode the update rehearsal verdict: %v", err))
+		return
+	}
+	cm = cm.DeepCopy()
+	cm.Data[rehearsalVerdictKey] = string(data)
+	if _, err := client.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to record the update rehearsal verdict: %v", err))
+		return
+	}
+
+	ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: optr.name, Namespace: optr.namespace}
+	if verdict.Accepted {
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeNormal, "UpdateRehearsed", "rehearsed update to version=%q image=%q would be accepted", verdict.Release.Version, verdict.Release.Image)
+	} else {
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "UpdateRehearsalFailed", "rehearsed update to version=%q image=%q would not be accepted: %s", verdict.Release.Version, verdict.Release.Image, verdict.Failures[0].Message)
+	}
+}
+
+func parseRehearsalProposal(data map[string]string) (configv1.Update, error) {
+	proposal := configv1.Update{Version: data["version"], Image: data["image"]}
+	if value, ok := data["force"]; ok {
+		force, err := strconv.ParseBool(value)
+		if err != nil {
+			return proposal, fmt.Errorf("force must be true or false: %v", err)
+		}
+		proposal.Force = force
+	}
+	if len(proposal.Version) == 0 && len(proposal.Image) == 0 {
+		return proposal, fmt.Errorf("a version or image is required")
+	}
+	return proposal, nil
+}
+
+// rehearse evaluates proposal against a shadow copy of config, as the sync loop and sync
+// worker would had it been set as spec.desiredUpdate. The ClusterVersion is not changed.
+func (optr *Operator) rehearse(ctx context.Context, config *configv1.ClusterVersion, proposal configv1.Update) *RehearsalVerdict {
+	verdict := &RehearsalVerdict{Proposal: proposal, EvaluatedAt: time.Now().UTC(), Release: configv1.Release{Version: proposal.Version, Image: proposal.Image}}
+	fail := func(step, reason, message string) *RehearsalVerdict {
+		verdict.Failures = append(verdict.Failures, RehearsalFailure{Step: step, Reason: reason, Message: message})
+		return verdict
+	}
+
+	shadow := config.DeepCopy()
+	shadow.Spec.DesiredUpdate = &proposal
+	if errs := validation.ValidateClusterVersion(shadow); len(errs) > 0 {
+		return fail("ValidateClusterVersion", "InvalidClusterVersion", errs.ToAggregate().Error())
+	}
+	update, ok := findUpdateFromConfig(shadow)
+	if !ok {
+		return fail("ResolveUpdate", "UnknownUpdate", fmt.Sprintf("version %s is not an available update or a previous version, specify the image", proposal.Version))
+	}
+	verdict.Release = configv1.Release{Version: update.Version, Image: update.Image}
+
+	optr.rehearseRisks(verdict, config)
+
+	info, err := optr.rehearsal.retriever.RetrievePayload(ctx, update)
+	if err != nil {
+		return fail("RetrievePayload", updateErrorReason(err, "RetrievePayload"), err.Error())
+	}
+	verdict.Verification = verificationMethod(info)
+	release, err := payload.LoadUpdate(info.Directory, update.Image, optr.exclude, optr.clusterProfile, optr.disabledCapabilities)
+	if err != nil {
+		return fail("VerifyPayload", updateErrorReason(err, "UpdatePayloadIntegrity"), err.Error())
+	}
+	verdict.Release = release.Release
+	if len(update.Version) > 0 {
+		if err := checkDesiredVersion(update, release.Release); err != nil {
+			return fail("VerifyPayloadVersion", updateErrorReason(err, "VerifyPayloadVersion"), err.Error())
+		}
+	}
+	verdict.Plan = rehearsalPlan(release.Manifests)
+
+	if len(optr.rehearsal.preconditions) == 0 || info.Local {
+		verdict.Preconditions.Skipped = true
+	} else {
+		results := optr.rehearsal.preconditions.RunAll(ctx, precondition.ReleaseContext{DesiredVersion: release.Release.Version, DesiredImage: release.Release.Image}, shadow)
+		errs, waivers := precondition.SplitWaivers(results.Errors())
+		errs, advisories := precondition.SplitSeverity(errs)
+		verdict.Preconditions.Passed = optr.rehearsal.preconditions.Passed(results.Errors())
+		verdict.Preconditions.Waived = len(waivers)
+		verdict.Preconditions.Advisory = len(advisories)
+		for _, advisory := range advisories {
+			verdict.Warnings = append(verdict.Warnings, fmt.Sprintf("Precondition %q failed because of %q: %v", advisory.Name, advisory.Reason, advisory.Error()))
+		}
+		for _, waiver := range waivers {
+			verdict.Warnings = append(verdict.Warnings, fmt.Sprintf("Precondition %q would be waived by %s until %s", waiver.Name, waiver.RequestedBy, waiver.Expires.UTC().Format(time.RFC3339)))
+		}
+		if proposal.Force && precondition.Forceable(errs) {
+			verdict.Preconditions.Overridden = len(errs)
+		} else {
+			verdict.Preconditions.Failed = len(errs)
+		}
+		for _, err := range errs {
+			reason := "PreconditionFailed"
+			var pErr *precondition.Error
+			if errors.As(err, &pErr) {
+				reason = pErr.Reason
+			}
+			verdict.Failures = append(verdict.Failures, RehearsalFailure{Step: "PreconditionChecks", Reason: reason, Message: err.Error()})
+		}
+	}
+
+	verdict.Accepted = verdict.Preconditions.Failed == 0
+	return verdict
+}
+
+// rehearseRisks records the upgrade readiness of the cluster, and whether the update is
+// recommended by the update service.
+func (optr *Operator) rehearseRisks(verdict *RehearsalVerdict, config *configv1.ClusterVersion) {
+	recommended := false
+	for _, update := range config.Status.AvailableUpdates {
+		if update.Image == verdict.Release.Image {
+			recommended = true
+			break
+		}
+	}
+	if !recommended {
+		verdict.Risks = append(verdict.Risks, "the release is not an update recommended by the update service")
+	}
+	if u := optr.getUpgradeable(); u != nil && u.OperatorHealth != nil {
+		score, factors := upgradeReadiness(config.Status.Conditions, u.OperatorHealth)
+		verdict.ReadinessScore = score
+		for _, factor := range factors {
+			verdict.Risks = append(verdict.Risks, fmt.Sprintf("%s (-%d)", factor.message, factor.penalty))
+		}
+	}
+}
+
+func updateErrorReason(err error, reason string) string {
+	var uErr *payload.UpdateError
+	if errors.As(err, &uErr) && len(uErr.Reason) > 0 {
+		return uErr.Reason
+	}
+	return reason
+}
+
+// rehearsalPlan groups manifests, in payload order, by run level.
+func rehearsalPlan(manifests []manifest.Manifest) []RehearsalRunLevel {
+	var plan []RehearsalRunLevel
+	var components map[string]struct{}
+	for i := range manifests {
+		m := &manifests[i]
+		level := payload.ManifestRunLevel(m)
+		if len(plan) == 0 || plan[len(plan)-1].RunLevel != level {
+			plan = append(plan, RehearsalRunLevel{RunLevel: level})
+			components = map[string]struct{}{}
+		}
+		step := &plan[len(plan)-1]
+		step.Manifests++
+		if component := payload.ManifestComponent(m); len(component) > 0 {
+			if _, ok := components[component]; !ok {
+				components[component] = struct{}{}
+				step.Components = append(step.Components, component)
+			}
+		}
+	}
+	for i := range plan {
+		sort.Strings(plan[i].Components)
+	}
+	return plan
+}
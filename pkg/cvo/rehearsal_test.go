@@ -0,0 +1,109 @@
+package cvo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+func TestSyncRehearsal(t *testing.T) {
+	ctx := context.Background()
+	config := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Spec:       configv1.ClusterVersionSpec{ClusterID: "d9b1a1de-2fbf-4a35-bd9c-8ab3e8ef81f5"},
+		Status: configv1.ClusterVersionStatus{
+			AvailableUpdates: []configv1.Release{{Version: "1.0.0-abc", Image: "image/image:1"}},
+		},
+	}
+	kubeClient := kfake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-cluster-version", Name: rehearsalConfigMap},
+		Data:       map[string]string{"version": "1.0.0-abc"},
+	})
+	recorder := record.NewFakeRecorder(10)
+	check := &testPrecondition{SuccessAfter: 2}
+	optr := &Operator{
+		name:           "version",
+		namespace:      "openshift-cluster-version",
+		kubeClient:     kubeClient,
+		eventRecorder:  recorder,
+		cvLister:       &cvLister{Items: []*configv1.ClusterVersion{config}},
+		clusterProfile: payload.DefaultClusterProfile,
+	}
+	optr.EnableUpdateRehearsal()
+	optr.rehearsal.retriever = &fakeDirectoryRetriever{Info: PayloadInfo{Directory: "testdata/payloadtest", Verified: true}}
+	optr.rehearsal.preconditions = precondition.List{check}
+
+	verdict := func() *RehearsalVerdict {
+		cm, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, rehearsalConfigMap, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var verdict RehearsalVerdict
+		if err := json.Unmarshal([]byte(cm.Data[rehearsalVerdictKey]), &verdict); err != nil {
+			t.Fatal(err)
+		}
+		return &verdict
+	}
+
+	// the first rehearsal fails its precondition
+	optr.syncRehearsal(ctx)
+	first := verdict()
+	if first.Accepted || first.Release.Image != "image/image:1" || first.Verification != "Signature" || first.Preconditions.Failed != 1 {
+		t.Fatalf("unexpected verdict: %#v", first)
+	}
+	if len(first.Failures) != 1 || first.Failures[0].Step != "PreconditionChecks" || first.Failures[0].Reason != "CheckFailure" {
+		t.Fatalf("unexpected failures: %#v", first.Failures)
+	}
+	if expected := []RehearsalRunLevel{{RunLevel: "10", Components: []string{"a"}, Manifests: 3}, {RunLevel: "20", Components: []string{"a"}, Manifests: 1}}; !reflect.DeepEqual(first.Plan, expected) {
+		t.Fatalf("unexpected plan: %#v", first.Plan)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning UpdateRehearsalFailed ") {
+		t.Fatalf("unexpected event: %s", event)
+	}
+
+	// an unchanged proposal is not rehearsed again until its verdict is stale
+	optr.syncRehearsal(ctx)
+	if check.attempt != 1 {
+		t.Fatalf("proposal rehearsed again: %d attempts", check.attempt)
+	}
+
+	// a changed proposal is rehearsed again, and now passes
+	cm, _ := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, rehearsalConfigMap, metav1.GetOptions{})
+	cm.Data["image"] = "image/image:1"
+	if _, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	optr.syncRehearsal(ctx)
+	if second := verdict(); !second.Accepted || len(second.Failures) != 0 || second.Preconditions.Passed != 1 || len(second.Risks) != 0 {
+		t.Fatalf("unexpected verdict: %#v", second)
+	}
+
+	// the ClusterVersion itself is never changed
+	if config.Spec.DesiredUpdate != nil {
+		t.Fatalf("rehearsal changed the ClusterVersion: %#v", config.Spec.DesiredUpdate)
+	}
+}
+
+func TestRehearseUnknownVersion(t *testing.T) {
+	optr := &Operator{}
+	optr.EnableUpdateRehearsal()
+	config := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Spec:       configv1.ClusterVersionSpec{ClusterID: "d9b1a1de-2fbf-4a35-bd9c-8ab3e8ef81f5"},
+	}
+	verdict := optr.rehearse(context.Background(), config, configv1.Update{Version: "4.0.5"})
+	if verdict.Accepted || len(verdict.Failures) != 1 || verdict.Failures[0].Step != "ValidateClusterVersion" {
+		t.Fatalf("unexpected verdict: %#v", verdict)
+	}
+}
@@ -0,0 +1,173 @@
+package cvo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// The sync worker publishes every status change once, through updateStatus. The report
+// channel feeds the main operator loop which writes ClusterVersion status, and any
+// additional StatusReporters registered on the worker receive the same status so that
+// every sink observes a consistent sequence. Additional reporters are invoked while the
+// worker holds its lock and must not block.
+
+// eventStatusReporter records events against the ClusterVersion when the sync worker
+// starts or stops failing, when a payload is completely applied and when unsupported
+// configuration is detected.
+type eventStatusReporter struct {
+	recorder record.EventRecorder
+	last     SyncWorkerStatus
+}
+
+func newEventStatusReporter(recorder record.EventRecorder) *eventStatusReporter {
+	return &eventStatusReporter{recorder: recorder}
+}
+
+func (r *eventStatusReporter) Report(status SyncWorkerStatus) {
+	last := r.last
+	r.last = status
+
+	ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: "version", Namespace: "openshift-cluster-version"}
+	version := versionString(status.Actual)
+	switch {
+	case status.Failure != nil && (last.Failure == nil || last.Failure.Error() != status.Failure.Error()):
+		r.recorder.Eventf(ref, corev1.EventTypeWarning, "SyncFailing", "sync of %s failing in step %s: %v", version, status.Step, status.Failure)
+	case status.Failure == nil && last.Failure != nil && status.Actual.Image == last.Actual.Image:
+		r.recorder.Eventf(ref, corev1.EventTypeNormal, "SyncRecovered", "sync of %s is no longer failing", version)
+	}
+	if status.Completed > 0 && (last.Completed == 0 || last.Actual.Image != status.Actual.Image) {
+		r.recorder.Eventf(ref, corev1.EventTypeNormal, "PayloadApplied", "payload version=%q image=%q applied", status.Actual.Version, status.Actual.Image)
+	}
+	if len(status.Unsupported) > 0 && !reflect.DeepEqual(status.Unsupported, last.Unsupported) {
+		r.recorder.Eventf(ref, corev1.EventTypeWarning, "UnsupportedConfiguration", "applying %s in an unsupported configuration: %s", version, strings.Join(status.Unsupported, " "))
+	}
+}
+
+// webhookStatus is the document sent to an external status webhook.
+type webhookStatus struct {
+	Generation   int64      `json:"generation"`
+	Step         string     `json:"step,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+	Failure      string     `json:"failure,omitempty"`
+	Done         int        `json:"done"`
+	Total        int        `json:"total"`
+	Completed    int        `json:"completed"`
+	Reconciling  bool       `json:"reconciling"`
+	Initial      bool       `json:"initial"`
+	Version      string     `json:"version,omitempty"`
+	Image        string     `json:"image,omitempty"`
+	Verified     bool       `json:"verified"`
+	LastProgress *time.Time `json:"lastProgress,omitempty"`
+}
+
+func newWebhookStatus(status SyncWorkerStatus) webhookStatus {
+	s := webhookStatus{
+		Generation:  status.Generation,
+		Step:        status.Step,
+		Done:        status.Done,
+		Total:       status.Total,
+		Completed:   status.Completed,
+		Reconciling: status.Reconciling,
+		Initial:     status.Initial,
+		Version:     status.Actual.Version,
+		Image:       status.Actual.Image,
+		Verified:    status.Verified,
+	}
+	if !status.LastProgress.IsZero() {
+		lastProgress := status.LastProgress
+		s.LastProgress = &lastProgress
+	}
+	if status.Failure != nil {
+		s.Failure = status.Failure.Error()
+		var uErr *payload.UpdateError
+		if errors.As(status.Failure, &uErr) {
+			s.Reason = uErr.Reason
+		}
+	}
+	return s
+}
+
+// webhookStatusReporter posts the most recent sync worker status to an external URL.
+// Report only records the latest status; Run delivers it, so a slow or unreachable
+// webhook never blocks the sync worker and intermediate states may be skipped.
+type webhookStatusReporter struct {
+	url        string
+	httpClient func() (*http.Client, error)
+	pending    chan SyncWorkerStatus
+}
+
+func newWebhookStatusReporter(url string, httpClient func() (*http.Client, error)) *webhookStatusReporter {
+	return &webhookStatusReporter{
+		url:        url,
+		httpClient: httpClient,
+		pending:    make(chan SyncWorkerStatus, 1),
+	}
+}
+
+func (r *webhookStatusReporter) Report(status SyncWorkerStatus) {
+	// replace any status that has not been delivered yet
+	select {
+	case <-r.pending:
+	default:
+	}
+	select {
+	case r.pending <- status:
+	default:
+	}
+}
+
+// Run delivers reported statuses to the webhook until ctx is cancelled.
+func (r *webhookStatusReporter) Run(ctx context.Context) {
+	klog.Infof("Reporting sync status to webhook %s", r.url)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case status := <-r.pending:
+			if err := r.send(ctx, status); err != nil {
+				utilruntime.HandleError(fmt.Errorf("unable to report status to webhook %s: %v", r.url, err))
+			}
+		}
+	}
+}
+
+func (r *webhookStatusReporter) send(ctx context.Context, status SyncWorkerStatus) error {
+	data, err := json.Marshal(newWebhookStatus(status))
+	if err != nil {
+		return err
+	}
+	client, err := r.httpClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package cvo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func Test_eventStatusReporter(t *testing.T) {
+	failure := &payload.UpdateError{Reason: "ClusterOperatorNotAvailable", Message: "Cluster operator test is not available"}
+	tests := []struct {
+		name     string
+		statuses []SyncWorkerStatus
+		want     []string
+	}{
+		{
+			name: "failure and recovery",
+			statuses: []SyncWorkerStatus{
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}},
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}, Failure: failure},
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}, Failure: failure},
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}},
+			},
+			want: []string{
+				"Warning SyncFailing sync of 1.0.0 failing in step : Cluster operator test is not available",
+				"Normal SyncRecovered sync of 1.0.0 is no longer failing",
+			},
+		},
+		{
+			name: "payload applied once per image",
+			statuses: []SyncWorkerStatus{
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}, Completed: 1},
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}, Completed: 2},
+				{Actual: configv1.Release{Version: "1.0.1", Image: "image/image:2"}, Completed: 2},
+			},
+			want: []string{
+				`Normal PayloadApplied payload version="1.0.0" image="image/image:1" applied`,
+				`Normal PayloadApplied payload version="1.0.1" image="image/image:2" applied`,
+			},
+		},
+		{
+			name: "unsupported configuration on change",
+			statuses: []SyncWorkerStatus{
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}, Unsupported: []string{"Forced."}},
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}, Unsupported: []string{"Forced."}},
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}},
+				{Actual: configv1.Release{Version: "1.0.0", Image: "image/image:1"}, Unsupported: []string{"Forced.", "Overridden."}},
+			},
+			want: []string{
+				"Warning UnsupportedConfiguration applying 1.0.0 in an unsupported configuration: Forced.",
+				"Warning UnsupportedConfiguration applying 1.0.0 in an unsupported configuration: Forced. Overridden.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := newEventStatusReporter(recorder)
+			for _, status := range tt.statuses {
+				r.Report(status)
+			}
+			close(recorder.Events)
+			var got []string
+			for event := range recorder.Events {
+				got = append(got, event)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected events:\n%#v\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webhookStatusReporter_send(t *testing.T) {
+	var got webhookStatus
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			t.Errorf("unable to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	r := newWebhookStatusReporter(server.URL, func() (*http.Client, error) { return server.Client(), nil })
+	status := SyncWorkerStatus{
+		Generation: 2,
+		Step:       "ApplyResources",
+		Failure:    &payload.UpdateError{Reason: "ClusterOperatorDegraded", Message: "degraded", Nested: fmt.Errorf("nested")},
+		Actual:     configv1.Release{Version: "1.0.0", Image: "image/image:1"},
+	}
+	if err := r.send(context.Background(), status); err != nil {
+		t.Fatal(err)
+	}
+	if got.Reason != "ClusterOperatorDegraded" || got.Failure != "degraded" || got.Generation != 2 || got.Image != "image/image:1" {
+		t.Fatalf("unexpected webhook status: %#v", got)
+	}
+}
+
+func Test_newWebhookStatus_lastProgress(t *testing.T) {
+	data, err := json.Marshal(newWebhookStatus(SyncWorkerStatus{Generation: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "lastProgress") {
+		t.Fatalf("unexpected lastProgress before any progress: %s", data)
+	}
+
+	lastProgress := time.Unix(1600000000, 0).UTC()
+	data, err = json.Marshal(newWebhookStatus(SyncWorkerStatus{Generation: 1, LastProgress: lastProgress}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"lastProgress":"2020-09-13T12:26:40Z"`) {
+		t.Fatalf("unexpected lastProgress: %s", data)
+	}
+}
@@ -0,0 +1,74 @@
+package cvo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// resyncComponentsAnnotation on the ClusterVersion holds a comma-separated list of components
+// whose manifests the CVO applies immediately, instead of on the next reconcile pass. Components
+// match manifest filenames of the form 0000_NN_NAME_* and manifest namespaces. The annotation
+// is removed once handled.
+const resyncComponentsAnnotation = "release.openshift.io/resync-components"
+
+// componentResyncer is implemented by sync workers that can apply manifests on request.
+type componentResyncer interface {
+	ResyncComponents(ctx context.Context, components []string, done func(applied []*payload.Task, err error)) ([]*payload.Task, error)
+}
+
+// resyncComponents handles a resync request in the ClusterVersion annotations by starting a
+// resync in the sync worker and removing the annotation. The result is reported as an event
+// once the resync finishes. Failures to apply are not retried: the next reconcile pass applies
+// the manifests again.
+func (optr *Operator) resyncComponents(ctx context.Context, config *configv1.ClusterVersion) error {
+	value, ok := config.Annotations[resyncComponentsAnnotation]
+	if !ok {
+		return nil
+	}
+	var components []string
+	for _, component := range strings.Split(value, ",") {
+		if component = strings.TrimSpace(component); len(component) > 0 {
+			components = append(components, component)
+		}
+	}
+
+	ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: optr.name, Namespace: optr.namespace}
+	resyncer, ok := optr.configSync.(componentResyncer)
+	switch {
+	case len(components) == 0:
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "ComponentResyncFailed", "the %s annotation lists no components", resyncComponentsAnnotation)
+	case !ok:
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "ComponentResyncFailed", "the sync worker does not support resynchronizing components")
+	default:
+		names := strings.Join(components, ", ")
+		tasks, err := resyncer.ResyncComponents(ctx, components, func(applied []*payload.Task, err error) {
+			if err != nil {
+				klog.Warningf("Unable to resynchronize %s: %v", names, err)
+				optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "ComponentResyncFailed", "resynchronizing %s (%d manifests applied) failed: %v", names, len(applied), err)
+				return
+			}
+			optr.eventRecorder.Eventf(ref, corev1.EventTypeNormal, "ComponentResynced", "resynchronized %s (%d manifests)", names, len(applied))
+		})
+		if err != nil {
+			klog.Warningf("Unable to resynchronize %s: %v", names, err)
+			optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "ComponentResyncFailed", "resynchronizing %s (%d manifests) failed: %v", names, len(tasks), err)
+		} else {
+			klog.V(2).Infof("Resynchronizing %s (%d manifests)", names, len(tasks))
+		}
+	}
+
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`, resyncComponentsAnnotation)
+	if _, err := optr.client.ConfigV1().ClusterVersions().Patch(ctx, config.Name, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("unable to remove the %s annotation: %v", resyncComponentsAnnotation, err)
+	}
+	return nil
+}
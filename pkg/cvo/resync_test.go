@@ -0,0 +1,157 @@
+package cvo
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/client-go/config/clientset/versioned/fake"
+	"github.com/openshift/library-go/pkg/manifest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// recordingResourceBuilder records the filenames of the manifests it applies.
+type recordingResourceBuilder struct {
+	lock    sync.Mutex
+	applied []string
+	// block, if set, is waited on before each manifest is applied.
+	block chan struct{}
+}
+
+func (b *recordingResourceBuilder) Apply(ctx context.Context, m *manifest.Manifest, state payload.State) error {
+	if b.block != nil {
+		select {
+		case <-b.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.applied = append(b.applied, m.OriginalFilename)
+	return nil
+}
+
+func (b *recordingResourceBuilder) appliedManifests() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]string(nil), b.applied...)
+}
+
+func TestOperator_resyncComponents(t *testing.T) {
+	newTask := func(filename, kind, namespace string) *payload.Task {
+		obj := &unstructured.Unstructured{}
+		obj.SetNamespace(namespace)
+		return &payload.Task{Manifest: &manifest.Manifest{OriginalFilename: filename, GVK: schema.GroupVersionKind{Kind: kind}, Obj: obj}}
+	}
+	operator := newTask("0000_50_ingress_02_operator.yaml", "ClusterOperator", "")
+	operator.Manifest.GVK = configv1.SchemeGroupVersion.WithKind("ClusterOperator")
+	builder := &recordingResourceBuilder{}
+	worker := &SyncWorker{builder: builder}
+	worker.reconciling = []*payload.Task{
+		newTask("0000_50_ingress_00_namespace.yaml", "Namespace", ""),
+		newTask("0000_50_ingress_01_deployment.yaml", "Deployment", "openshift-ingress-operator"),
+		operator,
+		newTask("0000_70_dns_00_deployment.yaml", "Deployment", "openshift-dns-operator"),
+		newTask("0000_90_cluster-monitoring-operator_00_deployment.yaml", "Deployment", "openshift-monitoring"),
+	}
+	config := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{
+		Name:        "version",
+		Annotations: map[string]string{resyncComponentsAnnotation: "ingress, openshift-monitoring"},
+	}}
+	client := fake.NewSimpleClientset(config)
+	optr := &Operator{
+		name:          "version",
+		namespace:     "openshift-cluster-version",
+		client:        client,
+		configSync:    worker,
+		eventRecorder: record.NewFakeRecorder(10),
+	}
+	events := optr.eventRecorder.(*record.FakeRecorder).Events
+	nextEvent := func() string {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatal("timed out waiting for an event")
+			return ""
+		}
+	}
+
+	// the resync runs in the background, skipping the ClusterOperator, which is only waited on
+	builder.block = make(chan struct{})
+	if err := optr.resyncComponents(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := client.ConfigV1().ClusterVersions().Get(context.Background(), "version", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := updated.Annotations[resyncComponentsAnnotation]; ok {
+		t.Fatalf("expected the annotation to be removed: %v", updated.Annotations)
+	}
+
+	// a second resync is refused while the first is running
+	config.Annotations[resyncComponentsAnnotation] = "ingress"
+	if err := optr.resyncComponents(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(); event != "Warning ComponentResyncFailed resynchronizing ingress (0 manifests) failed: an earlier resync is still running" {
+		t.Fatalf("unexpected event for a concurrent resync: %s", event)
+	}
+	close(builder.block)
+	if event := nextEvent(); event != "Normal ComponentResynced resynchronized ingress, openshift-monitoring (3 manifests)" {
+		t.Fatalf("unexpected event: %s", event)
+	}
+	want := []string{"0000_50_ingress_00_namespace.yaml", "0000_50_ingress_01_deployment.yaml", "0000_90_cluster-monitoring-operator_00_deployment.yaml"}
+	if applied := builder.appliedManifests(); !reflect.DeepEqual(applied, want) {
+		t.Fatalf("unexpected manifests applied:\n%v\n%v", applied, want)
+	}
+
+	// manifests held back by an active window are not resynchronized
+	builder.applied = nil
+	windows, err := parseHoldBackWindows(&corev1.ConfigMap{Data: map[string]string{"windows": `[
+		{"components": ["openshift-monitoring"], "start": "00:00", "end": "12:00"},
+		{"components": ["openshift-monitoring"], "start": "12:00", "end": "00:00"}
+	]`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker.holdBack = func() []holdBackWindow { return windows }
+	config.Annotations[resyncComponentsAnnotation] = "ingress, openshift-monitoring"
+	if err := optr.resyncComponents(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(); event != "Normal ComponentResynced resynchronized ingress, openshift-monitoring (2 manifests)" {
+		t.Fatalf("unexpected event with a hold-back window: %s", event)
+	}
+	worker.holdBack = nil
+
+	// unknown components and workers that are not reconciling are reported
+	builder.applied = nil
+	config.Annotations[resyncComponentsAnnotation] = "console"
+	if err := optr.resyncComponents(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(); event != "Warning ComponentResyncFailed resynchronizing console (0 manifests) failed: no managed manifests match console" || len(builder.appliedManifests()) != 0 {
+		t.Fatalf("unexpected event for an unknown component: %s", event)
+	}
+	worker.reconciling = nil
+	config.Annotations[resyncComponentsAnnotation] = "ingress"
+	if err := optr.resyncComponents(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(); event != "Warning ComponentResyncFailed resynchronizing ingress (0 manifests) failed: components can only be resynchronized while reconciling" || len(builder.appliedManifests()) != 0 {
+		t.Fatalf("unexpected event while not reconciling: %s", event)
+	}
+}
@@ -0,0 +1,386 @@
+package cvo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// rollbackConfigMap in the operator's namespace records an active rollback, so that it
+// continues when the operator restarts, including as the release rolled back to.
+const rollbackConfigMap = "cluster-version-rollback"
+
+// rolledBackFromAnnotation on the ClusterVersion names the image of the failed update the
+// desired update was rolled back from, while the desired update is the release rolled back to.
+const rolledBackFromAnnotation = "release.openshift.io/rolled-back-from"
+
+// ClusterStatusRollingBack is set on the ClusterVersion status while a failed update is being
+// rolled back, and is False once the release rolled back to has been applied again.
+const ClusterStatusRollingBack configv1.ClusterStatusConditionType = "RollingBack"
+
+// RollbackTrigger is an update failure that rolls the cluster back to the release it updated
+// from once the failure has persisted for long enough.
+type RollbackTrigger struct {
+	// Reason is the reason of the update failure, such as ClusterOperatorDegraded.
+	Reason string
+	// RunLevel, if set, limits the trigger to failures of manifests in that run level.
+	RunLevel string
+	// After is how long the failure must persist before the update is rolled back.
+	After time.Duration
+}
+
+// String formats the trigger as ParseRollbackTriggers accepts it.
+func (t RollbackTrigger) String() string {
+	if len(t.RunLevel) > 0 {
+		return fmt.Sprintf("%s@%s=%s", t.Reason, t.RunLevel, t.After)
+	}
+	return fmt.Sprintf("%s=%s", t.Reason, t.After)
+}
+
+// matches returns true if err is the failure the trigger describes.
+func (t RollbackTrigger) matches(err *payload.UpdateError) bool {
+	if err.Reason != t.Reason {
+		return false
+	}
+	return len(t.RunLevel) == 0 || (err.Task != nil && payload.TaskRunLevel(err.Task) == t.RunLevel)
+}
+
+// ParseRollbackTriggers parses triggers of the form REASON[@RUNLEVEL]=DURATION, such as
+// ClusterOperatorDegraded@10=30m.
+func ParseRollbackTriggers(values []string) ([]RollbackTrigger, error) {
+	triggers := make([]RollbackTrigger, 0, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("rollback trigger %q must be of the form REASON[@RUNLEVEL]=DURATION", value)
+		}
+		after, err := time.ParseDuration(parts[1])
+		if err != nil || after < 0 {
+			return nil, fmt.Errorf("rollback trigger %q must end with a duration, like 30m", value)
+		}
+		trigger := RollbackTrigger{Reason: parts[0], After: after}
+		if i := strings.Index(parts[0], "@"); i != -1 {
+			trigger.Reason, trigger.RunLevel = parts[0][:i], parts[0][i+1:]
+			if !payload.IsRunLevel(trigger.RunLevel) {
+				return nil, fmt.Errorf("rollback trigger %q run level %q must be two digits, as in ClusterOperatorDegraded@10=30m", value, trigger.RunLevel)
+			}
+		}
+		if len(trigger.Reason) == 0 {
+			return nil, fmt.Errorf("rollback trigger %q must name the reason of a failure", value)
+		}
+		triggers = append(triggers, trigger)
+	}
+	return triggers, nil
+}
+
+// rollback returns the cluster to the release it updated from when an update fails in one of
+// the ways described by its triggers for longer than they allow.
+type rollback struct {
+	triggers []RollbackTrigger
+
+	lock sync.Mutex
+
+	// failing identifies the update failure matching a trigger, and since when it has
+	// been observed.
+	failing      string
+	failingSince time.Time
+
+	// active is the rollback being applied, if any.
+	active *activeRollback
+	loaded bool
+}
+
+// activeRollback is a failed update and the release it is being rolled back to.
+type activeRollback struct {
+	// from is the failed update.
+	from configv1.Update
+	// to is the release the cluster updated from, which the desired update of the
+	// ClusterVersion is changed to.
+	to configv1.Update
+
+	message string
+	started time.Time
+}
+
+// EnableRollback rolls failed updates back to the release the cluster updated from once a
+// failure matching one of triggers has persisted for as long as the trigger allows, by
+// changing the desired update of the ClusterVersion to that release, so that the CVO of the
+// release rolled back to keeps applying it whether or not it rolls back updates itself.
+// Rollbacks skip preconditions and apply the release in the reverse of the run level order,
+// and last until the desired update is changed again.
+func (optr *Operator) EnableRollback(triggers []RollbackTrigger) {
+	optr.rollback = &rollback{triggers: triggers}
+}
+
+// rollbackTarget returns the state the sync worker should apply desired in while a rollback
+// to desired is active, cancelling the rollback once the cluster version asks for a different
+// update.
+func (optr *Operator) rollbackTarget(ctx context.Context, desired configv1.Update, state payload.State, config *configv1.ClusterVersion) (configv1.Update, payload.State) {
+	r := optr.rollback
+	if r == nil {
+		return desired, state
+	}
+	optr.loadRollback(ctx)
+	r.lock.Lock()
+	active := r.active
+	if active != nil && active.to.Image != desired.Image {
+		r.active = nil
+	}
+	r.lock.Unlock()
+	if active == nil {
+		return desired, state
+	}
+	if active.to.Image != desired.Image {
+		klog.Infof("Cancelling the rollback to %s, the desired update changed to %s", active.to.Image, desired.Image)
+		ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: optr.name, Namespace: optr.namespace}
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeNormal, "RollbackCancelled", "rollback to version=%q image=%q cancelled, the desired update changed to version=%q image=%q", active.to.Version, active.to.Image, desired.Version, desired.Image)
+		optr.saveRollback(ctx, nil)
+		if _, ok := config.Annotations[rolledBackFromAnnotation]; ok {
+			if err := optr.patchClusterVersion(ctx, config, map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": map[string]interface{}{rolledBackFromAnnotation: nil}},
+			}); err != nil {
+				utilruntime.HandleError(fmt.Errorf("unable to remove the %s annotation: %v", rolledBackFromAnnotation, err))
+			}
+		}
+		return desired, state
+	}
+	if hasReachedLevel(config, active.to) {
+		return desired, payload.ReconcilingPayload
+	}
+	return desired, payload.RollingBackPayload
+}
+
+// startRollback begins rolling back the update to desired if status reports a failure
+// matching a rollback trigger that has persisted for as long as the trigger allows, changing
+// the desired update of config to the release rolled back to, and returns that release if it
+// did.
+func (optr *Operator) startRollback(ctx context.Context, desired configv1.Update, state payload.State, status *SyncWorkerStatus, config *configv1.ClusterVersion, now time.Time) (configv1.Update, bool) {
+	r := optr.rollback
+	if r == nil {
+		return desired, false
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var uErr *payload.UpdateError
+	if r.active != nil || state != payload.UpdatingPayload || status.Actual.Image != desired.Image || !errors.As(status.Failure, &uErr) {
+		r.failing = ""
+		return desired, false
+	}
+	var trigger *RollbackTrigger
+	for i := range r.triggers {
+		if r.triggers[i].matches(uErr) {
+			trigger = &r.triggers[i]
+			break
+		}
+	}
+	if trigger == nil {
+		r.failing = ""
+		return desired, false
+	}
+	if failing := fmt.Sprintf("%s %s", desired.Image, trigger); r.failing != failing {
+		r.failing, r.failingSince = failing, now
+	}
+	if remaining := trigger.After - now.Sub(r.failingSince); remaining > 0 {
+		optr.queue.AddAfter(optr.queueKey(), remaining)
+		return desired, false
+	}
+
+	var previous *configv1.UpdateHistory
+	for i, entry := range config.Status.History {
+		if entry.State == configv1.CompletedUpdate && entry.Image != desired.Image {
+			previous = &config.Status.History[i]
+			break
+		}
+	}
+	if previous == nil {
+		klog.Warningf("Unable to roll back the update to %s, no earlier release completed", desired.Image)
+		return desired, false
+	}
+
+	active := &activeRollback{
+		from: configv1.Update{Version: desired.Version, Image: desired.Image},
+		// releases that were not verified when they were applied are forced again
+		to:      configv1.Update{Version: previous.Version, Image: previous.Image, Force: !previous.Verified},
+		message: fmt.Sprintf("%s for %s: %s", uErr.Reason, now.Sub(r.failingSince).Round(time.Second), uErr.Message),
+		started: now,
+	}
+	if err := optr.patchClusterVersion(ctx, config, map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{rolledBackFromAnnotation: active.from.Image}},
+		"spec":     map[string]interface{}{"desiredUpdate": active.to},
+	}); err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to change the desired update to %s to roll back the update to %s: %v", previous.Image, desired.Image, err))
+		return desired, false
+	}
+	r.failing = ""
+	r.active = active
+	klog.Warningf("Rolling back the update to %s to %s after %s", desired.Image, previous.Image, r.active.message)
+	ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: optr.name, Namespace: optr.namespace}
+	optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "RollbackStarted", "rolling back to version=%q image=%q after %s", previous.Version, previous.Image, r.active.message)
+	optr.saveRollbackLocked(ctx, r.active)
+	return active.to, true
+}
+
+// patchClusterVersion applies the JSON merge patch to the metadata and spec of config. config
+// may be shared with the informer, and is not changed; later syncs wait for the informer to
+// observe the patched object.
+func (optr *Operator) patchClusterVersion(ctx context.Context, config *configv1.ClusterVersion, patch map[string]interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	updated, err := optr.client.ConfigV1().ClusterVersions().Patch(ctx, config.Name, types.MergePatchType, data, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	optr.rememberLastUpdate(updated)
+	return nil
+}
+
+// setRollbackCondition sets the RollingBack condition while a rollback is active, and removes
+// it otherwise.
+func (optr *Operator) setRollbackCondition(config *configv1.ClusterVersion, status *SyncWorkerStatus, now metav1.Time) {
+	r := optr.rollback
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	active := r.active
+	r.lock.Unlock()
+	if active == nil {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusRollingBack)
+		return
+	}
+	version := active.to.Version
+	if len(version) == 0 {
+		version = active.to.Image
+	}
+	if hasReachedLevel(config, active.to) {
+		resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+			Type:               ClusterStatusRollingBack,
+			Status:             configv1.ConditionFalse,
+			Reason:             "RolledBack",
+			Message:            fmt.Sprintf("Rolled back to %s after %s. Change the desired update to update again.", version, active.message),
+			LastTransitionTime: now,
+		})
+		return
+	}
+	message := fmt.Sprintf("Rolling back to %s after %s", version, active.message)
+	if status.RollingBack && status.Total > 0 {
+		message = fmt.Sprintf("Rolling back to %s: %d of %d done, after %s", version, status.Done, status.Total, active.message)
+	}
+	resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+		Type:               ClusterStatusRollingBack,
+		Status:             configv1.ConditionTrue,
+		Reason:             "UpdateFailed",
+		Message:            message,
+		LastTransitionTime: now,
+	})
+}
+
+// loadRollback loads the rollback recorded by an earlier operator, if it has not been loaded.
+func (optr *Operator) loadRollback(ctx context.Context) {
+	r := optr.rollback
+	r.lock.Lock()
+	loaded := r.loaded
+	r.lock.Unlock()
+	if loaded || optr.kubeClient == nil {
+		return
+	}
+	cm, err := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace).Get(ctx, rollbackConfigMap, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		utilruntime.HandleError(fmt.Errorf("unable to load the active rollback: %v", err))
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err == nil {
+		r.active = parseRollback(cm.Data)
+	}
+	r.loaded = true
+}
+
+// saveRollback records active, or that no rollback is active if it is nil.
+func (optr *Operator) saveRollback(ctx context.Context, active *activeRollback) {
+	optr.rollback.lock.Lock()
+	defer optr.rollback.lock.Unlock()
+	optr.saveRollbackLocked(ctx, active)
+}
+
+func (optr *Operator) saveRollbackLocked(ctx context.Context, active *activeRollback) {
+	if optr.kubeClient == nil {
+		return
+	}
+	client := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace)
+	var err error
+	if active == nil {
+		if err = client.Delete(ctx, rollbackConfigMap, metav1.DeleteOptions{}); apierrors.IsNotFound(err) {
+			err = nil
+		}
+	} else {
+		data := formatRollback(active)
+		var cm *corev1.ConfigMap
+		cm, err = client.Get(ctx, rollbackConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = client.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: optr.namespace, Name: rollbackConfigMap},
+				Data:       data,
+			}, metav1.CreateOptions{})
+		} else if err == nil {
+			cm = cm.DeepCopy()
+			cm.Data = data
+			_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+		}
+	}
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to record the active rollback: %v", err))
+	}
+}
+
+// formatRollback formats active as the data of the rollback ConfigMap.
+func formatRollback(active *activeRollback) map[string]string {
+	return map[string]string{
+		"fromVersion": active.from.Version,
+		"fromImage":   active.from.Image,
+		"toVersion":   active.to.Version,
+		"toImage":     active.to.Image,
+		"toForce":     strconv.FormatBool(active.to.Force),
+		"message":     active.message,
+		"started":     active.started.UTC().Format(time.RFC3339),
+	}
+}
+
+// parseRollback parses the data of the rollback ConfigMap, returning nil if it does not
+// describe a rollback.
+func parseRollback(data map[string]string) *activeRollback {
+	if len(data["fromImage"]) == 0 || len(data["toImage"]) == 0 {
+		klog.Warningf("Ignoring %s, which does not name the images of a rollback", rollbackConfigMap)
+		return nil
+	}
+	started, err := time.Parse(time.RFC3339, data["started"])
+	if err != nil {
+		klog.Warningf("Ignoring the invalid start time of the rollback in %s: %v", rollbackConfigMap, err)
+	}
+	return &activeRollback{
+		from:    configv1.Update{Version: data["fromVersion"], Image: data["fromImage"]},
+		to:      configv1.Update{Version: data["toVersion"], Image: data["toImage"], Force: data["toForce"] == "true"},
+		message: data["message"],
+		started: started,
+	}
+}
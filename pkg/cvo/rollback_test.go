@@ -0,0 +1,155 @@
+package cvo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/client-go/config/clientset/versioned/fake"
+	"github.com/openshift/library-go/pkg/manifest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func TestParseRollbackTriggers(t *testing.T) {
+	triggers, err := ParseRollbackTriggers([]string{"ClusterOperatorDegraded@10=30m", "ClusterOperatorNotAvailable=1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []RollbackTrigger{
+		{Reason: "ClusterOperatorDegraded", RunLevel: "10", After: 30 * time.Minute},
+		{Reason: "ClusterOperatorNotAvailable", After: time.Hour},
+	}
+	if !reflect.DeepEqual(triggers, expected) {
+		t.Fatalf("unexpected triggers %v, expected %v", triggers, expected)
+	}
+	if value := triggers[0].String(); value != "ClusterOperatorDegraded@10=30m0s" {
+		t.Fatalf("unexpected formatted trigger %q", value)
+	}
+
+	for _, value := range []string{"ClusterOperatorDegraded", "ClusterOperatorDegraded=soon", "ClusterOperatorDegraded@ten=30m", "ClusterOperatorDegraded@5=30m", "@10=30m", "ClusterOperatorDegraded=-1h"} {
+		if _, err := ParseRollbackTriggers([]string{value}); err == nil {
+			t.Errorf("expected trigger %q to be rejected", value)
+		}
+	}
+}
+
+func TestOperator_rollback(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := kfake.NewSimpleClientset()
+	client := fake.NewSimpleClientset(&configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Spec:       configv1.ClusterVersionSpec{DesiredUpdate: &configv1.Update{Version: "4.2.0", Image: "image/image:2"}},
+	})
+	recorder := record.NewFakeRecorder(10)
+	newOperator := func() *Operator {
+		optr := &Operator{
+			name:          "version",
+			namespace:     "openshift-cluster-version",
+			client:        client,
+			kubeClient:    kubeClient,
+			eventRecorder: recorder,
+			queue:         workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		}
+		optr.EnableRollback([]RollbackTrigger{{Reason: "ClusterOperatorDegraded", RunLevel: "10", After: 30 * time.Minute}})
+		return optr
+	}
+	optr := newOperator()
+
+	desired := configv1.Update{Version: "4.2.0", Image: "image/image:2"}
+	config := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}, Status: configv1.ClusterVersionStatus{
+		History: []configv1.UpdateHistory{
+			{State: configv1.PartialUpdate, Version: "4.2.0", Image: "image/image:2"},
+			{State: configv1.CompletedUpdate, Version: "4.1.0", Image: "image/image:1", Verified: true},
+		},
+	}}
+	failure := &payload.UpdateError{
+		Reason:  "ClusterOperatorDegraded",
+		Message: "Cluster operator network is degraded",
+		Name:    "network",
+		Task:    &payload.Task{Manifest: &manifest.Manifest{OriginalFilename: "0000_10_network_clusteroperator.yaml"}},
+	}
+	status := &SyncWorkerStatus{Failure: failure, Actual: configv1.Release{Version: "4.2.0", Image: "image/image:2"}}
+
+	if target, state := optr.rollbackTarget(ctx, desired, payload.UpdatingPayload, config); target != desired || state != payload.UpdatingPayload {
+		t.Fatalf("unexpected target %#v in %s without a rollback", target, state)
+	}
+
+	// a failure in another run level does not roll back
+	now := time.Unix(0, 0)
+	other := *failure
+	other.Task = &payload.Task{Manifest: &manifest.Manifest{OriginalFilename: "0000_50_console_clusteroperator.yaml"}}
+	if _, ok := optr.startRollback(ctx, desired, payload.UpdatingPayload, &SyncWorkerStatus{Failure: &other, Actual: status.Actual}, config, now); ok {
+		t.Fatal("unexpected rollback for a failure in another run level")
+	}
+
+	// the failure must persist for as long as the trigger allows
+	if _, ok := optr.startRollback(ctx, desired, payload.UpdatingPayload, status, config, now); ok {
+		t.Fatal("unexpected rollback for a new failure")
+	}
+	now = now.Add(31 * time.Minute)
+	previous := configv1.Update{Version: "4.1.0", Image: "image/image:1"}
+	if to, ok := optr.startRollback(ctx, desired, payload.UpdatingPayload, status, config, now); !ok || to != previous {
+		t.Fatalf("expected a rollback to %#v, got %#v", previous, to)
+	}
+	if event, expected := <-recorder.Events, `Warning RollbackStarted rolling back to version="4.1.0" image="image/image:1" after ClusterOperatorDegraded for 31m0s: Cluster operator network is degraded`; event != expected {
+		t.Fatalf("unexpected event:\n%s\nexpected:\n%s", event, expected)
+	}
+
+	// the desired update is rolled back, so that the CVO of the earlier release applies it
+	cv, err := client.ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv.Spec.DesiredUpdate == nil || *cv.Spec.DesiredUpdate != previous || cv.Annotations[rolledBackFromAnnotation] != "image/image:2" {
+		t.Fatalf("unexpected cluster version after rolling back: %#v", cv)
+	}
+	config.Annotations = cv.Annotations
+	if target, state := optr.rollbackTarget(ctx, previous, payload.UpdatingPayload, config); target != previous || state != payload.RollingBackPayload {
+		t.Fatalf("unexpected target %#v in %s during a rollback", target, state)
+	}
+	optr.setRollbackCondition(config, &SyncWorkerStatus{RollingBack: true, Done: 3, Total: 10}, metav1.Now())
+	condition := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusRollingBack)
+	if condition == nil || condition.Status != configv1.ConditionTrue || condition.Message != "Rolling back to 4.1.0: 3 of 10 done, after ClusterOperatorDegraded for 31m0s: Cluster operator network is degraded" {
+		t.Fatalf("unexpected condition: %#v", condition)
+	}
+
+	// a restarted operator continues the rollback until the release rolled back to is applied
+	optr = newOperator()
+	config.Status.History = append([]configv1.UpdateHistory{{State: configv1.CompletedUpdate, Version: "4.1.0", Image: "image/image:1"}}, config.Status.History...)
+	if target, state := optr.rollbackTarget(ctx, previous, payload.ReconcilingPayload, config); target != previous || state != payload.ReconcilingPayload {
+		t.Fatalf("unexpected target %#v in %s after a rollback", target, state)
+	}
+	optr.setRollbackCondition(config, &SyncWorkerStatus{}, metav1.Now())
+	condition = resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusRollingBack)
+	if condition == nil || condition.Status != configv1.ConditionFalse || condition.Reason != "RolledBack" {
+		t.Fatalf("unexpected condition: %#v", condition)
+	}
+
+	// changing the desired update cancels the rollback
+	desired = configv1.Update{Version: "4.2.1", Image: "image/image:3"}
+	if target, state := optr.rollbackTarget(ctx, desired, payload.UpdatingPayload, config); target != desired || state != payload.UpdatingPayload {
+		t.Fatalf("unexpected target %#v in %s after cancelling", target, state)
+	}
+	if event, expected := <-recorder.Events, `Normal RollbackCancelled rollback to version="4.1.0" image="image/image:1" cancelled, the desired update changed to version="4.2.1" image="image/image:3"`; event != expected {
+		t.Fatalf("unexpected event:\n%s\nexpected:\n%s", event, expected)
+	}
+	if _, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, rollbackConfigMap, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected the rollback to be forgotten: %v", err)
+	}
+	if cv, err := client.ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{}); err != nil || len(cv.Annotations[rolledBackFromAnnotation]) > 0 {
+		t.Fatalf("expected the %s annotation to be removed: %#v %v", rolledBackFromAnnotation, cv, err)
+	}
+	optr.setRollbackCondition(config, &SyncWorkerStatus{}, metav1.Now())
+	if condition := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusRollingBack); condition != nil {
+		t.Fatalf("unexpected condition after cancelling: %#v", condition)
+	}
+}
@@ -0,0 +1,116 @@
+package cvo
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// RunLevelProgress records how an update is progressing through a run level that has an
+// expected duration budget in the release metadata.
+type RunLevelProgress struct {
+	// Level is the NN of 0000_NN_ manifest filenames.
+	Level string
+	// Budget is how long the level is expected to take.
+	Budget time.Duration
+	// Started is when the first manifest in the level began syncing, or zero if the level
+	// has not been reached.
+	Started time.Time
+	// Done is true once every managed manifest in the level has been applied.
+	Done bool
+}
+
+// runLevelTracker remembers run level progress for a release image across sync attempts, so
+// that a level that is retried is measured from when the update first reached it.
+type runLevelTracker struct {
+	lock      sync.Mutex
+	image     string
+	levels    map[string]*RunLevelProgress
+	tasks     map[*payload.Task]string
+	remaining map[string]int
+}
+
+// reset prepares the tracker for an attempt at applying tasks from image. Progress from
+// earlier attempts at the same image is kept, and levels without a budget are ignored.
+func (t *runLevelTracker) reset(image string, budgets map[string]time.Duration, tasks []*payload.Task, managed func(*payload.Task) bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.image != image || t.levels == nil {
+		t.image = image
+		t.levels = make(map[string]*RunLevelProgress, len(budgets))
+		for level, budget := range budgets {
+			t.levels[level] = &RunLevelProgress{Level: level, Budget: budget}
+		}
+	}
+	t.tasks = make(map[*payload.Task]string)
+	t.remaining = make(map[string]int)
+	for _, task := range tasks {
+		level := payload.TaskRunLevel(task)
+		if progress, ok := t.levels[level]; !ok || progress.Done || !managed(task) {
+			continue
+		}
+		t.tasks[task] = level
+		t.remaining[level]++
+	}
+}
+
+// start records that task has begun syncing, starting its level if this is the first task.
+func (t *runLevelTracker) start(task *payload.Task, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if level, ok := t.tasks[task]; ok && t.levels[level].Started.IsZero() {
+		t.levels[level].Started = now
+	}
+}
+
+// finish records that task was applied, completing its level if it was the last task.
+func (t *runLevelTracker) finish(task *payload.Task) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	level, ok := t.tasks[task]
+	if !ok {
+		return
+	}
+	delete(t.tasks, task)
+	t.remaining[level]--
+	if t.remaining[level] == 0 {
+		t.levels[level].Done = true
+	}
+}
+
+// snapshot returns a copy of the tracked levels in run level order.
+func (t *runLevelTracker) snapshot() []RunLevelProgress {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	levels := make([]RunLevelProgress, 0, len(t.levels))
+	for _, progress := range t.levels {
+		levels = append(levels, *progress)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Level < levels[j].Level })
+	return levels
+}
+
+// runLevelsOverBudget describes each started level that has been running for longer than its
+// budget at now, and returns how long until the next running level exceeds its budget, or
+// zero if no running level is still within budget.
+func runLevelsOverBudget(levels []RunLevelProgress, now time.Time) ([]string, time.Duration) {
+	var over []string
+	var next time.Duration
+	for _, level := range levels {
+		if level.Done || level.Started.IsZero() {
+			continue
+		}
+		elapsed := now.Sub(level.Started)
+		if elapsed > level.Budget {
+			over = append(over, fmt.Sprintf("run level %s has been updating for %s, longer than its %s budget", level.Level, elapsed.Round(time.Minute), level.Budget))
+			continue
+		}
+		if remaining := level.Budget - elapsed; next == 0 || remaining < next {
+			next = remaining
+		}
+	}
+	return over, next
+}
@@ -0,0 +1,112 @@
+package cvo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/manifest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func Test_runLevelTracker(t *testing.T) {
+	newTask := func(filename string) *payload.Task {
+		return &payload.Task{Manifest: &manifest.Manifest{OriginalFilename: filename, Obj: &unstructured.Unstructured{}}}
+	}
+	newTasks := func() []*payload.Task {
+		return []*payload.Task{
+			newTask("0000_10_config-operator_00_crd.yaml"),
+			newTask("0000_10_config-operator_01_deployment.yaml"),
+			newTask("0000_20_kube-apiserver_00_unmanaged.yaml"),
+			newTask("0000_30_machine-api_00_deployment.yaml"),
+			newTask("release-metadata"),
+		}
+	}
+	managed := func(task *payload.Task) bool {
+		return task.Manifest.OriginalFilename != "0000_20_kube-apiserver_00_unmanaged.yaml"
+	}
+	budgets := map[string]time.Duration{"10": 5 * time.Minute, "20": time.Minute}
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tracker := &runLevelTracker{}
+	tasks := newTasks()
+	tracker.reset("image/image:1", budgets, tasks, managed)
+	for _, task := range tasks {
+		tracker.start(task, start)
+	}
+	tracker.finish(tasks[0])
+	if got, want := tracker.snapshot(), []RunLevelProgress{
+		{Level: "10", Budget: 5 * time.Minute, Started: start},
+		{Level: "20", Budget: time.Minute},
+	}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected progress:\n%#v\n%#v", got, want)
+	}
+
+	// a retry of the same image keeps the original start time
+	tasks = newTasks()
+	tracker.reset("image/image:1", budgets, tasks, managed)
+	tracker.start(tasks[0], start.Add(time.Minute))
+	tracker.finish(tasks[0])
+	tracker.finish(tasks[1])
+	if got, want := tracker.snapshot(), []RunLevelProgress{
+		{Level: "10", Budget: 5 * time.Minute, Started: start, Done: true},
+		{Level: "20", Budget: time.Minute},
+	}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected progress:\n%#v\n%#v", got, want)
+	}
+
+	// a new image starts over
+	tracker.reset("image/image:2", map[string]time.Duration{"30": time.Minute}, newTasks(), managed)
+	if got, want := tracker.snapshot(), []RunLevelProgress{{Level: "30", Budget: time.Minute}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected progress:\n%#v\n%#v", got, want)
+	}
+}
+
+func Test_runLevelsOverBudget(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		levels   []RunLevelProgress
+		want     []string
+		wantNext time.Duration
+	}{
+		{name: "no levels"},
+		{
+			name: "not started or done",
+			levels: []RunLevelProgress{
+				{Level: "10", Budget: time.Minute, Started: now.Add(-time.Hour), Done: true},
+				{Level: "20", Budget: time.Minute},
+			},
+		},
+		{
+			name: "within budget",
+			levels: []RunLevelProgress{
+				{Level: "10", Budget: 10 * time.Minute, Started: now.Add(-4 * time.Minute)},
+				{Level: "20", Budget: 15 * time.Minute, Started: now.Add(-5 * time.Minute)},
+			},
+			wantNext: 6 * time.Minute,
+		},
+		{
+			name: "over budget",
+			levels: []RunLevelProgress{
+				{Level: "10", Budget: 10 * time.Minute, Started: now.Add(-4 * time.Minute)},
+				{Level: "40", Budget: 15 * time.Minute, Started: now.Add(-25 * time.Minute)},
+			},
+			want:     []string{"run level 40 has been updating for 25m0s, longer than its 15m0s budget"},
+			wantNext: 6 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, next := runLevelsOverBudget(tt.levels, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected messages:\n%q\n%q", got, tt.want)
+			}
+			if next != tt.wantNext {
+				t.Fatalf("unexpected next check %s, want %s", next, tt.wantNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package cvo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+)
+
+const (
+	// operatorDurationsConfigMap in the operator's namespace records how long each
+	// ClusterOperator took to update in recent updates, as comma-separated seconds.
+	operatorDurationsConfigMap = "cluster-version-operator-durations"
+
+	// maxOperatorDurationSamples is how many updates are remembered for each operator.
+	maxOperatorDurationSamples = 10
+
+	// minOperatorDurationSamples is how many updates must be remembered for an operator
+	// before it can be reported as slower than usual.
+	minOperatorDurationSamples = 3
+)
+
+// operatorTimings measures how long each ClusterOperator takes to update, so operators
+// taking much longer than in earlier updates can be pointed out while the update is in
+// progress.
+type operatorTimings struct {
+	// factor is how many times its usual duration an operator may take before it is slow.
+	factor float64
+
+	lock      sync.Mutex
+	image     string
+	started   map[string]time.Time
+	durations map[string]time.Duration
+	reported  map[string]struct{}
+
+	// baselines are the recorded durations of earlier updates, oldest first.
+	baselines map[string][]time.Duration
+	loaded    bool
+	recorded  string
+}
+
+// slowOperator is a ClusterOperator that has been updating for longer than usual.
+type slowOperator struct {
+	name    string
+	elapsed time.Duration
+	usual   time.Duration
+	factor  float64
+	samples int
+}
+
+// EnableSlowOperatorDetection reports ClusterOperators that have been updating for more than
+// factor times the 90th percentile of their durations in earlier updates. It must be called
+// before InitializeFromPayload.
+func (optr *Operator) EnableSlowOperatorDetection(factor float64) {
+	optr.operatorTimings = &operatorTimings{factor: factor}
+}
+
+// startedOperator records that the update to image began waiting for the named operator, if
+// it had not already.
+func (t *operatorTimings) startedOperator(image, name string, now time.Time) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.image != image {
+		t.image = image
+		t.started = map[string]time.Time{}
+		t.durations = map[string]time.Duration{}
+		t.reported = map[string]struct{}{}
+	}
+	if _, ok := t.started[name]; !ok {
+		t.started[name] = now
+	}
+}
+
+// completedOperator records that the named operator finished updating.
+func (t *operatorTimings) completedOperator(name string, now time.Time) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	start, ok := t.started[name]
+	if _, done := t.durations[name]; !ok || done {
+		return
+	}
+	t.durations[name] = now.Sub(start)
+}
+
+// slow returns the operators of the update in progress that have been updating for longer
+// than usual, by name, along with those that have not been reported before.
+func (t *operatorTimings) slow(now time.Time) ([]slowOperator, []slowOperator) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var slow, newlySlow []slowOperator
+	for name, start := range t.started {
+		if _, done := t.durations[name]; done {
+			continue
+		}
+		samples := t.baselines[name]
+		if len(samples) < minOperatorDurationSamples {
+			continue
+		}
+		usual := percentile90(samples)
+		elapsed := now.Sub(start)
+		if float64(elapsed) <= t.factor*float64(usual) {
+			continue
+		}
+		operator := slowOperator{name: name, elapsed: elapsed, usual: usual, factor: t.factor, samples: len(samples)}
+		slow = append(slow, operator)
+		if _, ok := t.reported[name]; !ok {
+			t.reported[name] = struct{}{}
+			newlySlow = append(newlySlow, operator)
+		}
+	}
+	sort.Slice(slow, func(i, j int) bool { return slow[i].name < slow[j].name })
+	sort.Slice(newlySlow, func(i, j int) bool { return newlySlow[i].name < newlySlow[j].name })
+	return slow, newlySlow
+}
+
+func percentile90(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(math.Ceil(0.9*float64(len(sorted))))-1]
+}
+
+func (o slowOperator) String() string {
+	return fmt.Sprintf("%s has been updating for %s, more than %sx its usual %s (90th percentile of %d updates)", o.name, o.elapsed.Round(time.Second), strconv.FormatFloat(o.factor, 'f', -1, 64), o.usual.Round(time.Second), o.samples)
+}
+
+// parseOperatorDurations reads the recorded durations of each operator.
+func parseOperatorDurations(data map[string]string) map[string][]time.Duration {
+	baselines := make(map[string][]time.Duration, len(data))
+	for name, value := range data {
+		for _, field := range strings.Split(value, ",") {
+			seconds, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+			if err != nil || seconds < 0 {
+				klog.Warningf("Ignoring invalid duration %q recorded for cluster operator %s in %s", field, name, operatorDurationsConfigMap)
+				continue
+			}
+			baselines[name] = append(baselines[name], time.Duration(seconds)*time.Second)
+		}
+	}
+	return baselines
+}
+
+// formatOperatorDurations records the most recent durations of each operator.
+func formatOperatorDurations(baselines map[string][]time.Duration) map[string]string {
+	data := make(map[string]string, len(baselines))
+	for name, durations := range baselines {
+		if len(durations) > maxOperatorDurationSamples {
+			durations = durations[len(durations)-maxOperatorDurationSamples:]
+		}
+		fields := make([]string, 0, len(durations))
+		for _, d := range durations {
+			fields = append(fields, strconv.FormatInt(int64(d.Round(time.Second)/time.Second), 10))
+		}
+		data[name] = strings.Join(fields, ",")
+	}
+	return data
+}
+
+// loadOperatorDurations reads the durations of earlier updates once.
+func (optr *Operator) loadOperatorDurations(ctx context.Context) {
+	t := optr.operatorTimings
+	t.lock.Lock()
+	loaded := t.loaded
+	t.lock.Unlock()
+	if loaded || optr.kubeClient == nil {
+		return
+	}
+	cm, err := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace).Get(ctx, operatorDurationsConfigMap, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		utilruntime.HandleError(fmt.Errorf("unable to load cluster operator update durations: %v", err))
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.baselines = map[string][]time.Duration{}
+	if err == nil {
+		t.baselines = parseOperatorDurations(cm.Data)
+	}
+	t.loaded = true
+}
+
+// setSlowOperatorsCondition sets the SlowClusterOperators condition while an update is
+// waiting on operators that have been updating for longer than usual, recording an event as
+// each is found, and removes it otherwise.
+func (optr *Operator) setSlowOperatorsCondition(ctx context.Context, config *configv1.ClusterVersion, status *SyncWorkerStatus, now metav1.Time) {
+	if optr.operatorTimings == nil {
+		return
+	}
+	var slow, newlySlow []slowOperator
+	if !status.Reconciling && len(config.Status.History) > 0 && config.Status.History[0].State == configv1.PartialUpdate {
+		optr.loadOperatorDurations(ctx)
+		slow, newlySlow = optr.operatorTimings.slow(now.Time)
+	}
+	if len(slow) == 0 {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusSlowClusterOperators)
+		return
+	}
+
+	ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: optr.name, Namespace: optr.namespace}
+	for _, operator := range newlySlow {
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "ClusterOperatorSlow", "cluster operator %s", operator)
+	}
+	messages := make([]string, 0, len(slow))
+	for _, operator := range slow {
+		messages = append(messages, operator.String())
+	}
+	message := fmt.Sprintf("Cluster operator %s", messages[0])
+	if len(messages) > 1 {
+		message = fmt.Sprintf("Cluster operators are updating slower than usual:\n* %s", strings.Join(messages, "\n* "))
+	}
+	resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+		Type:               ClusterStatusSlowClusterOperators,
+		Status:             configv1.ConditionTrue,
+		Reason:             "SlowerThanUsual",
+		Message:            message,
+		LastTransitionTime: now,
+	})
+}
+
+// syncOperatorDurations records how long each operator took once an update completes.
+func (optr *Operator) syncOperatorDurations(ctx context.Context, history []configv1.UpdateHistory) {
+	t := optr.operatorTimings
+	if t == nil || optr.kubeClient == nil || len(history) == 0 || history[0].State != configv1.CompletedUpdate {
+		return
+	}
+	optr.loadOperatorDurations(ctx)
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.loaded || t.image != history[0].Image || t.recorded == t.image || len(t.durations) == 0 {
+		return
+	}
+	baselines := make(map[string][]time.Duration, len(t.baselines))
+	for name, durations := range t.baselines {
+		baselines[name] = durations
+	}
+	for name, d := range t.durations {
+		baselines[name] = append(append([]time.Duration(nil), baselines[name]...), d)
+	}
+	data := formatOperatorDurations(baselines)
+
+	client := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace)
+	cm, err := client.Get(ctx, operatorDurationsConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: optr.namespace, Name: operatorDurationsConfigMap},
+			Data:       data,
+		}, metav1.CreateOptions{})
+	} else if err == nil {
+		cm = cm.DeepCopy()
+		cm.Data = data
+		_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to record cluster operator update durations for %s: %v", t.image, err))
+		return
+	}
+	t.baselines = parseOperatorDurations(data)
+	t.recorded = t.image
+}
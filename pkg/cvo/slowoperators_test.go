@@ -0,0 +1,113 @@
+package cvo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+)
+
+func TestSlowOperators(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := kfake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-cluster-version", Name: operatorDurationsConfigMap},
+		Data: map[string]string{
+			"network": "600,540,480,420",
+			"dns":     "60,60",
+		},
+	})
+	recorder := record.NewFakeRecorder(10)
+	optr := &Operator{name: "version", namespace: "openshift-cluster-version", kubeClient: kubeClient, eventRecorder: recorder}
+	optr.EnableSlowOperatorDetection(2)
+
+	start := time.Unix(0, 0)
+	image := "image/image:1"
+	for _, name := range []string{"network", "dns", "console"} {
+		optr.operatorTimings.startedOperator(image, name, start)
+	}
+	optr.operatorTimings.startedOperator(image, "network", start.Add(time.Hour))
+
+	config := &configv1.ClusterVersion{Status: configv1.ClusterVersionStatus{
+		History: []configv1.UpdateHistory{{State: configv1.PartialUpdate, Image: image}},
+	}}
+	status := &SyncWorkerStatus{}
+
+	// network has been updating for longer than twice its 90th percentile of ten minutes,
+	// while dns does not have enough history to judge
+	now := metav1.NewTime(start.Add(25 * time.Minute))
+	optr.setSlowOperatorsCondition(ctx, config, status, now)
+	condition := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusSlowClusterOperators)
+	if condition == nil || condition.Status != configv1.ConditionTrue || condition.Reason != "SlowerThanUsual" {
+		t.Fatalf("unexpected condition: %#v", condition)
+	}
+	if expected := "Cluster operator network has been updating for 25m0s, more than 2x its usual 10m0s (90th percentile of 4 updates)"; condition.Message != expected {
+		t.Fatalf("unexpected message:\n%s\nexpected:\n%s", condition.Message, expected)
+	}
+	select {
+	case event := <-recorder.Events:
+		if expected := "Warning ClusterOperatorSlow cluster operator network has been updating for 25m0s, more than 2x its usual 10m0s (90th percentile of 4 updates)"; event != expected {
+			t.Fatalf("unexpected event: %s", event)
+		}
+	default:
+		t.Fatal("expected an event for the slow operator")
+	}
+
+	// each slow operator is only reported by event once per update
+	optr.setSlowOperatorsCondition(ctx, config, status, metav1.NewTime(start.Add(30*time.Minute)))
+	select {
+	case event := <-recorder.Events:
+		t.Fatalf("unexpected repeated event: %s", event)
+	default:
+	}
+
+	// once network completes, nothing is slow
+	optr.operatorTimings.completedOperator("network", start.Add(40*time.Minute))
+	optr.operatorTimings.completedOperator("dns", start.Add(time.Minute))
+	optr.operatorTimings.completedOperator("console", start.Add(2*time.Minute))
+	optr.setSlowOperatorsCondition(ctx, config, status, metav1.NewTime(start.Add(41*time.Minute)))
+	if condition := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusSlowClusterOperators); condition != nil {
+		t.Fatalf("unexpected condition after completion: %#v", condition)
+	}
+
+	// the durations are recorded once the update completes
+	optr.syncOperatorDurations(ctx, []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Image: image}})
+	cm, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, operatorDurationsConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"network": "600,540,480,420,2400",
+		"dns":     "60,60,60",
+		"console": "120",
+	}
+	if !reflect.DeepEqual(cm.Data, expected) {
+		t.Fatalf("unexpected recorded durations: %v", cm.Data)
+	}
+	optr.syncOperatorDurations(ctx, []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Image: image}})
+	cm, _ = kubeClient.CoreV1().ConfigMaps("openshift-cluster-version").Get(ctx, operatorDurationsConfigMap, metav1.GetOptions{})
+	if !reflect.DeepEqual(cm.Data, expected) {
+		t.Fatalf("durations recorded twice for the same update: %v", cm.Data)
+	}
+}
+
+func TestFormatOperatorDurations(t *testing.T) {
+	var durations []time.Duration
+	for i := 1; i <= 12; i++ {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+	data := formatOperatorDurations(map[string][]time.Duration{"network": durations})
+	if expected := "3,4,5,6,7,8,9,10,11,12"; data["network"] != expected {
+		t.Fatalf("unexpected durations %q, expected %q", data["network"], expected)
+	}
+	if p90 := percentile90(parseOperatorDurations(data)["network"]); p90 != 11*time.Second {
+		t.Fatalf("unexpected 90th percentile %s", p90)
+	}
+}
@@ -0,0 +1,77 @@
+package cvo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+var metricStandbyDiscrepancies = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "cluster_version_standby_discrepancies",
+	Help: "Reports, from a non-leader cluster-version operator replica, manifests of the release image whose in-cluster objects do not match the manifest.",
+}, []string{"kind", "namespace", "name"})
+
+func init() {
+	prometheus.MustRegister(metricStandbyDiscrepancies)
+}
+
+// RunStandbyVerification periodically verifies the local release image against the cluster
+// until ctx is cancelled, for example when this replica becomes the leader. Discrepancies
+// are exported as metrics and, when they change, recorded as an event on the ClusterVersion.
+// It does not write to any other resource.
+func (optr *Operator) RunStandbyVerification(ctx context.Context, interval time.Duration) {
+	if optr.conformance == nil {
+		return
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), optr.cacheSynced...) {
+		return
+	}
+	klog.Infof("Starting standby verification of %s every %s", versionString(optr.release), interval)
+	defer klog.Info("Stopping standby verification")
+	defer metricStandbyDiscrepancies.Reset()
+	wait.UntilWithContext(ctx, optr.standbyVerify, interval)
+}
+
+func (optr *Operator) standbyVerify(ctx context.Context) {
+	config, err := optr.cvLister.Get(optr.name)
+	if err != nil {
+		klog.V(2).Infof("Standby verification unable to read the ClusterVersion: %v", err)
+		return
+	}
+	// the leader may be moving the cluster away from the local release
+	if config.Spec.DesiredUpdate != nil && len(config.Spec.DesiredUpdate.Image) > 0 && config.Spec.DesiredUpdate.Image != optr.release.Image {
+		klog.V(2).Infof("Standby verification skipped, the cluster is updating to %s", config.Spec.DesiredUpdate.Image)
+		metricStandbyDiscrepancies.Reset()
+		return
+	}
+	findings, err := optr.conformance.verify(ctx, config.Spec.Overrides)
+	if err != nil {
+		return
+	}
+	metricStandbyDiscrepancies.Reset()
+	discrepancies := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		metricStandbyDiscrepancies.WithLabelValues(finding.manifest.GVK.Kind, finding.manifest.Obj.GetNamespace(), finding.manifest.Obj.GetName()).Set(1)
+		discrepancies = append(discrepancies, finding.String())
+	}
+	for _, discrepancy := range discrepancies {
+		klog.V(2).Infof("Standby verification: %s", discrepancy)
+	}
+	if len(discrepancies) > 0 && !reflect.DeepEqual(discrepancies, optr.standbyLast) {
+		message := strings.Join(discrepancies, ", ")
+		if len(discrepancies) > 5 {
+			message = fmt.Sprintf("%s, and %d more", strings.Join(discrepancies[:5], ", "), len(discrepancies)-5)
+		}
+		ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: optr.name, Namespace: optr.namespace}
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "StandbyDriftDetected", "%d manifests of %s do not match the cluster: %s", len(discrepancies), versionString(optr.release), message)
+	}
+	optr.standbyLast = discrepancies
+}
@@ -4,24 +4,31 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/klog/v2"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	configv1 "github.com/openshift/api/config/v1"
 	configclientv1 "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
 
 	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+	"github.com/openshift/cluster-version-operator/pkg/internal"
 	"github.com/openshift/cluster-version-operator/pkg/payload"
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
 )
 
 const (
@@ -150,6 +157,52 @@ func pruneStatusHistory(config *configv1.ClusterVersion, maxHistory int) {
 	config.Status.History = config.Status.History[:maxHistory]
 }
 
+// ClusterStatusReconcileDeferred is set on the ClusterVersion status while manifests are not
+// being reconciled because an administrator configured hold-back window is active.
+const ClusterStatusReconcileDeferred configv1.ClusterStatusConditionType = "ReconcileDeferred"
+
+// ClusterStatusPreconditionWarnings is set on the ClusterVersion status while preconditions with
+// warning severity fail for the release being applied.
+const ClusterStatusPreconditionWarnings configv1.ClusterStatusConditionType = "PreconditionWarnings"
+
+// ClusterStatusPreconditionChecks is set on the ClusterVersion status with the result of each
+// precondition check last run for the release being applied. It is False while any check
+// that blocks the update fails.
+const ClusterStatusPreconditionChecks configv1.ClusterStatusConditionType = "PreconditionChecks"
+
+// ClusterStatusDegradedClusterOperatorsTolerated is set on the ClusterVersion status while the
+// administrator tolerates ClusterOperators being degraded during updates. It is True while any
+// of them are degraded.
+const ClusterStatusDegradedClusterOperatorsTolerated configv1.ClusterStatusConditionType = "DegradedClusterOperatorsTolerated"
+
+// ClusterStatusSlowClusterOperators is set on the ClusterVersion status while an update is
+// waiting on ClusterOperators that have been updating for much longer than in earlier updates.
+const ClusterStatusSlowClusterOperators configv1.ClusterStatusConditionType = "SlowClusterOperators"
+
+// ClusterStatusUnsupportedConfiguration is set on the ClusterVersion status while the sync worker
+// is applying a payload in a known unsupported configuration, such as a forced unsigned release.
+const ClusterStatusUnsupportedConfiguration configv1.ClusterStatusConditionType = "UnsupportedConfiguration"
+
+// ClusterStatusManifestsDisabled is set on the ClusterVersion status while manifests of the
+// release being applied are not applied because they belong to a disabled capability.
+const ClusterStatusManifestsDisabled configv1.ClusterStatusConditionType = "ManifestsDisabled"
+
+// ClusterStatusRunLevelBudgetExceeded is set on the ClusterVersion status while an update has
+// spent longer in a run level than the budget given for it in the release metadata.
+const ClusterStatusRunLevelBudgetExceeded configv1.ClusterStatusConditionType = "RunLevelBudgetExceeded"
+
+// ClusterStatusPostUpdateVerified is set on the ClusterVersion status once the verification
+// sweep after an update completes, and is False if it found anything not at the new release.
+const ClusterStatusPostUpdateVerified configv1.ClusterStatusConditionType = "PostUpdateVerified"
+
+// ClusterStatusUpgradeReadiness is set on the ClusterVersion status while something lowers the
+// upgrade readiness score, which is False and given in the message along with its factors.
+const ClusterStatusUpgradeReadiness configv1.ClusterStatusConditionType = "UpgradeReadiness"
+
+// ClusterStatusSyncWorkerStalled is set on the ClusterVersion status while the sync worker
+// has made no progress for longer than the watchdog timeout despite pending work.
+const ClusterStatusSyncWorkerStalled configv1.ClusterStatusConditionType = "SyncWorkerStalled"
+
 // ClusterVersionInvalid indicates that the cluster version has an error that prevents the server from
 // taking action. The cluster version operator will only reconcile the current state as long as this
 // condition is set.
@@ -222,6 +275,142 @@ func (optr *Operator) syncStatus(ctx context.Context, original, config *configv1
 		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterVersionInvalid)
 	}
 
+	// report manifests held back from reconciliation
+	if len(status.Deferred) > 0 {
+		message := fmt.Sprintf("Reconciliation of %d manifests is deferred by an active hold-back window: %s", len(status.Deferred), strings.Join(status.Deferred, ", "))
+		if len(status.Deferred) > 5 {
+			message = fmt.Sprintf("Reconciliation of %d manifests is deferred by an active hold-back window, including: %s", len(status.Deferred), strings.Join(status.Deferred[:5], ", "))
+		}
+		resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+			Type:               ClusterStatusReconcileDeferred,
+			Status:             configv1.ConditionTrue,
+			Reason:             "HoldBackWindowActive",
+			Message:            message,
+			LastTransitionTime: now,
+		})
+	} else {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusReconcileDeferred)
+	}
+
+	// report unsupported configuration found while applying the payload, leaving the
+	// condition alone for status reported before the payload is applied
+	if len(status.Unsupported) > 0 {
+		message := status.Unsupported[0]
+		if len(status.Unsupported) > 1 {
+			message = fmt.Sprintf("The cluster is in an unsupported configuration for multiple reasons:\n* %s", strings.Join(status.Unsupported, "\n* "))
+		}
+		resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+			Type:               ClusterStatusUnsupportedConfiguration,
+			Status:             configv1.ConditionTrue,
+			Reason:             "UnsupportedConfigurationDetected",
+			Message:            message,
+			LastTransitionTime: now,
+		})
+	} else if status.Total > 0 {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusUnsupportedConfiguration)
+	}
+
+	// report precondition failures that did not block the release being applied, leaving the
+	// condition alone for status reported before the payload is applied
+	if len(status.PreconditionWarnings) > 0 {
+		message := status.PreconditionWarnings[0]
+		if len(status.PreconditionWarnings) > 1 {
+			message = fmt.Sprintf("Multiple precondition checks failed with warnings:\n* %s", strings.Join(status.PreconditionWarnings, "\n* "))
+		}
+		resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+			Type:               ClusterStatusPreconditionWarnings,
+			Status:             configv1.ConditionTrue,
+			Reason:             "PreconditionChecksWarned",
+			Message:            message,
+			LastTransitionTime: now,
+		})
+	} else if status.Total > 0 {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusPreconditionWarnings)
+	}
+
+	// report the result of each precondition check, leaving the condition alone for status
+	// reported before the payload is applied
+	if len(status.Preconditions) > 0 {
+		setPreconditionChecksCondition(config, status.Preconditions, now)
+	} else if status.Total > 0 {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusPreconditionChecks)
+	}
+
+	// report the ClusterOperators whose Degraded condition does not block updates
+	optr.setDegradedToleratedCondition(config, now)
+
+	// report manifests left out because their capabilities are disabled, leaving the
+	// condition alone for status reported before the payload is applied
+	if len(status.Disabled) > 0 {
+		message := fmt.Sprintf("%d manifests are not applied because their capabilities are disabled: %s", len(status.Disabled), strings.Join(status.Disabled, ", "))
+		if len(status.Disabled) > 5 {
+			message = fmt.Sprintf("%d manifests are not applied because their capabilities are disabled, including: %s", len(status.Disabled), strings.Join(status.Disabled[:5], ", "))
+		}
+		resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+			Type:               ClusterStatusManifestsDisabled,
+			Status:             configv1.ConditionTrue,
+			Reason:             "CapabilitiesDisabled",
+			Message:            message,
+			LastTransitionTime: now,
+		})
+	} else if status.Total > 0 {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusManifestsDisabled)
+	}
+
+	// warn when an update spends longer in a run level than expected, and check
+	// again once the next running level would exceed its budget
+	overBudget, nextBudgetCheck := runLevelsOverBudget(status.RunLevels, now.Time)
+	if len(overBudget) > 0 {
+		message := fmt.Sprintf("The update is taking longer than expected: %s.", strings.Join(overBudget, "; "))
+		resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+			Type:               ClusterStatusRunLevelBudgetExceeded,
+			Status:             configv1.ConditionTrue,
+			Reason:             "RunLevelBudgetExceeded",
+			Message:            message,
+			LastTransitionTime: now,
+		})
+	} else if status.Total > 0 {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusRunLevelBudgetExceeded)
+	}
+	if nextBudgetCheck > 0 {
+		optr.queue.AddAfter(optr.queueKey(), nextBudgetCheck)
+	}
+
+	// report the verification sweep of the most recently completed update
+	if result := optr.getPostUpdateVerification(ctx); result != nil && result.image == status.Actual.Image {
+		condition := configv1.ClusterOperatorStatusCondition{
+			Type:               ClusterStatusPostUpdateVerified,
+			Status:             configv1.ConditionTrue,
+			Reason:             "NoProblemsFound",
+			Message:            fmt.Sprintf("Verification after the update to %s found every cluster operator, workload and manifest at the new release.", result.version),
+			LastTransitionTime: now,
+		}
+		if len(result.findings) > 0 {
+			condition.Status = configv1.ConditionFalse
+			condition.Reason = "ProblemsFound"
+			condition.Message = fmt.Sprintf("Verification after the update to %s found %d problems:\n* %s", result.version, len(result.findings), strings.Join(result.findings, "\n* "))
+			if len(result.findings) > 10 {
+				condition.Message = fmt.Sprintf("Verification after the update to %s found %d problems, including:\n* %s", result.version, len(result.findings), strings.Join(result.findings[:10], "\n* "))
+			}
+		}
+		resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, condition)
+	} else if status.Total > 0 {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusPostUpdateVerified)
+	}
+
+	// report a sync worker the watchdog found stalled until it makes progress again
+	if lastProgress := optr.watchdog.stalled(); !lastProgress.IsZero() {
+		resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
+			Type:               ClusterStatusSyncWorkerStalled,
+			Status:             configv1.ConditionTrue,
+			Reason:             "NoProgress",
+			Message:            fmt.Sprintf("The cluster-version operator has made no progress applying the release since %s despite pending work. Goroutine stacks were written to the operator log.", lastProgress.UTC().Format(time.RFC3339)),
+			LastTransitionTime: now,
+		})
+	} else {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusSyncWorkerStalled)
+	}
+
 	// set the available condition
 	if status.Completed > 0 {
 		resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, configv1.ClusterOperatorStatusCondition{
@@ -246,7 +435,8 @@ func (optr *Operator) syncStatus(ctx context.Context, original, config *configv1
 	if err := status.Failure; err != nil && !skipFailure {
 		var reason string
 		msg := progressMessage
-		if uErr, ok := err.(*payload.UpdateError); ok {
+		var uErr *payload.UpdateError
+		if errors.As(err, &uErr) {
 			reason = uErr.Reason
 			if msg == "" {
 				msg = payload.SummaryForReason(reason, uErr.Name)
@@ -260,7 +450,7 @@ func (optr *Operator) syncStatus(ctx context.Context, original, config *configv1
 			Type:               ClusterStatusFailing,
 			Status:             configv1.ConditionTrue,
 			Reason:             reason,
-			Message:            err.Error(),
+			Message:            failureMessage(err),
 			LastTransitionTime: now,
 		})
 
@@ -310,9 +500,11 @@ func (optr *Operator) syncStatus(ctx context.Context, original, config *configv1
 				reason = progressReason
 				message = fmt.Sprintf("Working towards %s: %d of %d done (%.0f%% complete), %s", version,
 					status.Done, status.Total, math.Trunc(float64(fractionComplete*100)), progressMessage)
+				message += optr.operatorsNotAtVersionMessage(status.Actual.Version)
 			case fractionComplete > 0:
 				message = fmt.Sprintf("Working towards %s: %d of %d done (%.0f%% complete)", version,
 					status.Done, status.Total, math.Trunc(float64(fractionComplete*100)))
+				message += optr.operatorsNotAtVersionMessage(status.Actual.Version)
 			case status.Step == "RetrievePayload":
 				if len(reason) == 0 {
 					reason = "DownloadingUpdate"
@@ -343,14 +535,159 @@ func (optr *Operator) syncStatus(ctx context.Context, original, config *configv1
 		})
 	}
 
+	optr.setSlowOperatorsCondition(ctx, config, status, now)
+	optr.setRollbackCondition(config, status, now)
+
+	// summarize the risks of updating once every other condition is set
+	optr.setUpgradeReadinessCondition(config, now)
+
 	if klog.V(6).Enabled() {
 		klog.Infof("Apply config: %s", diff.ObjectReflectDiff(original, config))
 	}
+	if optr.coalesceStatusWrite(original, config) {
+		return nil
+	}
 	updated, err := applyClusterVersionStatus(ctx, optr.client.ConfigV1(), config, original)
 	optr.rememberLastUpdate(updated)
+	if err == nil {
+		optr.syncUpdateAudit(ctx, updated.Status.History)
+		optr.syncOperatorDurations(ctx, updated.Status.History)
+		optr.syncCheckpoint(ctx, updated.Status.History)
+	}
 	return err
 }
 
+// maxOperatorsNotAtVersion is how many of the ClusterOperators not yet at the version being
+// applied the Progressing message names.
+const maxOperatorsNotAtVersion = 5
+
+// operatorsNotAtVersionMessage returns a suffix for the Progressing message naming the
+// ClusterOperators that report a version of their operator other than version, or an empty
+// string if there are none or the version is not known.
+func (optr *Operator) operatorsNotAtVersionMessage(version string) string {
+	if optr.operatorVersions == nil || len(version) == 0 {
+		return ""
+	}
+	names := optr.operatorVersions.NotAtVersion(version)
+	switch {
+	case len(names) == 0:
+		return ""
+	case len(names) > maxOperatorsNotAtVersion:
+		return fmt.Sprintf("; %d cluster operators are not yet at %s, including %s", len(names), version, strings.Join(names[:maxOperatorsNotAtVersion], ", "))
+	case len(names) == 1:
+		return fmt.Sprintf("; cluster operator %s is not yet at %s", names[0], version)
+	default:
+		return fmt.Sprintf("; cluster operators %s are not yet at %s", strings.Join(names, ", "), version)
+	}
+}
+
+// coalesceStatusWrite returns true if the change from original to required should not be
+// written yet. Transitions are always written, while changes to messages and progress are
+// limited by statusWriteLimiter and the sync is requeued so later changes are written together.
+func (optr *Operator) coalesceStatusWrite(original, required *configv1.ClusterVersion) bool {
+	if optr.statusWriteLimiter == nil || original == nil || equality.Semantic.DeepEqual(&original.Status, &required.Status) {
+		return false
+	}
+	if isStatusTransition(&original.Status, &required.Status) {
+		optr.statusWriteLimiter.Allow()
+		return false
+	}
+	reservation := optr.statusWriteLimiter.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return false
+	}
+	reservation.Cancel()
+	klog.V(4).Infof("Coalescing cluster version status changes for %s", delay)
+	metricStatusWrites.WithLabelValues("coalesced").Inc()
+	optr.queue.AddAfter(optr.queueKey(), delay)
+	return true
+}
+
+// isStatusTransition returns true if required differs from original by more than condition
+// messages, such as by a condition changing status or reason or by a change to the history.
+func isStatusTransition(original, required *configv1.ClusterVersionStatus) bool {
+	if original.ObservedGeneration != required.ObservedGeneration ||
+		original.VersionHash != required.VersionHash ||
+		!equality.Semantic.DeepEqual(original.Desired, required.Desired) ||
+		!equality.Semantic.DeepEqual(original.History, required.History) ||
+		!equality.Semantic.DeepEqual(original.AvailableUpdates, required.AvailableUpdates) ||
+		len(original.Conditions) != len(required.Conditions) {
+		return true
+	}
+	for _, condition := range required.Conditions {
+		existing := resourcemerge.FindOperatorStatusCondition(original.Conditions, condition.Type)
+		if existing == nil || existing.Status != condition.Status || existing.Reason != condition.Reason {
+			return true
+		}
+	}
+	return false
+}
+
+// setPreconditionChecksCondition describes each of results on the ClusterVersion, with when
+// the check was last run and since when it has reported its reason.
+func setPreconditionChecksCondition(config *configv1.ClusterVersion, results precondition.Results, now metav1.Time) {
+	condition := configv1.ClusterOperatorStatusCondition{
+		Type:               ClusterStatusPreconditionChecks,
+		Status:             configv1.ConditionTrue,
+		Reason:             "PreconditionChecksPassed",
+		LastTransitionTime: now,
+	}
+	lines := make([]string, 0, len(results))
+	for _, result := range results {
+		line := fmt.Sprintf("%s: %s, last checked %s", result.Name, result.Reason, result.LastProbeTime.UTC().Format(time.RFC3339))
+		if result.Err != nil {
+			line = fmt.Sprintf("%s: %s since %s, last checked %s: %s", result.Name, result.Reason, result.Since.UTC().Format(time.RFC3339), result.LastProbeTime.UTC().Format(time.RFC3339), result.Message)
+		}
+		lines = append(lines, line)
+		if !result.Blocking() {
+			continue
+		}
+		if condition.Status == configv1.ConditionTrue {
+			condition.Status = configv1.ConditionFalse
+			condition.Reason = result.Reason
+		} else if condition.Reason != result.Reason {
+			condition.Reason = "MultiplePreconditionChecksFailed"
+		}
+	}
+	condition.Message = fmt.Sprintf("Precondition check results:\n* %s", strings.Join(lines, "\n* "))
+	resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, condition)
+}
+
+// setDegradedToleratedCondition describes the ClusterOperators listed in the tolerated degraded
+// operators annotation of the ClusterVersion, and which of them are currently degraded.
+func (optr *Operator) setDegradedToleratedCondition(config *configv1.ClusterVersion, now metav1.Time) {
+	names := internal.ToleratedDegradedOperators(config.Annotations)
+	if len(names) == 0 {
+		resourcemerge.RemoveOperatorStatusCondition(&config.Status.Conditions, ClusterStatusDegradedClusterOperatorsTolerated)
+		return
+	}
+	var degraded []string
+	for _, name := range names {
+		if optr.coLister == nil {
+			continue
+		}
+		if co, err := optr.coLister.Get(name); err == nil {
+			if c := resourcemerge.FindOperatorStatusCondition(co.Status.Conditions, configv1.OperatorDegraded); c != nil && c.Status == configv1.ConditionTrue {
+				degraded = append(degraded, fmt.Sprintf("%s (%s: %s)", name, c.Reason, c.Message))
+			}
+		}
+	}
+	condition := configv1.ClusterOperatorStatusCondition{
+		Type:               ClusterStatusDegradedClusterOperatorsTolerated,
+		Status:             configv1.ConditionFalse,
+		Reason:             "NoneDegraded",
+		Message:            fmt.Sprintf("The %s annotation tolerates %s being degraded during updates, and none of them are degraded.", internal.ToleratedDegradedOperatorsAnnotation, strings.Join(names, ", ")),
+		LastTransitionTime: now,
+	}
+	if len(degraded) > 0 {
+		condition.Status = configv1.ConditionTrue
+		condition.Reason = "DegradedTolerated"
+		condition.Message = fmt.Sprintf("Updates proceed although these cluster operators are degraded, as the %s annotation tolerates: %s", internal.ToleratedDegradedOperatorsAnnotation, strings.Join(degraded, "; "))
+	}
+	resourcemerge.SetOperatorStatusCondition(&config.Status.Conditions, condition)
+}
+
 // convertErrorToProgressing returns true if the provided status indicates a failure condition can be interpreted as
 // still making internal progress. The general error we try to suppress is an operator or operators still being
 // unavailable AND the general payload task making progress towards its goal. The error's UpdateEffect determines
@@ -360,8 +697,8 @@ func convertErrorToProgressing(history []configv1.UpdateHistory, now time.Time,
 	if len(history) == 0 || status.Failure == nil || status.Reconciling {
 		return "", "", false
 	}
-	uErr, ok := status.Failure.(*payload.UpdateError)
-	if !ok {
+	var uErr *payload.UpdateError
+	if !errors.As(status.Failure, &uErr) {
 		return "", "", false
 	}
 	switch uErr.UpdateEffect {
@@ -386,6 +723,35 @@ func convertErrorToProgressing(history []configv1.UpdateHistory, now time.Time,
 	return "", "", false
 }
 
+// failureMessage returns the message of err followed by the distinct errors observed
+// while waiting on each of the ClusterOperators it reports, so that a cluster which waited
+// a long time on an operator explains what the operator went through.
+func failureMessage(err error) string {
+	var buf strings.Builder
+	buf.WriteString(err.Error())
+	var histories func(err error)
+	histories = func(err error) {
+		var uErr *payload.UpdateError
+		if !errors.As(err, &uErr) {
+			return
+		}
+		if len(uErr.History) > 0 {
+			fmt.Fprintf(&buf, "\nRecent errors waiting on %s:", uErr.Name)
+			for _, observed := range uErr.History {
+				fmt.Fprintf(&buf, "\n* %s %s: %s", observed.Time.UTC().Format(time.RFC3339), observed.Reason, observed.Message)
+			}
+			return
+		}
+		if agg, ok := uErr.Nested.(utilerrors.Aggregate); ok {
+			for _, nested := range agg.Errors() {
+				histories(nested)
+			}
+		}
+	}
+	histories(err)
+	return buf.String()
+}
+
 // syncFailingStatus handles generic errors in the cluster version. It tries to preserve
 // all status fields that it can by using the provided config or loading the latest version
 // from the cache (instead of clearing the status).
@@ -446,33 +812,143 @@ func (optr *Operator) syncFailingStatus(ctx context.Context, original *configv1.
 	return ierr
 }
 
+var (
+	metricStatusWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cluster_version_status_writes_total",
+		Help: "Reports the number of ClusterVersion status writes by result. 'applied' writes succeeded, 'coalesced' changes were deferred into a later write, 'conflict' writes were rejected by the server with a conflict and retried, and 'error' writes failed.",
+	}, []string{"result"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		metricStatusWrites,
+	)
+}
+
+// errClusterVersionRecreated is returned when the status of a cluster version cannot be
+// written because the cluster version was deleted and recreated since it was read.
+var errClusterVersionRecreated = errors.New("the cluster version was deleted and recreated")
+
 // applyClusterVersionStatus attempts to overwrite the status subresource of required. If
 // original is provided it is compared to required and no update will be made if the
-// object does not change. The method will retry a conflict by retrieving the latest live
-// version and updating the metadata of required. required is modified if the object on
-// the server is newer.
+// object does not change. The status is written with a JSON patch that changes only the
+// status fields that differ from original, each guarded by its original value, so that
+// writes to the rest of the object by other clients do not conflict with it and changes
+// to the status made since original was read are not overwritten silently. If the status
+// changed, the cluster version is read again and the patch retried once against it. The
+// patch fails with errClusterVersionRecreated if the cluster version was deleted and
+// recreated. required is modified if the object on the server is newer.
 func applyClusterVersionStatus(ctx context.Context, client configclientv1.ClusterVersionsGetter, required, original *configv1.ClusterVersion) (*configv1.ClusterVersion, error) {
 	if original != nil && equality.Semantic.DeepEqual(&original.Status, &required.Status) {
 		return required, nil
 	}
-	actual, err := client.ClusterVersions().UpdateStatus(ctx, required, metav1.UpdateOptions{})
-	if apierrors.IsConflict(err) {
-		existing, cErr := client.ClusterVersions().Get(ctx, required.Name, metav1.GetOptions{})
-		if err != nil {
-			return nil, cErr
+	patch, err := clusterVersionStatusPatch(original, required)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := client.ClusterVersions().Patch(ctx, required.Name, types.JSONPatchType, patch, metav1.PatchOptions{}, "status")
+	if isStatusPatchConflict(err) {
+		metricStatusWrites.WithLabelValues("conflict").Inc()
+		actual, err = retryClusterVersionStatus(ctx, client, required)
+	}
+	if err != nil {
+		metricStatusWrites.WithLabelValues("error").Inc()
+		return nil, err
+	}
+	metricStatusWrites.WithLabelValues("applied").Inc()
+	required.ObjectMeta = actual.ObjectMeta
+	return actual, nil
+}
+
+// retryClusterVersionStatus reads the cluster version again and patches its status to the
+// status of required.
+func retryClusterVersionStatus(ctx context.Context, client configclientv1.ClusterVersionsGetter, required *configv1.ClusterVersion) (*configv1.ClusterVersion, error) {
+	current, err := client.ClusterVersions().Get(ctx, required.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(required.UID) > 0 && current.UID != required.UID {
+		return nil, fmt.Errorf("%w: %s has UID %s, expected %s", errClusterVersionRecreated, required.Name, current.UID, required.UID)
+	}
+	if equality.Semantic.DeepEqual(&current.Status, &required.Status) {
+		return current, nil
+	}
+	patch, err := clusterVersionStatusPatch(current, required)
+	if err != nil {
+		return nil, err
+	}
+	return client.ClusterVersions().Patch(ctx, required.Name, types.JSONPatchType, patch, metav1.PatchOptions{}, "status")
+}
+
+// isStatusPatchConflict returns true if err reports that the status patch did not apply
+// because the cluster version changed, which the server reports as an invalid patch when a
+// test operation fails.
+func isStatusPatchConflict(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsInvalid(err)
+}
+
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// clusterVersionStatusPatch returns a JSON patch changing the status fields of original to
+// those of required, guarded by the UID of the cluster version when known. Each field that
+// original sets is tested against its value there before it is changed. Without an
+// original status the whole status is written.
+func clusterVersionStatusPatch(original, required *configv1.ClusterVersion) ([]byte, error) {
+	var patch []jsonPatchOperation
+	if len(required.UID) > 0 {
+		patch = append(patch, jsonPatchOperation{Op: "test", Path: "/metadata/uid", Value: required.UID})
+	}
+	if original == nil {
+		patch = append(patch, jsonPatchOperation{Op: "add", Path: "/status", Value: required.Status})
+		return json.Marshal(patch)
+	}
+	originalFields, err := statusFields(&original.Status)
+	if err != nil {
+		return nil, err
+	}
+	requiredFields, err := statusFields(&required.Status)
+	if err != nil {
+		return nil, err
+	}
+	names := sets.NewString()
+	for name := range originalFields {
+		names.Insert(name)
+	}
+	for name := range requiredFields {
+		names.Insert(name)
+	}
+	for _, name := range names.List() {
+		from, hasFrom := originalFields[name]
+		to, hasTo := requiredFields[name]
+		if hasFrom && hasTo && bytes.Equal(from, to) {
+			continue
 		}
-		if existing.UID != required.UID {
-			return nil, fmt.Errorf("cluster version was deleted and recreated, cannot update status")
+		path := "/status/" + name
+		if hasFrom {
+			patch = append(patch, jsonPatchOperation{Op: "test", Path: path, Value: from})
 		}
-		if equality.Semantic.DeepEqual(&existing.Status, &required.Status) {
-			return existing, nil
+		if hasTo {
+			patch = append(patch, jsonPatchOperation{Op: "add", Path: path, Value: to})
+		} else {
+			patch = append(patch, jsonPatchOperation{Op: "remove", Path: path})
 		}
-		required.ObjectMeta = existing.ObjectMeta
-		actual, err = client.ClusterVersions().UpdateStatus(ctx, required, metav1.UpdateOptions{})
 	}
+	return json.Marshal(patch)
+}
+
+// statusFields returns the serialized fields of status by name.
+func statusFields(status *configv1.ClusterVersionStatus) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(status)
 	if err != nil {
 		return nil, err
 	}
-	required.ObjectMeta = actual.ObjectMeta
-	return actual, nil
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
 }
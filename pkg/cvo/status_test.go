@@ -2,16 +2,29 @@ package cvo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
+	"golang.org/x/time/rate"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/diff"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 
 	configv1 "github.com/openshift/api/config/v1"
 	"github.com/openshift/client-go/config/clientset/versioned/fake"
+	configlistersv1 "github.com/openshift/client-go/config/listers/config/v1"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+	"github.com/openshift/cluster-version-operator/pkg/internal"
+	"github.com/openshift/cluster-version-operator/pkg/operatorversions"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
 )
 
 func Test_mergeEqualVersions(t *testing.T) {
@@ -271,3 +284,289 @@ func TestOperator_syncFailingStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestOperator_coalesceStatusWrite(t *testing.T) {
+	original := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Status: configv1.ClusterVersionStatus{
+			Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue, Message: "Working towards 4.0.1: 10 of 100 done"},
+			},
+		},
+	}
+	withProgressing := func(status configv1.ConditionStatus, message string) *configv1.ClusterVersion {
+		cv := original.DeepCopy()
+		cv.Status.Conditions[0].Status = status
+		cv.Status.Conditions[0].Message = message
+		return cv
+	}
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "coalesce-test")
+	defer queue.ShutDown()
+	optr := &Operator{
+		name:               "version",
+		queue:              queue,
+		statusWriteLimiter: rate.NewLimiter(rate.Every(time.Hour), 1),
+	}
+
+	if optr.coalesceStatusWrite(original, original.DeepCopy()) {
+		t.Fatal("unchanged status must not be coalesced")
+	}
+	if optr.coalesceStatusWrite(original, withProgressing(configv1.ConditionTrue, "Working towards 4.0.1: 20 of 100 done")) {
+		t.Fatal("the first progress change must be written")
+	}
+	if !optr.coalesceStatusWrite(original, withProgressing(configv1.ConditionTrue, "Working towards 4.0.1: 30 of 100 done")) {
+		t.Fatal("further progress changes must be coalesced")
+	}
+	if optr.coalesceStatusWrite(original, withProgressing(configv1.ConditionFalse, "Cluster version is 4.0.1")) {
+		t.Fatal("transitions must always be written")
+	}
+}
+
+func Test_clusterVersionStatusPatch(t *testing.T) {
+	original := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version", UID: "abc"},
+		Spec:       configv1.ClusterVersionSpec{Channel: "fast"},
+		Status:     configv1.ClusterVersionStatus{ObservedGeneration: 1, VersionHash: "x"},
+	}
+	cv := original.DeepCopy()
+	cv.Status.ObservedGeneration = 2
+	cv.Status.Conditions = []configv1.ClusterOperatorStatusCondition{{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue}}
+	patch, err := clusterVersionStatusPatch(original, cv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(patch), `[{"op":"test","path":"/metadata/uid","value":"abc"},{"op":"add","path":"/status/conditions","value":[{"type":"Available","status":"True","lastTransitionTime":null}]},{"op":"test","path":"/status/observedGeneration","value":1},{"op":"add","path":"/status/observedGeneration","value":2}]`; got != want {
+		t.Fatalf("unexpected patch:\n%s\n%s", got, want)
+	}
+
+	existing := original.DeepCopy()
+	existing.Spec.Channel = "stable"
+	existing.Status.History = []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Image: "image/image:1"}}
+	if err := applyStatusPatch(existing, patch); err != nil {
+		t.Fatal(err)
+	}
+	if existing.Spec.Channel != "stable" || existing.Status.ObservedGeneration != 2 || len(existing.Status.Conditions) != 1 || existing.Status.VersionHash != "x" {
+		t.Fatalf("patch must only change the status fields that changed: %#v", existing)
+	}
+	if len(existing.Status.History) != 1 {
+		t.Fatalf("patch must not change status fields it does not change: %#v", existing.Status)
+	}
+
+	existing = original.DeepCopy()
+	existing.Status.ObservedGeneration = 3
+	if err := applyStatusPatch(existing, patch); err == nil {
+		t.Fatal("patch must not apply to a status field that changed since it was read")
+	}
+	existing = original.DeepCopy()
+	existing.UID = "recreated"
+	if err := applyStatusPatch(existing, patch); err == nil {
+		t.Fatal("patch must not apply to a recreated cluster version")
+	}
+
+	// fields that are no longer set are removed
+	patch, err = clusterVersionStatusPatch(cv, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(patch), `[{"op":"test","path":"/metadata/uid","value":"abc"},{"op":"test","path":"/status/conditions","value":[{"type":"Available","status":"True","lastTransitionTime":null}]},{"op":"remove","path":"/status/conditions"},{"op":"test","path":"/status/observedGeneration","value":2},{"op":"add","path":"/status/observedGeneration","value":1}]`; got != want {
+		t.Fatalf("unexpected patch:\n%s\n%s", got, want)
+	}
+
+	// without an original status the whole status is written
+	patch, err = clusterVersionStatusPatch(nil, cv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing = original.DeepCopy()
+	existing.Status = configv1.ClusterVersionStatus{}
+	if err := applyStatusPatch(existing, patch); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(existing.Status, cv.Status) {
+		t.Fatalf("unexpected status: %s", diff.ObjectReflectDiff(cv.Status, existing.Status))
+	}
+}
+
+func Test_applyClusterVersionStatus(t *testing.T) {
+	ctx := context.Background()
+	original := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version", UID: "abc", ResourceVersion: "1"},
+		Status:     configv1.ClusterVersionStatus{ObservedGeneration: 1, VersionHash: "x"},
+	}
+
+	// a status read before another client changed it is retried against the current status
+	existing := original.DeepCopy()
+	existing.Status.VersionHash = "y"
+	client := fakeClientsetWithUpdates(existing)
+	required := original.DeepCopy()
+	required.Status.VersionHash = "z"
+	actual, err := applyClusterVersionStatus(ctx, client.ConfigV1(), required, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.Status.VersionHash != "z" || existing.Status.VersionHash != "z" {
+		t.Fatalf("unexpected status after retry: %#v", existing.Status)
+	}
+	var patches int
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "patch" {
+			patches++
+		}
+	}
+	if patches != 2 {
+		t.Fatalf("expected the status patch to be retried once, got %d patches: %v", patches, client.Actions())
+	}
+
+	// a recreated cluster version is reported as such and not written
+	existing = original.DeepCopy()
+	existing.UID = "recreated"
+	client = fakeClientsetWithUpdates(existing)
+	required = original.DeepCopy()
+	required.Status.VersionHash = "z"
+	if _, err := applyClusterVersionStatus(ctx, client.ConfigV1(), required, original); !errors.Is(err, errClusterVersionRecreated) {
+		t.Fatalf("expected the recreated cluster version to be reported, got %v", err)
+	}
+	if existing.Status.VersionHash != "x" {
+		t.Fatalf("the status of a recreated cluster version must not be written: %#v", existing.Status)
+	}
+}
+
+func Test_failureMessage(t *testing.T) {
+	history := []payload.ErrorObservation{
+		{Time: time.Unix(0, 0), Reason: "ClusterOperatorNotAvailable", Message: "Cluster operator ingress is still updating"},
+		{Time: time.Unix(60, 0), Reason: "ClusterOperatorDegraded", Message: "Cluster operator ingress is degraded"},
+	}
+	ingress := &payload.UpdateError{Reason: "ClusterOperatorDegraded", Message: "Cluster operator ingress is degraded", Name: "ingress", History: history}
+	network := &payload.UpdateError{Reason: "ClusterOperatorNotAvailable", Message: "Cluster operator network is not available", Name: "network"}
+	multiple := &payload.UpdateError{Reason: "MultipleErrors", Message: "Multiple errors are preventing progress", Nested: utilerrors.NewAggregate([]error{ingress, network})}
+
+	expected := `Multiple errors are preventing progress
+Recent errors waiting on ingress:
+* 1970-01-01T00:00:00Z ClusterOperatorNotAvailable: Cluster operator ingress is still updating
+* 1970-01-01T00:01:00Z ClusterOperatorDegraded: Cluster operator ingress is degraded`
+	if message := failureMessage(multiple); message != expected {
+		t.Fatalf("unexpected message:\n%s\nexpected:\n%s", message, expected)
+	}
+	if message := failureMessage(network); message != network.Message {
+		t.Fatalf("unexpected message without history: %s", message)
+	}
+}
+
+func Test_setPreconditionChecksCondition(t *testing.T) {
+	probed := time.Unix(120, 0)
+	failure := &precondition.Error{Reason: "UpgradeGateNotAcknowledged", Message: "Upgrade gate \"backup-verified\" has not been acknowledged for 4.8.2", Name: "UpgradeGates"}
+	results := precondition.Results{
+		{Name: "UpgradeGates", Reason: failure.Reason, Message: failure.Message, Severity: precondition.SeverityBlocking, LastProbeTime: probed, Since: time.Unix(0, 0), Err: failure},
+		{Name: "EtcdBackup", Reason: "PreconditionFailed", Message: "the most recent etcd backup is 30h old", Severity: precondition.SeverityWarning, LastProbeTime: probed, Since: probed, Err: errors.New("the most recent etcd backup is 30h old")},
+		{Name: "ClusterVersionUpgradeable", Reason: "Passed", Severity: precondition.SeverityBlocking, LastProbeTime: probed, Since: probed},
+	}
+	config := &configv1.ClusterVersion{}
+	setPreconditionChecksCondition(config, results, metav1.Now())
+	expected := `Precondition check results:
+* UpgradeGates: UpgradeGateNotAcknowledged since 1970-01-01T00:00:00Z, last checked 1970-01-01T00:02:00Z: Upgrade gate "backup-verified" has not been acknowledged for 4.8.2
+* EtcdBackup: PreconditionFailed since 1970-01-01T00:02:00Z, last checked 1970-01-01T00:02:00Z: the most recent etcd backup is 30h old
+* ClusterVersionUpgradeable: Passed, last checked 1970-01-01T00:02:00Z`
+	condition := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusPreconditionChecks)
+	if condition == nil || condition.Status != configv1.ConditionFalse || condition.Reason != "UpgradeGateNotAcknowledged" || condition.Message != expected {
+		t.Fatalf("unexpected condition: %#v", condition)
+	}
+
+	// failures that do not block the update leave the condition True
+	setPreconditionChecksCondition(config, results[1:], metav1.Now())
+	condition = resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusPreconditionChecks)
+	if condition.Status != configv1.ConditionTrue || condition.Reason != "PreconditionChecksPassed" {
+		t.Fatalf("unexpected condition: %#v", condition)
+	}
+}
+
+func TestOperator_setDegradedToleratedCondition(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	registry := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "image-registry"},
+		Status: configv1.ClusterOperatorStatus{Conditions: []configv1.ClusterOperatorStatusCondition{
+			{Type: configv1.OperatorDegraded, Status: configv1.ConditionFalse},
+		}},
+	}
+	if err := indexer.Add(registry); err != nil {
+		t.Fatal(err)
+	}
+	optr := &Operator{coLister: configlistersv1.NewClusterOperatorLister(indexer)}
+	config := &configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{internal.ToleratedDegradedOperatorsAnnotation: "image-registry, monitoring"}}}
+
+	optr.setDegradedToleratedCondition(config, metav1.Now())
+	condition := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusDegradedClusterOperatorsTolerated)
+	if condition == nil || condition.Status != configv1.ConditionFalse || condition.Message != "The release.openshift.io/tolerated-degraded-operators annotation tolerates image-registry, monitoring being degraded during updates, and none of them are degraded." {
+		t.Fatalf("unexpected condition: %#v", condition)
+	}
+
+	registry = registry.DeepCopy()
+	registry.Status.Conditions[0] = configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue, Reason: "StorageNotConfigured", Message: "storage backend not configured"}
+	if err := indexer.Update(registry); err != nil {
+		t.Fatal(err)
+	}
+	optr.setDegradedToleratedCondition(config, metav1.Now())
+	condition = resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusDegradedClusterOperatorsTolerated)
+	if condition == nil || condition.Status != configv1.ConditionTrue || condition.Message != "Updates proceed although these cluster operators are degraded, as the release.openshift.io/tolerated-degraded-operators annotation tolerates: image-registry (StorageNotConfigured: storage backend not configured)" {
+		t.Fatalf("unexpected condition: %#v", condition)
+	}
+
+	config.Annotations = nil
+	optr.setDegradedToleratedCondition(config, metav1.Now())
+	if condition := resourcemerge.FindOperatorStatusCondition(config.Status.Conditions, ClusterStatusDegradedClusterOperatorsTolerated); condition != nil {
+		t.Fatalf("unexpected condition without the annotation: %#v", condition)
+	}
+}
+
+func TestOperator_syncStatusOperatorsNotAtVersion(t *testing.T) {
+	ctx := context.Background()
+	cv := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version", ResourceVersion: "1"},
+		Status: configv1.ClusterVersionStatus{
+			History: []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Version: "4.1.0", Image: "image/image:1"}},
+		},
+	}
+	client := fakeClientsetWithUpdates(cv.DeepCopy())
+	optr := &Operator{name: "version", client: client, operatorVersions: operatorversions.New()}
+	optr.cvLister = &clientCVLister{client: client}
+	optr.coLister = &clientCOLister{client: client}
+	for name, version := range map[string]string{"dns": "4.2.0", "network": "4.1.0", "ingress": "4.1.0", "storage": ""} {
+		co := &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if len(version) > 0 {
+			co.Status.Versions = []configv1.OperandVersion{{Name: "operator", Version: version}}
+		}
+		optr.operatorVersions.Set(co)
+	}
+	// an add-on operator that is not part of the release is not named
+	optr.operatorVersions.Set(&configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "addon"},
+		Status:     configv1.ClusterOperatorStatus{Versions: []configv1.OperandVersion{{Name: "addon-operator", Version: "0.3.0"}}},
+	})
+
+	status := &SyncWorkerStatus{Done: 10, Total: 100, Actual: configv1.Release{Version: "4.2.0", Image: "image/image:2"}}
+	if err := optr.syncStatus(ctx, cv, cv.DeepCopy(), status, nil); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := client.ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	condition := resourcemerge.FindOperatorStatusCondition(updated.Status.Conditions, configv1.OperatorProgressing)
+	if expected := "Working towards 4.2.0: 10 of 100 done (10% complete); cluster operators ingress, network are not yet at 4.2.0"; condition == nil || condition.Message != expected {
+		t.Fatalf("unexpected condition %#v, expected message %q", condition, expected)
+	}
+
+	names := []string{"a", "b", "c", "d", "e", "f"}
+	optr.operatorVersions = operatorversions.New()
+	for _, name := range names {
+		optr.operatorVersions.Set(&configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     configv1.ClusterOperatorStatus{Versions: []configv1.OperandVersion{{Name: "operator", Version: "4.1.0"}}},
+		})
+	}
+	if message, expected := optr.operatorsNotAtVersionMessage("4.2.0"), "; 6 cluster operators are not yet at 4.2.0, including a, b, c, d, e"; message != expected {
+		t.Fatalf("unexpected message %q, expected %q", message, expected)
+	}
+	if message := optr.operatorsNotAtVersionMessage(""); message != "" {
+		t.Fatalf("unexpected message without a version: %q", message)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"k8s.io/client-go/tools/record"
 
@@ -36,6 +37,7 @@ import (
 func Test_SyncWorker_apply(t *testing.T) {
 	tests := []struct {
 		manifests   []string
+		state       payload.State
 		reactors    map[action]error
 		cancelAfter int
 
@@ -108,6 +110,85 @@ func Test_SyncWorker_apply(t *testing.T) {
 				t.Fatalf("%s", diff.ObjectReflectDiff(exp, got))
 			}
 		},
+	}, {
+		manifests: []string{
+			`{
+				"apiVersion": "test.cvo.io/v1",
+				"kind": "TestA",
+				"metadata": {
+					"namespace": "default",
+					"name": "testa"
+				}
+			}`,
+			`{
+				"apiVersion": "test.cvo.io/v1",
+				"kind": "TestB",
+				"metadata": {
+					"namespace": "default",
+					"name": "testb",
+					"annotations": {
+						"release.openshift.io/security-critical": "true"
+					}
+				}
+			}`,
+		},
+		state:    payload.ReconcilingPayload,
+		reactors: map[action]error{},
+		check: func(t *testing.T, actions []action) {
+			if len(actions) != 2 {
+				spew.Dump(actions)
+				t.Fatalf("unexpected %d actions", len(actions))
+			}
+
+			if got, exp := actions[0], (newAction(schema.GroupVersionKind{Group: "test.cvo.io", Version: "v1", Kind: "TestB"}, "default", "testb")); !reflect.DeepEqual(got, exp) {
+				t.Fatalf("%s", diff.ObjectReflectDiff(exp, got))
+			}
+			if got, exp := actions[1], (newAction(schema.GroupVersionKind{Group: "test.cvo.io", Version: "v1", Kind: "TestA"}, "default", "testa")); !reflect.DeepEqual(got, exp) {
+				t.Fatalf("%s", diff.ObjectReflectDiff(exp, got))
+			}
+		},
+	}, {
+		manifests: []string{
+			`{
+				"apiVersion": "test.cvo.io/v1",
+				"kind": "TestA",
+				"metadata": {
+					"namespace": "default",
+					"name": "testa"
+				}
+			}`,
+			`{
+				"apiVersion": "test.cvo.io/v1",
+				"kind": "TestB",
+				"metadata": {
+					"namespace": "default",
+					"name": "testb",
+					"annotations": {
+						"release.openshift.io/security-critical": "true"
+					}
+				}
+			}`,
+		},
+		state: payload.ReconcilingPayload,
+		reactors: map[action]error{
+			newAction(schema.GroupVersionKind{Group: "test.cvo.io", Version: "v1", Kind: "TestB"}, "default", "testb"): &meta.NoResourceMatchError{},
+		},
+		cancelAfter: 5,
+		wantErr:     true,
+		check: func(t *testing.T, actions []action) {
+			// the failing security-critical manifest is applied once and the rest of the payload is still applied
+			if len(actions) != 2 {
+				spew.Dump(actions)
+				t.Fatalf("unexpected %d actions", len(actions))
+			}
+
+			if got, exp := actions[0], (newAction(schema.GroupVersionKind{Group: "test.cvo.io", Version: "v1", Kind: "TestB"}, "default", "testb")); !reflect.DeepEqual(got, exp) {
+				t.Fatalf("%s", diff.ObjectReflectDiff(exp, got))
+			}
+			if got, exp := actions[1], (newAction(schema.GroupVersionKind{Group: "test.cvo.io", Version: "v1", Kind: "TestA"}, "default", "testa")); !reflect.DeepEqual(got, exp) {
+				t.Fatalf("%s", diff.ObjectReflectDiff(exp, got))
+			}
+		},
 	}}
 	for idx, test := range tests {
 		t.Run(fmt.Sprintf("test#%d", idx), func(t *testing.T) {
@@ -144,12 +225,73 @@ func Test_SyncWorker_apply(t *testing.T) {
 				remainingErrors: test.cancelAfter,
 			}
 
-			worker.apply(ctx, up, &SyncWork{}, 1, &statusWrapper{w: worker, previousStatus: worker.Status()})
+			worker.apply(ctx, up, &SyncWork{State: test.state}, 1, &statusWrapper{w: worker, previousStatus: worker.Status()})
 			test.check(t, r.actions)
 		})
 	}
 }
 
+func Test_SyncWorker_syncSecurityCriticalDuringReconcile(t *testing.T) {
+	var manifests []manifest.Manifest
+	for _, s := range []string{
+		`{"apiVersion": "test.cvo.io/v1", "kind": "TestA", "metadata": {"namespace": "default", "name": "testa"}}`,
+		`{"apiVersion": "test.cvo.io/v1", "kind": "TestB", "metadata": {"namespace": "default", "name": "testb", "annotations": {"release.openshift.io/security-critical": "true"}}}`,
+	} {
+		m := manifest.Manifest{}
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			t.Fatal(err)
+		}
+		manifests = append(manifests, m)
+	}
+	up := &payload.Update{
+		Release:   configv1.Release{Version: "v0.0.0", Image: "test"},
+		Manifests: manifests,
+	}
+
+	builder := &concurrencyBuilder{applying: map[string]int{}}
+	worker := &SyncWorker{eventRecorder: record.NewFakeRecorder(1000), builder: builder}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			worker.syncSecurityCritical(ctx)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if err := worker.apply(ctx, up, &SyncWork{State: payload.ReconcilingPayload}, 1, &statusWrapper{w: worker, previousStatus: worker.Status()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cancel()
+	<-done
+	if builder.concurrent {
+		t.Fatal("security-critical manifests were applied concurrently")
+	}
+}
+
+// concurrencyBuilder records whether a manifest was applied while it was already being applied.
+type concurrencyBuilder struct {
+	lock       sync.Mutex
+	applying   map[string]int
+	concurrent bool
+}
+
+func (b *concurrencyBuilder) Apply(ctx context.Context, m *manifest.Manifest, state payload.State) error {
+	name := m.Obj.GetName()
+	b.lock.Lock()
+	b.applying[name]++
+	if b.applying[name] > 1 {
+		b.concurrent = true
+	}
+	b.lock.Unlock()
+	time.Sleep(time.Millisecond)
+	b.lock.Lock()
+	b.applying[name]--
+	b.lock.Unlock()
+	return nil
+}
+
 type cancelAfterErrorBuilder struct {
 	builder         payload.ResourceBuilder
 	cancel          func()
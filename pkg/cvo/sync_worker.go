@@ -2,9 +2,11 @@ package cvo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"reflect"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -15,7 +17,7 @@ import (
 	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
@@ -24,6 +26,7 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 
 	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
+	"github.com/openshift/cluster-version-operator/pkg/internal"
 	"github.com/openshift/cluster-version-operator/pkg/payload"
 	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
 	"github.com/openshift/library-go/pkg/manifest"
@@ -58,7 +61,8 @@ type PayloadRetriever interface {
 	RetrievePayload(ctx context.Context, desired configv1.Update) (PayloadInfo, error)
 }
 
-// StatusReporter abstracts how status is reported by the worker run method. Introduced for testing.
+// StatusReporter abstracts how status is reported by the worker run method. Introduced for testing,
+// it is also implemented by the additional status sinks registered on the SyncWorker.
 type StatusReporter interface {
 	Report(status SyncWorkerStatus)
 }
@@ -101,6 +105,31 @@ type SyncWorkerStatus struct {
 
 	Actual   configv1.Release
 	Verified bool
+
+	// Deferred lists the tasks that were not reconciled because of an active hold-back window.
+	Deferred []string
+
+	// Unsupported describes known unsupported configuration detected while applying the payload.
+	Unsupported []string
+
+	// PreconditionWarnings describes the precondition failures for the payload that have
+	// warning severity, which do not block the update.
+	PreconditionWarnings []string
+
+	// Preconditions are the results of the precondition checks last run for the payload, if
+	// they were run.
+	Preconditions precondition.Results
+
+	// RunLevels reports update progress through the run levels with a budget in the release
+	// metadata. It is empty unless an update is being applied.
+	RunLevels []RunLevelProgress
+
+	// RollingBack is true while the release is being applied to roll back a failed update.
+	RollingBack bool
+
+	// Disabled describes the manifests of the release that are not applied because they
+	// belong to a disabled capability.
+	Disabled []string
 }
 
 // DeepCopy copies the worker status.
@@ -139,18 +168,74 @@ type SyncWorker struct {
 	// used to define the maximum backoff interval when syncOnce() returns an error.
 	minimumReconcileInterval time.Duration
 
+	// securityCriticalInterval is how often security-critical manifests are reapplied
+	// while reconciling. If zero, they are only applied as part of the reconcile loop.
+	securityCriticalInterval time.Duration
+	// securityCriticalLock is held while security-critical manifests are applied, so that
+	// their periodic reapply does not race a reconcile pass applying the same manifests.
+	securityCriticalLock sync.Mutex
+
 	// coordination between the sync loop and external callers
 	notify chan struct{}
 	report chan SyncWorkerStatus
 
+	// reporters receive every status published to report, in the same order.
+	// They are invoked with lock held and must not block.
+	reporters []StatusReporter
+
+	// holdBack, if set, returns the windows during which components must not be reconciled.
+	holdBack func() []holdBackWindow
+
+	// audit, if set, is called with how each loaded release was accepted or rejected.
+	audit func(UpdateAudit)
+
+	// watchdog, if set, is told of the sync loop's progress so that stalls can be detected.
+	watchdog *syncWatchdog
+
 	// lock guards changes to these fields
 	lock     sync.Mutex
 	work     *SyncWork
 	cancelFn func()
 	status   SyncWorkerStatus
 
+	// securityCritical is the set of security-critical manifests from the payload being
+	// reconciled, or empty if the worker is not reconciling.
+	securityCritical []*payload.Task
+	// reconciling is the set of managed manifests from the payload being reconciled, or
+	// empty if the worker is not reconciling.
+	reconciling []*payload.Task
+	// resyncing is set while a component resync is running.
+	resyncing bool
+
 	// updated by the run method only
 	payload *payload.Update
+	// preconditionWarnings describes the warning-level precondition failures for the payload.
+	preconditionWarnings []string
+	// preconditionResults are the results of the precondition checks last run, for the
+	// payload image preconditionImage.
+	preconditionResults precondition.Results
+	preconditionImage   string
+
+	// parallelOperatorWaits, if set, makes updates wait for the ClusterOperators of each run
+	// level in parallel instead of holding a sync worker for each wait.
+	parallelOperatorWaits bool
+
+	// timings, if set, measures how long each ClusterOperator takes to update.
+	timings *operatorTimings
+
+	// checkpoint, if set, records the manifests each update applies, and skips those that an
+	// earlier operator applied on the first attempt after a restart.
+	checkpoint *updateCheckpoint
+
+	// progress, if set, records structured progress events.
+	progress *progressFeed
+
+	// preflight, if set, submits the manifests of a release with server-side dry-run before
+	// an update to it begins.
+	preflight bool
+
+	// runLevels tracks update progress against the run level budgets of the payload.
+	runLevels runLevelTracker
 
 	// exclude is an identifier used to determine which
 	// manifests should be excluded based on an annotation
@@ -158,6 +243,9 @@ type SyncWorker struct {
 	exclude string
 
 	clusterProfile string
+
+	// disabledCapabilities are capabilities whose manifests are not applied.
+	disabledCapabilities []string
 }
 
 // NewSyncWorker initializes a ConfigSyncWorker that will retrieve payloads to disk, apply them via builder
@@ -170,6 +258,7 @@ func NewSyncWorker(retriever PayloadRetriever, builder payload.ResourceBuilder,
 		eventRecorder: eventRecorder,
 
 		minimumReconcileInterval: reconcileInterval,
+		securityCriticalInterval: 10 * time.Second,
 
 		notify: make(chan struct{}, 1),
 		// report is a large buffered channel to improve local testing - most consumers should invoke
@@ -192,6 +281,18 @@ func NewSyncWorkerWithPreconditions(retriever PayloadRetriever, builder payload.
 	return worker
 }
 
+// recordAudit reports audit for the release that work loaded. The operator's own release and
+// releases reloaded while reconciling, as after a restart, are not reported, because they
+// say nothing about how the update was accepted and would replace the audit that does.
+func (w *SyncWorker) recordAudit(work *SyncWork, info PayloadInfo, audit UpdateAudit) {
+	if info.Local || work.State.Reconciling() {
+		return
+	}
+	if w.audit != nil {
+		w.audit(audit)
+	}
+}
+
 // StatusCh returns a channel that reports status from the worker. The channel is buffered and events
 // can be lost, so this is best used as a trigger to read the latest status.
 func (w *SyncWorker) StatusCh() <-chan SyncWorkerStatus {
@@ -242,6 +343,11 @@ func (w *SyncWorker) Update(generation int64, desired configv1.Update, overrides
 		}
 	}
 
+	// a rollback is applied as a rollback, not as an update to the release rolled back to
+	if state == payload.RollingBackPayload {
+		work.State = state
+	}
+
 	// notify the sync loop that we changed config
 	w.work = work
 	if w.cancelFn != nil {
@@ -249,6 +355,7 @@ func (w *SyncWorker) Update(generation int64, desired configv1.Update, overrides
 		w.cancelFn()
 		w.cancelFn = nil
 	}
+	w.watchdog.workNotified(time.Now())
 	select {
 	case w.notify <- struct{}{}:
 		klog.V(5).Info("Notify the sync worker that new work is available")
@@ -267,6 +374,10 @@ func (w *SyncWorker) Start(ctx context.Context, maxWorkers int, cvoOptrName stri
 
 	work := &SyncWork{}
 
+	if w.securityCriticalInterval > 0 {
+		go wait.Until(func() { w.syncSecurityCritical(ctx) }, w.securityCriticalInterval, ctx.Done())
+	}
+
 	wait.Until(func() {
 		consecutiveErrors := 0
 		errorInterval := w.minimumReconcileInterval / 16
@@ -309,7 +420,7 @@ func (w *SyncWorker) Start(ctx context.Context, maxWorkers int, cvoOptrName stri
 					// created, so time out syncs more often to show a snapshot of progress
 					// TODO: allow status outside of sync
 					syncTimeout = w.minimumReconcileInterval
-				case payload.UpdatingPayload:
+				case payload.UpdatingPayload, payload.RollingBackPayload:
 					// during updates we want to flag failures on any resources that -
 					// for cluster operators that are not reporting failing the error
 					// message will point users to which operator is upgrading
@@ -326,6 +437,8 @@ func (w *SyncWorker) Start(ctx context.Context, maxWorkers int, cvoOptrName stri
 				w.cancelFn = cancelFn
 				w.lock.Unlock()
 				defer cancelFn()
+				w.watchdog.syncStarted(time.Now(), cancelFn)
+				defer func() { w.watchdog.syncFinished(time.Now()) }()
 
 				config, err := lister.Get(cvoOptrName)
 				if err != nil {
@@ -335,7 +448,10 @@ func (w *SyncWorker) Start(ctx context.Context, maxWorkers int, cvoOptrName stri
 				// so that we don't fail, then immediately start reporting an earlier status
 				reporter := &statusWrapper{w: w, previousStatus: w.Status()}
 				klog.V(5).Infof("Previous sync status: %#v", reporter.previousStatus)
-				return w.syncOnce(ctx, work, maxWorkers, reporter, config)
+				start := time.Now()
+				err = w.syncOnce(ctx, work, maxWorkers, reporter, config)
+				internal.ObserveReconcile("syncworker", start, err)
+				return err
 			}()
 			if err != nil {
 				// backoff wait
@@ -366,6 +482,119 @@ func (w *SyncWorker) Start(ctx context.Context, maxWorkers int, cvoOptrName stri
 	klog.V(5).Infof("Worker shut down")
 }
 
+// securityCriticalApplyTimeout bounds each application of a security-critical manifest, which
+// is made while securityCriticalLock is held.
+const securityCriticalApplyTimeout = 2 * time.Minute
+
+// syncSecurityCritical applies the security-critical manifests recorded by the most
+// recent reconcile so that drift in them is corrected between reconcile passes. Each
+// manifest is applied once; failures are retried on the next interval. It does not run
+// while a reconcile pass is applying the same manifests.
+func (w *SyncWorker) syncSecurityCritical(ctx context.Context) {
+	w.securityCriticalLock.Lock()
+	defer w.securityCriticalLock.Unlock()
+	w.lock.Lock()
+	tasks := w.securityCritical
+	w.lock.Unlock()
+
+	var holdBack []holdBackWindow
+	if len(tasks) > 0 && w.holdBack != nil {
+		holdBack = w.holdBack()
+	}
+	now := time.Now()
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			return
+		}
+		if heldBack(holdBack, task, now) != nil {
+			continue
+		}
+		applyCtx, cancel := context.WithTimeout(ctx, securityCriticalApplyTimeout)
+		err := w.builder.Apply(applyCtx, task.Manifest, payload.ReconcilingPayload)
+		cancel()
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("unable to reconcile security-critical resource %s: %v", task, err))
+			continue
+		}
+		klog.V(4).Infof("Reconciled security-critical resource %s", task)
+	}
+}
+
+// componentResyncTimeout bounds how long a component resync may take.
+const componentResyncTimeout = 10 * time.Minute
+
+// ResyncComponents starts applying the managed manifests of the payload being reconciled
+// whose component or namespace is one of components, without waiting for the next reconcile
+// pass. It returns the manifests it will apply, and fails if the worker is not reconciling, if
+// no manifest matches, or if an earlier resync is still running. The manifests are applied in
+// the background in payload order, for at most componentResyncTimeout, skipping
+// ClusterOperators, which are only waited on, and manifests held back by an active hold-back
+// window. Done is then called with the manifests that were applied and any failures.
+func (w *SyncWorker) ResyncComponents(ctx context.Context, components []string, done func(applied []*payload.Task, err error)) ([]*payload.Task, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.reconciling) == 0 {
+		return nil, fmt.Errorf("components can only be resynchronized while reconciling")
+	}
+	if w.resyncing {
+		return nil, fmt.Errorf("an earlier resync is still running")
+	}
+
+	var holdBack []holdBackWindow
+	if w.holdBack != nil {
+		holdBack = w.holdBack()
+	}
+	now := time.Now()
+	var matched []*payload.Task
+	for _, task := range w.reconciling {
+		if !taskMatchesComponent(task, components) || isClusterOperatorTask(task) {
+			continue
+		}
+		if window := heldBack(holdBack, task, now); window != nil {
+			klog.V(2).Infof("Not resynchronizing %s during hold-back window %s-%s", task, window.Start, window.End)
+			continue
+		}
+		matched = append(matched, task)
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no managed manifests match %s", strings.Join(components, ", "))
+	}
+	w.resyncing = true
+
+	go func() {
+		defer utilruntime.HandleCrash()
+		ctx, cancel := context.WithTimeout(ctx, componentResyncTimeout)
+		defer cancel()
+
+		graph := payload.NewTaskGraph(matched)
+		graph.Split(payload.SplitOnJobs)
+		graph.Parallelize(payload.ByNumberAndComponent)
+		var lock sync.Mutex
+		var applied []*payload.Task
+		errs := payload.RunGraph(ctx, graph, 2, func(ctx context.Context, tasks []*payload.Task) error {
+			for _, task := range tasks {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := w.builder.Apply(ctx, task.Manifest, payload.ReconcilingPayload); err != nil {
+					return fmt.Errorf("%s: %v", task, err)
+				}
+				klog.V(4).Infof("Resynchronized resource %s", task)
+				lock.Lock()
+				applied = append(applied, task)
+				lock.Unlock()
+			}
+			return nil
+		})
+
+		w.lock.Lock()
+		w.resyncing = false
+		w.lock.Unlock()
+		done(applied, utilerrors.NewAggregate(errs))
+	}()
+	return matched, nil
+}
+
 // statusWrapper prevents a newer status update from overwriting a previous
 // failure from later in the sync process.
 type statusWrapper struct {
@@ -418,6 +647,9 @@ func (w *SyncWorker) calculateNext(work *SyncWork) bool {
 		work.State = w.work.State
 	} else if changed {
 		work.State = payload.UpdatingPayload
+		if w.work.State == payload.RollingBackPayload {
+			work.State = payload.RollingBackPayload
+		}
 	}
 	// always clear the completed variable if we are not reconciling
 	if work.State != payload.ReconcilingPayload {
@@ -505,13 +737,14 @@ func equalSyncWork(a, b *SyncWork, state payload.State) bool {
 
 // updateStatus records the current status of the sync action for observation
 // by others. It sends a copy of the update to the report channel for improved
-// testability.
+// testability, and to any additional reporters.
 func (w *SyncWorker) updateStatus(update SyncWorkerStatus) {
 	w.lock.Lock()
 	defer w.lock.Unlock()
 
 	klog.V(6).Infof("Status change %#v", update)
 	w.status = update
+	w.watchdog.progressed(time.Now())
 	select {
 	case w.report <- update:
 	default:
@@ -519,6 +752,9 @@ func (w *SyncWorker) updateStatus(update SyncWorkerStatus) {
 			klog.Infof("Status report channel was full %#v", update)
 		}
 	}
+	for _, reporter := range w.reporters {
+		reporter.Report(update)
+	}
 }
 
 // Desired returns the state the SyncWorker is trying to achieve.
@@ -555,7 +791,20 @@ func (w *SyncWorker) syncOnce(ctx context.Context, work *SyncWork, maxWorkers in
 	// cache the payload until the release image changes
 	validPayload := w.payload
 	if validPayload != nil && validPayload.Release.Image == desired.Image {
-		// possibly complain here if Version, etc. diverges from the payload content
+		// the payload was verified when it was loaded, so only confirm that the
+		// requested version name matches its metadata
+		if err := checkDesiredVersion(work.Desired, validPayload.Release); err != nil {
+			reporter.Report(SyncWorkerStatus{
+				Generation:  work.Generation,
+				Failure:     err,
+				Step:        "VerifyPayloadVersion",
+				Initial:     work.State.Initializing(),
+				Reconciling: work.State.Reconciling(),
+				Actual:      validPayload.Release,
+				Verified:    validPayload.VerifiedImage,
+			})
+			return err
+		}
 		desired = validPayload.Release
 	} else if validPayload == nil || !equalUpdate(configv1.Update{Image: validPayload.Release.Image}, configv1.Update{Image: desired.Image}) {
 		klog.V(4).Infof("Loading payload")
@@ -583,7 +832,7 @@ func (w *SyncWorker) syncOnce(ctx context.Context, work *SyncWork, maxWorkers in
 		}
 
 		w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeNormal, "VerifyPayload", "verifying payload version=%q image=%q", desired.Version, desired.Image)
-		payloadUpdate, err := payload.LoadUpdate(info.Directory, desired.Image, w.exclude, w.clusterProfile)
+		payloadUpdate, err := payload.LoadUpdate(info.Directory, desired.Image, w.exclude, w.clusterProfile, w.disabledCapabilities)
 		if err != nil {
 			w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeWarning, "VerifyPayloadFailed", "verifying payload failed version=%q image=%q failure=%v", desired.Version, desired.Image, err)
 			reporter.Report(SyncWorkerStatus{
@@ -601,11 +850,13 @@ func (w *SyncWorker) syncOnce(ctx context.Context, work *SyncWork, maxWorkers in
 		payloadUpdate.VerifiedImage = info.Verified
 		payloadUpdate.LoadedAt = time.Now()
 
+		// digest-only targets take their version name from the verified release metadata,
+		// and a requested version name must match that metadata
 		if work.Desired.Version == "" {
 			work.Desired.Version = payloadUpdate.Release.Version
 			desired.Version = payloadUpdate.Release.Version
-		} else if payloadUpdate.Release.Version != work.Desired.Version {
-			err = fmt.Errorf("release image version %s does not match the expected upstream version %s", payloadUpdate.Release.Version, work.Desired.Version)
+			w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeNormal, "PayloadVersionResolved", "resolved version=%q from the metadata of image=%q verified=%t", desired.Version, desired.Image, info.Verified)
+		} else if err := checkDesiredVersion(work.Desired, payloadUpdate.Release); err != nil {
 			w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeWarning, "VerifyPayloadVersionFailed", "verifying payload failed version=%q image=%q failure=%v", work.Desired.Version, work.Desired.Image, err)
 			reporter.Report(SyncWorkerStatus{
 				Generation:  work.Generation,
@@ -619,11 +870,24 @@ func (w *SyncWorker) syncOnce(ctx context.Context, work *SyncWork, maxWorkers in
 			return err
 		}
 
+		audit := UpdateAudit{Version: desired.Version, Image: desired.Image, Verification: verificationMethod(info), Force: work.Desired.Force}
+		var preconditionWarnings []string
+
 		// need to make sure the payload is only set when the preconditions have been successful
+		var previousPreconditions precondition.Results
+		if w.preconditionImage == desired.Image {
+			previousPreconditions = w.preconditionResults
+		}
+		w.preconditionResults, w.preconditionImage = nil, ""
 		if len(w.preconditions) == 0 {
 			klog.V(4).Info("No preconditions configured.")
+			audit.Preconditions.Skipped = true
 		} else if info.Local {
 			klog.V(4).Info("Skipping preconditions for a local operator image payload.")
+			audit.Preconditions.Skipped = true
+		} else if work.State == payload.RollingBackPayload {
+			klog.V(4).Info("Skipping preconditions for a rollback to an earlier payload.")
+			audit.Preconditions.Skipped = true
 		} else {
 			reporter.Report(SyncWorkerStatus{
 				Generation:  work.Generation,
@@ -633,8 +897,31 @@ func (w *SyncWorker) syncOnce(ctx context.Context, work *SyncWork, maxWorkers in
 				Actual:      desired,
 				Verified:    info.Verified,
 			})
-			if err := precondition.Summarize(w.preconditions.RunAll(ctx, precondition.ReleaseContext{DesiredVersion: payloadUpdate.Release.Version}, clusterVersion)); err != nil {
-				if work.Desired.Force {
+			results := w.preconditions.RunAll(ctx, precondition.ReleaseContext{DesiredVersion: payloadUpdate.Release.Version, DesiredImage: desired.Image}, clusterVersion)
+			results.CarrySince(previousPreconditions)
+			w.preconditionResults, w.preconditionImage = results, desired.Image
+			w.progress.preconditions(desired, results)
+			errs, waivers := precondition.SplitWaivers(results.Errors())
+			errs, advisories := precondition.SplitSeverity(errs)
+			audit.Preconditions.Passed = w.preconditions.Passed(results.Errors())
+			audit.Preconditions.Waived = len(waivers)
+			audit.Preconditions.Advisory = len(advisories)
+			for _, advisory := range advisories {
+				message := fmt.Sprintf("Precondition %q failed because of %q: %v", advisory.Name, advisory.Reason, advisory.Error())
+				eventType := corev1.EventTypeNormal
+				if advisory.Severity == precondition.SeverityWarning {
+					eventType = corev1.EventTypeWarning
+					preconditionWarnings = append(preconditionWarnings, message)
+				}
+				w.eventRecorder.Eventf(cvoObjectRef, eventType, "PreconditionAdvisory", "precondition %s with severity %s failed for payload loaded version=%q image=%q: %v", advisory.Name, advisory.Severity, desired.Version, desired.Image, advisory)
+			}
+			for _, waiver := range waivers {
+				audit.Waivers = append(audit.Waivers, newPreconditionWaiver(waiver))
+				w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeWarning, "PreconditionWaived", "precondition %s waived for payload loaded version=%q image=%q by %s until %s, bypassing %s", waiver.Name, desired.Version, desired.Image, waiver.RequestedBy, waiver.Expires.UTC().Format(time.RFC3339), strings.Join(waiver.Bypassed, ", "))
+			}
+			if err := precondition.Summarize(errs); err != nil {
+				if work.Desired.Force && precondition.Forceable(errs) {
+					audit.Preconditions.Overridden = len(errs)
 					klog.V(4).Infof("Forcing past precondition failures: %s", err)
 					w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeWarning, "PreconditionsForced", "preconditions forced for payload loaded version=%q image=%q failures=%v", desired.Version, desired.Image, err)
 				} else {
@@ -647,18 +934,61 @@ func (w *SyncWorker) syncOnce(ctx context.Context, work *SyncWork, maxWorkers in
 						Reconciling: work.State.Reconciling(),
 						Actual:      desired,
 						Verified:    info.Verified,
+
+						Preconditions: results,
 					})
+					audit.Preconditions.Failed = len(errs)
+					w.recordAudit(work, info, audit)
 					return err
 				}
 			}
 			w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeNormal, "PreconditionsPassed", "preconditions passed for payload loaded version=%q image=%q", desired.Version, desired.Image)
 		}
 
+		// submit the release with server-side dry-run before any of it is applied
+		if w.preflight && work.State == payload.UpdatingPayload {
+			reporter.Report(SyncWorkerStatus{
+				Generation:  work.Generation,
+				Step:        "PreflightChecks",
+				Initial:     work.State.Initializing(),
+				Reconciling: work.State.Reconciling(),
+				Actual:      desired,
+				Verified:    info.Verified,
+			})
+			if err := w.runPreflight(ctx, payloadUpdate, work.Overrides); err != nil {
+				if work.Desired.Force {
+					audit.Preflight = "Overridden"
+					klog.V(4).Infof("Forcing past preflight failures: %s", err)
+					w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeWarning, "PreflightForced", "preflight dry-run forced for payload loaded version=%q image=%q failures=%v", desired.Version, desired.Image, err)
+				} else {
+					w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeWarning, "PreflightFailed", "preflight dry-run failed for payload loaded version=%q image=%q failures=%v", desired.Version, desired.Image, err)
+					reporter.Report(SyncWorkerStatus{
+						Generation:  work.Generation,
+						Failure:     err,
+						Step:        "PreflightChecks",
+						Initial:     work.State.Initializing(),
+						Reconciling: work.State.Reconciling(),
+						Actual:      desired,
+						Verified:    info.Verified,
+					})
+					audit.Preflight = "Failed"
+					w.recordAudit(work, info, audit)
+					return err
+				}
+			} else {
+				audit.Preflight = "Passed"
+				w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeNormal, "PreflightPassed", "preflight dry-run passed for payload loaded version=%q image=%q", desired.Version, desired.Image)
+			}
+		}
+
+		w.recordAudit(work, info, audit)
 		w.payload = payloadUpdate
+		w.preconditionWarnings = preconditionWarnings
 		w.eventRecorder.Eventf(cvoObjectRef, corev1.EventTypeNormal, "PayloadLoaded", "payload loaded version=%q image=%q", desired.Version, desired.Image)
 		klog.V(4).Infof("Payload loaded from %s with hash %s", desired.Image, payloadUpdate.ManifestHash)
 	}
 
+	setReleaseInfoMetric(w.payload.Release, clusterVersion)
 	return w.apply(ctx, w.payload, work, maxWorkers, reporter)
 }
 
@@ -676,6 +1006,12 @@ func (w *SyncWorker) apply(ctx context.Context, payloadUpdate *payload.Update, w
 			VersionHash: payloadUpdate.ManifestHash,
 			Actual:      payloadUpdate.Release,
 			Verified:    payloadUpdate.VerifiedImage,
+			Unsupported: unsupportedConfiguration(work, payloadUpdate),
+			RollingBack: work.State == payload.RollingBackPayload,
+			Disabled:    disabledManifests(payloadUpdate),
+
+			PreconditionWarnings: w.preconditionWarnings,
+			Preconditions:        w.preconditionResults,
 		},
 		completed: work.Completed,
 		version:   payloadUpdate.Release.Version,
@@ -696,6 +1032,68 @@ func (w *SyncWorker) apply(ctx context.Context, payloadUpdate *payload.Update, w
 			Backoff:  backoff,
 		})
 	}
+	// during reconcile, security-critical manifests are applied ahead of the rest of
+	// the payload and are remembered so they can be reapplied between passes
+	var critical []*payload.Task
+	var criticalManaged []*payload.Task
+	var managed []*payload.Task
+	if work.State == payload.ReconcilingPayload {
+		var rest []*payload.Task
+		for _, task := range tasks {
+			ov, ok := getOverrideForManifest(work.Overrides, task.Manifest)
+			if !ok || !ov.Unmanaged {
+				managed = append(managed, task)
+			}
+			if !payload.IsSecurityCritical(task.Manifest) {
+				rest = append(rest, task)
+				continue
+			}
+			critical = append(critical, task)
+			if !ok || !ov.Unmanaged {
+				criticalManaged = append(criticalManaged, task)
+			}
+		}
+		tasks = rest
+	}
+	w.lock.Lock()
+	w.securityCritical = criticalManaged
+	w.reconciling = managed
+	w.lock.Unlock()
+
+	// rollbacks return the run levels an update applied last to their earlier versions first
+	if work.State == payload.RollingBackPayload {
+		tasks = payload.ReverseRunLevels(tasks)
+	}
+
+	// updates measure how long each run level with a budget takes
+	if work.State == payload.UpdatingPayload && len(payloadUpdate.RunLevelBudgets) > 0 {
+		w.runLevels.reset(payloadUpdate.Release.Image, payloadUpdate.RunLevelBudgets, tasks, func(task *payload.Task) bool {
+			ov, ok := getOverrideForManifest(work.Overrides, task.Manifest)
+			return !ok || !ov.Unmanaged
+		})
+		cr.runLevels = &w.runLevels
+		cr.status.RunLevels = w.runLevels.snapshot()
+	}
+
+	// updates record the manifests they apply, and resume where an earlier operator left off
+	var checkpoint *updateCheckpoint
+	var resumed map[string]string
+	if work.State == payload.UpdatingPayload && w.checkpoint != nil {
+		checkpoint = w.checkpoint
+		levels := map[string][]string{}
+		for _, task := range tasks {
+			if ov, ok := getOverrideForManifest(work.Overrides, task.Manifest); !ok || !ov.Unmanaged {
+				level := payload.TaskRunLevel(task)
+				levels[level] = append(levels[level], checkpointKey(task))
+			}
+		}
+		var waiting map[string]time.Time
+		resumed, waiting = checkpoint.begin(payloadUpdate.Release.Image, levels)
+		for name, since := range waiting {
+			w.timings.startedOperator(work.Desired.Image, name, since)
+		}
+	}
+
 	graph := payload.NewTaskGraph(tasks)
 	graph.Split(payload.SplitOnJobs)
 	var precreateObjects bool
@@ -752,30 +1150,128 @@ func (w *SyncWorker) apply(ctx context.Context, payloadUpdate *payload.Update, w
 		})
 	}
 
-	// update each object
-	errs := payload.RunGraph(ctx, graph, maxWorkers, func(ctx context.Context, tasks []*payload.Task) error {
-		for _, task := range tasks {
-			if err := ctx.Err(); err != nil {
-				return cr.ContextError(err)
-			}
-			cr.Update()
+	// components held back by an administrator are skipped while reconciling
+	var holdBack []holdBackWindow
+	if work.State == payload.ReconcilingPayload && w.holdBack != nil {
+		holdBack = w.holdBack()
+	}
+	now := time.Now()
 
-			klog.V(4).Infof("Running sync for %s", task)
+	// syncTask applies task with apply unless it is unmanaged, held back, or was applied before
+	// the operator restarted, and records its progress. ClusterOperators are waited for in
+	// parallel if waits is set.
+	var waits *operatorWaits
+	syncTask := func(ctx context.Context, task *payload.Task, apply func(context.Context, *payload.Task) error) error {
+		cr.StartRunLevel(task)
+		cr.Update()
 
-			ov, ok := getOverrideForManifest(work.Overrides, task.Manifest)
-			if ok && ov.Unmanaged {
-				klog.V(4).Infof("Skipping %s as unmanaged", task)
-				continue
-			}
+		klog.V(4).Infof("Running sync for %s", task)
 
-			if err := task.Run(ctx, payloadUpdate.Release.Version, w.builder, work.State); err != nil {
-				return err
+		ov, ok := getOverrideForManifest(work.Overrides, task.Manifest)
+		if ok && ov.Unmanaged {
+			klog.V(4).Infof("Skipping %s as unmanaged", task)
+			return nil
+		}
+		if window := heldBack(holdBack, task, now); window != nil {
+			klog.V(2).Infof("Deferring %s during hold-back window %s-%s for %s", task, window.Start, window.End, strings.Join(window.Components, ", "))
+			cr.Defer(task)
+			return nil
+		}
+
+		if hash, ok := resumed[checkpointKey(task)]; ok && hash == checkpointHash(task) {
+			klog.V(2).Infof("Skipping %s, which was applied before the operator restarted", task)
+			checkpoint.succeeded(payloadUpdate.Release.Image, task)
+			cr.Inc()
+			cr.FinishRunLevel(task)
+			return nil
+		}
+
+		timed := work.State == payload.UpdatingPayload && isClusterOperatorTask(task)
+		if timed {
+			w.timings.startedOperator(work.Desired.Image, task.Manifest.Obj.GetName(), time.Now())
+		}
+
+		run := func(ctx context.Context) error {
+			started := time.Now()
+			w.progress.taskStarted(payloadUpdate.Release, work.State, task)
+			err := apply(ctx, task)
+			w.progress.taskFinished(payloadUpdate.Release, work.State, task, started, err)
+			return err
+		}
+		done := func() {
+			if timed {
+				w.timings.completedOperator(task.Manifest.Obj.GetName(), time.Now())
 			}
+			checkpoint.succeeded(payloadUpdate.Release.Image, task)
 			cr.Inc()
+			cr.FinishRunLevel(task)
 			klog.V(4).Infof("Done syncing for %s", task)
 		}
+
+		if waits != nil && isClusterOperatorTask(task) {
+			waits.start(ctx, task, run, done)
+			return nil
+		}
+		if err := run(ctx); err != nil {
+			return err
+		}
+		done()
 		return nil
-	})
+	}
+
+	// update each object, retrying until it is applied or the context is cancelled
+	runTasks := func(ctx context.Context, tasks []*payload.Task) error {
+		for _, task := range tasks {
+			if err := ctx.Err(); err != nil {
+				return cr.ContextError(err)
+			}
+			if err := syncTask(ctx, task, func(ctx context.Context, task *payload.Task) error {
+				return task.Run(ctx, payloadUpdate.Release.Version, w.builder, work.State)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// security-critical manifests are applied once each before the rest of the payload starts,
+	// without the periodic reapply. Failures are reported with the rest of the payload's rather
+	// than retried, so that one failing manifest does not hold up the reconcile.
+	var errs []error
+	if len(critical) > 0 {
+		applyOnce := func(ctx context.Context, task *payload.Task) error {
+			ctx, cancel := context.WithTimeout(ctx, securityCriticalApplyTimeout)
+			defer cancel()
+			return task.RunOnce(ctx, payloadUpdate.Release.Version, w.builder, work.State)
+		}
+		w.securityCriticalLock.Lock()
+		for _, task := range critical {
+			if ctx.Err() != nil {
+				break
+			}
+			if err := syncTask(ctx, task, applyOnce); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		w.securityCriticalLock.Unlock()
+	}
+	if w.parallelOperatorWaits && work.State == payload.UpdatingPayload {
+		// run each run level in turn, initiating all of its components before waiting for
+		// its ClusterOperators
+		for _, levelTasks := range splitByRunLevel(tasks) {
+			levelGraph := payload.NewTaskGraph(levelTasks)
+			levelGraph.Split(payload.SplitOnJobs)
+			levelGraph.Parallelize(payload.ByNumberAndComponent)
+			waits = &operatorWaits{}
+			errs = append(errs, payload.RunGraph(ctx, levelGraph, maxWorkers, runTasks)...)
+			errs = append(errs, waits.wait()...)
+			if len(errs) > 0 {
+				break
+			}
+		}
+	} else {
+		errs = append(errs, payload.RunGraph(ctx, graph, maxWorkers, runTasks)...)
+	}
 	if len(errs) > 0 {
 		if err := cr.Errors(errs); err != nil {
 			return err
@@ -793,14 +1289,30 @@ var (
 		Name: "cluster_version_payload",
 		Help: "Report the number of entries in the payload.",
 	}, []string{"version", "type"})
+	metricReleaseInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cluster_version_release_info",
+		Help: "Reports the identity of the release payload most recently accepted by the operator. The value is always 1.",
+	}, []string{"version", "image", "digest", "architecture", "channel"})
 )
 
 func init() {
 	prometheus.MustRegister(
 		metricPayload,
+		metricReleaseInfo,
 	)
 }
 
+// setReleaseInfoMetric replaces the release info series with one describing release and
+// the channel the cluster is subscribed to.
+func setReleaseInfoMetric(release configv1.Release, clusterVersion *configv1.ClusterVersion) {
+	var channel string
+	if clusterVersion != nil {
+		channel = clusterVersion.Spec.Channel
+	}
+	metricReleaseInfo.Reset()
+	metricReleaseInfo.WithLabelValues(release.Version, release.Image, splitDigest(release.Image), runtime.GOARCH, channel).Set(1)
+}
+
 type errContext struct {
 	err error
 }
@@ -816,6 +1328,8 @@ type consistentReporter struct {
 	completed int
 	total     int
 	done      int
+	deferred  []string
+	runLevels *runLevelTracker
 	reporter  StatusReporter
 }
 
@@ -825,6 +1339,36 @@ func (r *consistentReporter) Inc() {
 	r.done++
 }
 
+// Defer records a task that was not reconciled because of a hold-back window.
+func (r *consistentReporter) Defer(task *payload.Task) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.deferred = append(r.deferred, task.String())
+	r.status.Deferred = append([]string(nil), r.deferred...)
+}
+
+// StartRunLevel records that task is about to be synced, for update run level budgets.
+func (r *consistentReporter) StartRunLevel(task *payload.Task) {
+	if r.runLevels == nil {
+		return
+	}
+	r.runLevels.start(task, time.Now())
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.status.RunLevels = r.runLevels.snapshot()
+}
+
+// FinishRunLevel records that task was applied, for update run level budgets.
+func (r *consistentReporter) FinishRunLevel(task *payload.Task) {
+	if r.runLevels == nil {
+		return
+	}
+	r.runLevels.finish(task)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.status.RunLevels = r.runLevels.snapshot()
+}
+
 func (r *consistentReporter) Update() {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -890,19 +1434,20 @@ func isContextError(err error) bool {
 	if err == nil {
 		return false
 	}
-	_, ok := err.(errContext)
-	return ok
+	var cErr errContext
+	return errors.As(err, &cErr)
 }
 
 func isImageVerificationError(err error) bool {
-	if err == nil {
-		return false
-	}
-	updateErr, ok := err.(*payload.UpdateError)
-	if !ok {
-		return false
-	}
-	return updateErr.Reason == "ImageVerificationFailed"
+	return updateErrorHasReason(err, payload.ErrImageVerificationFailed)
+}
+
+// updateErrorHasReason returns true if the outermost UpdateError in the chain of
+// err has the same reason as the provided sentinel. Nested update errors (for
+// instance those aggregated into a MultipleErrors error) are not considered.
+func updateErrorHasReason(err error, sentinel *payload.UpdateError) bool {
+	var uErr *payload.UpdateError
+	return errors.As(err, &uErr) && uErr.Is(sentinel)
 }
 
 // summarizeTaskGraphErrors takes a set of errors returned by the execution of a graph and attempts
@@ -914,12 +1459,12 @@ func summarizeTaskGraphErrors(errs []error) error {
 	// we ignore context errors (canceled or timed out) since they don't
 	// provide good feedback to users and are an internal detail of the
 	// server
-	err := errors.FilterOut(errors.NewAggregate(errs), isContextError)
+	err := utilerrors.FilterOut(utilerrors.NewAggregate(errs), isContextError)
 	if err == nil {
 		klog.V(4).Infof("All errors were context errors: %v", errs)
 		return nil
 	}
-	agg, ok := err.(errors.Aggregate)
+	agg, ok := err.(utilerrors.Aggregate)
 	if !ok {
 		errs = []error{err}
 	} else {
@@ -930,7 +1475,8 @@ func summarizeTaskGraphErrors(errs []error) error {
 	if klog.V(4).Enabled() {
 		klog.Infof("Summarizing %d errors", len(errs))
 		for _, err := range errs {
-			if uErr, ok := err.(*payload.UpdateError); ok {
+			var uErr *payload.UpdateError
+			if errors.As(err, &uErr) {
 				if uErr.Task != nil {
 					klog.Infof("Update error %d of %d: %s %s (%T: %v)", uErr.Task.Index, uErr.Task.Total, uErr.Reason, uErr.Message, uErr.Nested, uErr.Nested)
 				} else {
@@ -980,8 +1526,7 @@ func errorMatches(err error, fns ...func(err error) bool) bool {
 
 // isClusterOperatorNotAvailable returns true if this is a ClusterOperatorNotAvailable error
 func isClusterOperatorNotAvailable(err error) bool {
-	uErr, ok := err.(*payload.UpdateError)
-	return ok && uErr != nil && uErr.Reason == "ClusterOperatorNotAvailable"
+	return updateErrorHasReason(err, payload.ErrClusterOperatorNotAvailable)
 }
 
 // newClusterOperatorsNotAvailable unifies multiple ClusterOperatorNotAvailable errors into
@@ -990,8 +1535,8 @@ func newClusterOperatorsNotAvailable(errs []error) error {
 	updateEffect := payload.UpdateEffectNone
 	names := make([]string, 0, len(errs))
 	for _, err := range errs {
-		uErr, ok := err.(*payload.UpdateError)
-		if !ok || uErr.Reason != "ClusterOperatorNotAvailable" {
+		var uErr *payload.UpdateError
+		if !errors.As(err, &uErr) || !uErr.Is(payload.ErrClusterOperatorNotAvailable) {
 			return nil
 		}
 		if len(uErr.Name) > 0 {
@@ -1018,7 +1563,7 @@ func newClusterOperatorsNotAvailable(errs []error) error {
 	sort.Strings(names)
 	name := strings.Join(names, ", ")
 	return &payload.UpdateError{
-		Nested:       errors.NewAggregate(errs),
+		Nested:       utilerrors.NewAggregate(errs),
 		UpdateEffect: updateEffect,
 		Reason:       "ClusterOperatorsNotAvailable",
 		Message:      fmt.Sprintf("Some cluster operators are still updating: %s", name),
@@ -1065,7 +1610,7 @@ func newMultipleError(errs []error) error {
 		return errs[0]
 	}
 	return &payload.UpdateError{
-		Nested:  errors.NewAggregate(errs),
+		Nested:  utilerrors.NewAggregate(errs),
 		Reason:  "MultipleErrors",
 		Message: fmt.Sprintf("Multiple errors are preventing progress:\n* %s", strings.Join(messages, "\n* ")),
 	}
@@ -1084,6 +1629,21 @@ func getOverrideForManifest(overrides []configv1.ComponentOverride, manifest *ma
 	return configv1.ComponentOverride{}, false
 }
 
+// checkDesiredVersion returns an error if the desired update names a version that does not
+// match the version embedded in the release image metadata, so that a desiredUpdate cannot
+// claim a version name for an image that carries a different one.
+func checkDesiredVersion(desired configv1.Update, release configv1.Release) error {
+	if len(desired.Version) == 0 || desired.Version == release.Version {
+		return nil
+	}
+	return &payload.UpdateError{
+		UpdateEffect: payload.UpdateEffectFail,
+		Reason:       "PayloadVersionMismatch",
+		Message:      fmt.Sprintf("release image version %s does not match the expected upstream version %s", release.Version, desired.Version),
+		Name:         desired.Image,
+	}
+}
+
 // ownerKind contains the schema.GroupVersionKind for type that owns objects managed by CVO.
 var ownerKind = configv1.SchemeGroupVersion.WithKind("ClusterVersion")
 
@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"runtime"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 
 	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
 )
 
 func Test_statusWrapper_ReportProgress(t *testing.T) {
@@ -190,6 +195,27 @@ func Test_runThrottledStatusNotifier(t *testing.T) {
 	}
 }
 
+func Test_SyncWorker_calculateNextRollingBack(t *testing.T) {
+	w := &SyncWorker{notify: make(chan struct{}, 1)}
+	work := &SyncWork{}
+	w.Update(1, configv1.Update{Image: "image/image:2"}, nil, payload.ReconcilingPayload)
+	w.calculateNext(work)
+	if work.State != payload.ReconcilingPayload {
+		t.Fatalf("unexpected initial state %s", work.State)
+	}
+
+	w.Update(1, configv1.Update{Image: "image/image:3"}, nil, payload.UpdatingPayload)
+	if !w.calculateNext(work) || work.State != payload.UpdatingPayload {
+		t.Fatalf("unexpected state %s for an update", work.State)
+	}
+
+	// a rollback is applied as a rollback, not as an update
+	w.Update(1, configv1.Update{Image: "image/image:2"}, nil, payload.RollingBackPayload)
+	if !w.calculateNext(work) || work.State != payload.RollingBackPayload || work.Desired.Image != "image/image:2" {
+		t.Fatalf("unexpected work %#v for a rollback", work)
+	}
+}
+
 func Test_equalDigest(t *testing.T) {
 	for _, testCase := range []struct {
 		name      string
@@ -284,3 +310,66 @@ func Test_equalDigest(t *testing.T) {
 		})
 	}
 }
+
+func Test_checkDesiredVersion(t *testing.T) {
+	release := configv1.Release{Version: "4.1.0", Image: "example.com@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}
+	for _, testCase := range []struct {
+		name        string
+		desired     configv1.Update
+		expectedErr string
+	}{
+		{
+			name:    "digest only",
+			desired: configv1.Update{Image: release.Image},
+		},
+		{
+			name:    "matching version",
+			desired: configv1.Update{Version: "4.1.0", Image: release.Image},
+		},
+		{
+			name:        "mismatched version",
+			desired:     configv1.Update{Version: "4.2.0", Image: release.Image},
+			expectedErr: "release image version 4.1.0 does not match the expected upstream version 4.2.0",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := checkDesiredVersion(testCase.desired, release)
+			if testCase.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != testCase.expectedErr {
+				t.Fatalf("expected error %q, got %v", testCase.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_setReleaseInfoMetric(t *testing.T) {
+	clusterVersion := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Spec:       configv1.ClusterVersionSpec{Channel: "stable-4.8"},
+	}
+	setReleaseInfoMetric(configv1.Release{Version: "4.8.1", Image: "example.com/release:4.8.1"}, nil)
+	setReleaseInfoMetric(configv1.Release{Version: "4.8.2", Image: "example.com/release@sha256:0123"}, clusterVersion)
+
+	ch := make(chan prometheus.Metric, 10)
+	metricReleaseInfo.Collect(ch)
+	close(ch)
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected a single release info series, got %d", len(metrics))
+	}
+	expectMetric(t, metrics[0], 1, map[string]string{
+		"version":      "4.8.2",
+		"image":        "example.com/release@sha256:0123",
+		"digest":       "sha256:0123",
+		"architecture": runtime.GOARCH,
+		"channel":      "stable-4.8",
+	})
+}
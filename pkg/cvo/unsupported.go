@@ -0,0 +1,55 @@
+package cvo
+
+import (
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// cvoNamespace holds the cluster-version operator's own deployment and configuration.
+const cvoNamespace = "openshift-cluster-version"
+
+// unsupportedConfiguration returns a description, including remediation, of each known
+// unsupported situation the sync worker runs into while applying payloadUpdate for work.
+func unsupportedConfiguration(work *SyncWork, payloadUpdate *payload.Update) []string {
+	var findings []string
+	if work.Desired.Force && !payloadUpdate.VerifiedImage {
+		findings = append(findings, fmt.Sprintf("The release image %s could not be verified and is only being applied because the update was forced. Update to a signed release image to return to a supported configuration.", payloadUpdate.Release.Image))
+	}
+	for i := range payloadUpdate.Manifests {
+		m := &payloadUpdate.Manifests[i]
+		if ov, ok := getOverrideForManifest(work.Overrides, m); !ok || !ov.Unmanaged {
+			continue
+		}
+		switch {
+		case m.Obj.GetNamespace() == cvoNamespace:
+			findings = append(findings, fmt.Sprintf("The cluster-version operator's own %s is unmanaged via spec.overrides and may have been modified. Remove the override so the operator can restore it.", describeManifest(m.GVK.Kind, m.Obj.GetNamespace(), m.Obj.GetName())))
+		case m.GVK == configv1.SchemeGroupVersion.WithKind("ClusterOperator"), payload.IsSecurityCritical(m):
+			findings = append(findings, fmt.Sprintf("The critical %s is unmanaged via spec.overrides. Remove the override so the operator can keep it reconciled.", describeManifest(m.GVK.Kind, m.Obj.GetNamespace(), m.Obj.GetName())))
+		}
+	}
+	return findings
+}
+
+// disabledManifests describes the manifests of payloadUpdate that are not applied because they
+// belong to a disabled capability.
+func disabledManifests(payloadUpdate *payload.Update) []string {
+	if len(payloadUpdate.Disabled) == 0 {
+		return nil
+	}
+	descriptions := make([]string, 0, len(payloadUpdate.Disabled))
+	for _, m := range payloadUpdate.Disabled {
+		descriptions = append(descriptions, m.String())
+	}
+	return descriptions
+}
+
+func describeManifest(kind, namespace, name string) string {
+	if len(namespace) == 0 {
+		return fmt.Sprintf("%s %q", strings.ToLower(kind), name)
+	}
+	return fmt.Sprintf("%s %q", strings.ToLower(kind), namespace+"/"+name)
+}
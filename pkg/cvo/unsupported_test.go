@@ -0,0 +1,81 @@
+package cvo
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func Test_unsupportedConfiguration(t *testing.T) {
+	newManifest := func(gvk schema.GroupVersionKind, namespace, name string, annotations map[string]string) manifest.Manifest {
+		obj := &unstructured.Unstructured{}
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		obj.SetAnnotations(annotations)
+		return manifest.Manifest{GVK: gvk, Obj: obj}
+	}
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	update := &payload.Update{
+		Release: configv1.Release{Version: "1.0.0", Image: "image/image:1"},
+		Manifests: []manifest.Manifest{
+			newManifest(deployment, "openshift-cluster-version", "cluster-version-operator", nil),
+			newManifest(configv1.SchemeGroupVersion.WithKind("ClusterOperator"), "", "ingress", nil),
+			newManifest(configMap, "openshift-config-managed", "trusted-ca", map[string]string{payload.SecurityCriticalAnnotation: "true"}),
+			newManifest(configMap, "openshift-monitoring", "config", nil),
+		},
+	}
+	unmanaged := func(kind, namespace, name string) configv1.ComponentOverride {
+		return configv1.ComponentOverride{Kind: kind, Namespace: namespace, Name: name, Unmanaged: true}
+	}
+	tests := []struct {
+		name     string
+		work     SyncWork
+		verified bool
+		want     []string
+	}{
+		{
+			name:     "supported",
+			verified: true,
+			work:     SyncWork{Overrides: []configv1.ComponentOverride{unmanaged("ConfigMap", "openshift-monitoring", "config")}},
+		},
+		{
+			name: "unverified but not forced",
+			work: SyncWork{},
+		},
+		{
+			name: "forced unverified payload",
+			work: SyncWork{Desired: configv1.Update{Image: "image/image:1", Force: true}},
+			want: []string{"The release image image/image:1 could not be verified and is only being applied because the update was forced. Update to a signed release image to return to a supported configuration."},
+		},
+		{
+			name:     "overrides on critical resources",
+			verified: true,
+			work: SyncWork{Overrides: []configv1.ComponentOverride{
+				unmanaged("Deployment", "openshift-cluster-version", "cluster-version-operator"),
+				unmanaged("ClusterOperator", "", "ingress"),
+				unmanaged("ConfigMap", "openshift-config-managed", "trusted-ca"),
+				{Kind: "ConfigMap", Namespace: "openshift-monitoring", Name: "config"},
+			}},
+			want: []string{
+				`The cluster-version operator's own deployment "openshift-cluster-version/cluster-version-operator" is unmanaged via spec.overrides and may have been modified. Remove the override so the operator can restore it.`,
+				`The critical clusteroperator "ingress" is unmanaged via spec.overrides. Remove the override so the operator can keep it reconciled.`,
+				`The critical configmap "openshift-config-managed/trusted-ca" is unmanaged via spec.overrides. Remove the override so the operator can keep it reconciled.`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update.VerifiedImage = tt.verified
+			if got := unsupportedConfiguration(&tt.work, update); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected findings:\n%q\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -57,8 +57,13 @@ func (optr *Operator) syncUpgradeable(config *configv1.ClusterVersion) error {
 		})
 	}
 	sort.Slice(conds, func(i, j int) bool { return conds[i].Type < conds[j].Type })
+	operators, err := optr.coLister.List(labels.Everything())
+	if err != nil {
+		klog.Warningf("Unable to list cluster operators for the upgrade readiness score: %v", err)
+	}
 	optr.setUpgradeable(&upgradeable{
-		Conditions: conds,
+		Conditions:     conds,
+		OperatorHealth: newOperatorHealth(operators),
 	})
 	// requeue
 	optr.queue.Add(optr.queueKey())
@@ -70,6 +75,9 @@ type upgradeable struct {
 
 	// these are sorted by Type
 	Conditions []configv1.ClusterOperatorStatusCondition
+
+	// OperatorHealth feeds the upgrade readiness score.
+	OperatorHealth *operatorHealth
 }
 
 func (u *upgradeable) RecentlyChanged(interval time.Duration) bool {
@@ -0,0 +1,165 @@
+package cvo
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// syncWatchdogInterval is how often the watchdog checks the sync worker for progress.
+const syncWatchdogInterval = time.Minute
+
+// syncWatchdog detects a sync worker that has work pending, because it was notified of new
+// work or is in the middle of a sync, but has made no state transitions for longer than
+// timeout. Sync attempts are bounded by a timeout, so a stall means a wait that ignores its
+// context or a deadlock.
+type syncWatchdog struct {
+	timeout time.Duration
+	// restart cancels the sync attempt in progress when a stall is detected, so that the sync
+	// loop starts over. Goroutines blocked without regard to their context are not recovered.
+	restart bool
+
+	lock         sync.Mutex
+	lastProgress time.Time
+	notified     bool
+	syncing      bool
+	cancel       func()
+	stalledSince time.Time
+}
+
+// newSyncWatchdog returns a watchdog that treats timeout without progress as a stall.
+func newSyncWatchdog(timeout time.Duration, restart bool) *syncWatchdog {
+	return &syncWatchdog{timeout: timeout, restart: restart}
+}
+
+// workNotified records that new work was handed to the sync loop.
+func (d *syncWatchdog) workNotified(now time.Time) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.notified && !d.syncing {
+		d.lastProgress = now
+	}
+	d.notified = true
+}
+
+// syncStarted records that the sync loop began an attempt which cancel aborts.
+func (d *syncWatchdog) syncStarted(now time.Time, cancel func()) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.notified = false
+	d.syncing = true
+	d.cancel = cancel
+	d.progress(now)
+}
+
+// syncFinished records that the sync loop finished an attempt.
+func (d *syncWatchdog) syncFinished(now time.Time) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.syncing = false
+	d.cancel = nil
+	d.progress(now)
+}
+
+// progressed records a state transition of the sync worker.
+func (d *syncWatchdog) progressed(now time.Time) {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.progress(now)
+}
+
+func (d *syncWatchdog) progress(now time.Time) {
+	d.lastProgress = now
+	d.stalledSince = time.Time{}
+}
+
+// check returns when the sync worker was last seen to make progress if it is stalled, or the
+// zero time. newlyStalled is true on the first check that finds a given stall, at which point
+// the attempt in progress is cancelled if the watchdog restarts stalled workers.
+func (d *syncWatchdog) check(now time.Time) (lastProgress time.Time, newlyStalled bool) {
+	if d == nil {
+		return time.Time{}, false
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.notified && !d.syncing {
+		return time.Time{}, false
+	}
+	if now.Sub(d.lastProgress) < d.timeout {
+		return time.Time{}, false
+	}
+	if !d.stalledSince.IsZero() {
+		return d.lastProgress, false
+	}
+	d.stalledSince = now
+	if d.restart && d.cancel != nil {
+		d.cancel()
+	}
+	return d.lastProgress, true
+}
+
+// stalled returns when the sync worker was last seen to make progress if a stall has been
+// detected, or the zero time.
+func (d *syncWatchdog) stalled() time.Time {
+	if d == nil {
+		return time.Time{}
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.stalledSince.IsZero() {
+		return time.Time{}
+	}
+	return d.lastProgress
+}
+
+// EnableSyncWorkerWatchdog makes the operator report a sync worker that has made no progress
+// for timeout despite pending work. If restart is true, the stalled sync attempt is also
+// cancelled. It must be called before InitializeFromPayload.
+func (optr *Operator) EnableSyncWorkerWatchdog(timeout time.Duration, restart bool) {
+	optr.watchdog = newSyncWatchdog(timeout, restart)
+}
+
+// runSyncWatchdog checks the sync worker for stalls every interval until ctx is done.
+func (optr *Operator) runSyncWatchdog(ctx context.Context, interval time.Duration) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) { optr.checkSyncWorker(time.Now()) }, interval)
+}
+
+// checkSyncWorker logs goroutine stacks and reports a newly stalled sync worker, and requests
+// a status sync so the stall is reflected in the ClusterVersion conditions.
+func (optr *Operator) checkSyncWorker(now time.Time) {
+	lastProgress, newlyStalled := optr.watchdog.check(now)
+	if !newlyStalled {
+		return
+	}
+	stalledFor := now.Sub(lastProgress).Round(time.Second)
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		klog.Errorf("Unable to collect goroutine stacks: %v", err)
+	}
+	klog.Errorf("The sync worker has made no progress for %s with work pending, goroutine stacks:\n%s", stalledFor, buf.String())
+	ref := &corev1.ObjectReference{APIVersion: "config.openshift.io/v1", Kind: "ClusterVersion", Name: optr.name, Namespace: optr.namespace}
+	if optr.watchdog.restart {
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "SyncWorkerStalled", "the sync worker made no progress for %s with work pending, cancelling the current sync attempt", stalledFor)
+	} else {
+		optr.eventRecorder.Eventf(ref, corev1.EventTypeWarning, "SyncWorkerStalled", "the sync worker made no progress for %s with work pending", stalledFor)
+	}
+	optr.queue.Add(optr.queueKey())
+}
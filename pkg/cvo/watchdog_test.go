@@ -0,0 +1,64 @@
+package cvo
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestOperator_checkSyncWorker(t *testing.T) {
+	start := time.Unix(1000, 0)
+	cancelled := 0
+	optr := &Operator{
+		name:          "version",
+		namespace:     "openshift-cluster-version",
+		eventRecorder: record.NewFakeRecorder(10),
+		queue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test"),
+		watchdog:      newSyncWatchdog(10*time.Minute, true),
+	}
+	defer optr.queue.ShutDown()
+	events := optr.eventRecorder.(*record.FakeRecorder).Events
+
+	// an idle worker is never stalled
+	optr.checkSyncWorker(start.Add(time.Hour))
+	if !optr.watchdog.stalled().IsZero() || len(events) != 0 {
+		t.Fatalf("idle worker reported as stalled")
+	}
+
+	// a sync attempt that keeps reporting progress is not stalled
+	optr.watchdog.syncStarted(start, func() { cancelled++ })
+	optr.watchdog.progressed(start.Add(8 * time.Minute))
+	optr.checkSyncWorker(start.Add(15 * time.Minute))
+	if !optr.watchdog.stalled().IsZero() {
+		t.Fatalf("progressing worker reported as stalled")
+	}
+
+	optr.checkSyncWorker(start.Add(20 * time.Minute))
+	if got := optr.watchdog.stalled(); !got.Equal(start.Add(8*time.Minute)) || cancelled != 1 || optr.queue.Len() != 1 {
+		t.Fatalf("expected a stall since the last progress to be reported and the attempt cancelled, got %s with %d cancellations", got, cancelled)
+	}
+	if event := <-events; event != "Warning SyncWorkerStalled the sync worker made no progress for 12m0s with work pending, cancelling the current sync attempt" {
+		t.Fatalf("unexpected event: %s", event)
+	}
+
+	// a stall is only reported once
+	optr.checkSyncWorker(start.Add(30 * time.Minute))
+	if len(events) != 0 || cancelled != 1 {
+		t.Fatalf("stall reported again")
+	}
+
+	// the stall clears once the attempt returns
+	optr.watchdog.syncFinished(start.Add(31 * time.Minute))
+	if !optr.watchdog.stalled().IsZero() {
+		t.Fatalf("stall not cleared")
+	}
+
+	// pending work the sync loop never picks up is a stall
+	optr.watchdog.workNotified(start.Add(40 * time.Minute))
+	optr.checkSyncWorker(start.Add(50 * time.Minute))
+	if got := optr.watchdog.stalled(); !got.Equal(start.Add(40 * time.Minute)) {
+		t.Fatalf("expected unconsumed work to be reported as a stall, got %s", got)
+	}
+}
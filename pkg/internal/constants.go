@@ -5,4 +5,17 @@ const (
 	ConfigManagedNamespace = "openshift-config-managed"
 	InstallerConfigMap     = "openshift-install"
 	ManifestsConfigMap     = "openshift-install-manifests"
+	HoldBackConfigMap      = "cluster-version-hold-back"
+
+	UpgradeableOverrideConfigMap = "cluster-version-upgradeable-override"
+	UpgradeGatesConfigMap        = "cluster-version-upgrade-gates"
+
+	// ReleaseSignatureBundleConfigMap holds the signatures and keys the release signature
+	// precondition trusts in addition to those of the signature stores, for clusters that
+	// cannot reach them.
+	ReleaseSignatureBundleConfigMap = "cluster-version-signature-bundle"
+
+	// ToleratedDegradedOperatorsAnnotation on the ClusterVersion holds a comma-separated list
+	// of ClusterOperators whose Degraded=True condition does not block updates.
+	ToleratedDegradedOperatorsAnnotation = "release.openshift.io/tolerated-degraded-operators"
 )
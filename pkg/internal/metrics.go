@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// The reconcile and work queue metrics use the names and labels that controller-runtime
+// reports, so that existing controller dashboards cover every controller in the operator.
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_reconcile_total",
+		Help: "Total number of reconciliations per controller.",
+	}, []string{"controller", "result"})
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "controller_runtime_reconcile_errors_total",
+		Help: "Total number of reconciliation errors per controller.",
+	}, []string{"controller"})
+	reconcileTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "controller_runtime_reconcile_time_seconds",
+		Help:    "Length of time per reconciliation per controller.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 22),
+	}, []string{"controller"})
+
+	workQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "workqueue_depth",
+		Help: "Current depth of workqueue.",
+	}, []string{"name"})
+	workQueueAdds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "workqueue_adds_total",
+		Help: "Total number of adds handled by workqueue.",
+	}, []string{"name"})
+	workQueueLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "workqueue_queue_duration_seconds",
+		Help:    "How long in seconds an item stays in workqueue before being requested.",
+		Buckets: prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{"name"})
+	workQueueWorkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "workqueue_work_duration_seconds",
+		Help:    "How long in seconds processing an item from workqueue takes.",
+		Buckets: prometheus.ExponentialBuckets(10e-9, 10, 10),
+	}, []string{"name"})
+	workQueueUnfinishedWork = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "workqueue_unfinished_work_seconds",
+		Help: "How many seconds of work has been done that is in progress and hasn't been observed by work_duration. Large values indicate stuck threads. One can deduce the number of stuck threads by observing the rate at which this increases.",
+	}, []string{"name"})
+	workQueueLongestRunningProcessor = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "workqueue_longest_running_processor_seconds",
+		Help: "How many seconds has the longest running processor for workqueue been running.",
+	}, []string{"name"})
+	workQueueRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "workqueue_retries_total",
+		Help: "Total number of retries handled by workqueue.",
+	}, []string{"name"})
+)
+
+func init() {
+	prometheus.MustRegister(reconcileTotal, reconcileErrors, reconcileTime)
+	prometheus.MustRegister(workQueueDepth, workQueueAdds, workQueueLatency, workQueueWorkDuration, workQueueUnfinishedWork, workQueueLongestRunningProcessor, workQueueRetries)
+	workqueue.SetProvider(workQueueMetricsProvider{})
+}
+
+// ObserveReconcile records the result and duration of a reconciliation by a controller,
+// such as "availableupdates" or "syncworker", that started at start.
+func ObserveReconcile(controller string, start time.Time, err error) {
+	reconcileTime.WithLabelValues(controller).Observe(time.Since(start).Seconds())
+	if err != nil {
+		reconcileTotal.WithLabelValues(controller, "error").Inc()
+		reconcileErrors.WithLabelValues(controller).Inc()
+		return
+	}
+	reconcileTotal.WithLabelValues(controller, "success").Inc()
+}
+
+// workQueueMetricsProvider reports the metrics of every named work queue, labeled by the
+// queue name.
+type workQueueMetricsProvider struct{}
+
+func (workQueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workQueueDepth.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workQueueAdds.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workQueueLatency.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workQueueWorkDuration.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workQueueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workQueueLongestRunningProcessor.WithLabelValues(name)
+}
+
+func (workQueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workQueueRetries.WithLabelValues(name)
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestObserveReconcile(t *testing.T) {
+	ObserveReconcile("test", time.Now(), nil)
+	ObserveReconcile("test", time.Now(), errors.New("failed"))
+	ObserveReconcile("test", time.Now(), errors.New("failed"))
+
+	for _, tt := range []struct {
+		name  string
+		value float64
+		want  float64
+	}{
+		{name: "success", value: counterValue(t, reconcileTotal.WithLabelValues("test", "success")), want: 1},
+		{name: "error", value: counterValue(t, reconcileTotal.WithLabelValues("test", "error")), want: 2},
+		{name: "errors", value: counterValue(t, reconcileErrors.WithLabelValues("test")), want: 2},
+	} {
+		if tt.value != tt.want {
+			t.Errorf("unexpected %s count %v, want %v", tt.name, tt.value, tt.want)
+		}
+	}
+}
+
+func TestWorkQueueMetrics(t *testing.T) {
+	queue := workqueue.NewNamed("test")
+	defer queue.ShutDown()
+	queue.Add("a")
+	queue.Add("b")
+
+	m := &dto.Metric{}
+	if err := workQueueDepth.WithLabelValues("test").Write(m); err != nil {
+		t.Fatal(err)
+	}
+	if depth := m.GetGauge().GetValue(); depth != 2 {
+		t.Errorf("unexpected depth %v", depth)
+	}
+	if adds := counterValue(t, workQueueAdds.WithLabelValues("test")); adds != 2 {
+		t.Errorf("unexpected adds %v", adds)
+	}
+}
+
+func counterValue(t *testing.T, c interface{ Write(*dto.Metric) error }) float64 {
+	m := &dto.Metric{}
+	if err := c.Write(m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
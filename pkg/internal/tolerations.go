@@ -0,0 +1,15 @@
+package internal
+
+import "strings"
+
+// ToleratedDegradedOperators returns the ClusterOperators listed in the
+// ToleratedDegradedOperatorsAnnotation of annotations.
+func ToleratedDegradedOperators(annotations map[string]string) []string {
+	var names []string
+	for _, name := range strings.Split(annotations[ToleratedDegradedOperatorsAnnotation], ",") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
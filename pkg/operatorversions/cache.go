@@ -0,0 +1,125 @@
+// Package operatorversions maintains an in-memory view of the versions reported by every
+// ClusterOperator so that callers do not need to list and compare operators themselves.
+package operatorversions
+
+import (
+	"sort"
+	"sync"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configinformersv1 "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// OperatorOperand is the operand name ClusterOperators use to report their own version.
+const OperatorOperand = "operator"
+
+// Cache records the operand versions reported by each ClusterOperator. It is kept up to
+// date by an informer and is safe for concurrent use.
+type Cache struct {
+	lock sync.RWMutex
+	// versions maps operator name to operand name to reported version.
+	versions map[string]map[string]string
+}
+
+// New returns an empty cache.
+func New() *Cache {
+	return &Cache{versions: make(map[string]map[string]string)}
+}
+
+// NewForInformer returns a cache that is updated by events from informer.
+func NewForInformer(informer configinformersv1.ClusterOperatorInformer) *Cache {
+	c := New()
+	informer.Informer().AddEventHandler(c.eventHandler())
+	return c
+}
+
+func (c *Cache) eventHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if co, ok := obj.(*configv1.ClusterOperator); ok {
+				c.Set(co)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if co, ok := obj.(*configv1.ClusterOperator); ok {
+				c.Set(co)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			co, ok := obj.(*configv1.ClusterOperator)
+			if !ok {
+				klog.V(4).Infof("Unexpected object in cluster operator delete: %T", obj)
+				return
+			}
+			c.Delete(co.Name)
+		},
+	}
+}
+
+// Set records the versions currently reported by the operator.
+func (c *Cache) Set(co *configv1.ClusterOperator) {
+	versions := make(map[string]string, len(co.Status.Versions))
+	for _, v := range co.Status.Versions {
+		versions[v.Name] = v.Version
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.versions[co.Name] = versions
+}
+
+// Delete forgets the named operator.
+func (c *Cache) Delete(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.versions, name)
+}
+
+// Version returns the version the named operator reports for operand, and whether the
+// operator reports that operand at all.
+func (c *Cache) Version(name, operand string) (string, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	version, ok := c.versions[name][operand]
+	return version, ok
+}
+
+// NotAtVersion returns the sorted names of the operators that report a different version
+// for their operator operand. Operators that do not report that operand, such as add-on
+// operators that are not part of the release, are not included.
+func (c *Cache) NotAtVersion(version string) []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	var names []string
+	for name, versions := range c.versions {
+		if reported, ok := versions[OperatorOperand]; ok && reported != version {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Undone compares the expected operand versions with those actually reported and returns
+// a map of operand name to the expected version followed by the actual version, if any, for
+// every operand that is not yet at its expected version.
+func Undone(expected, actual []configv1.OperandVersion) map[string][]string {
+	undone := map[string][]string{}
+	for _, expOp := range expected {
+		undone[expOp.Name] = []string{expOp.Version}
+		for _, actOp := range actual {
+			if actOp.Name == expOp.Name {
+				undone[expOp.Name] = append(undone[expOp.Name], actOp.Version)
+				if actOp.Version == expOp.Version {
+					delete(undone, expOp.Name)
+				}
+				break
+			}
+		}
+	}
+	return undone
+}
@@ -0,0 +1,81 @@
+package operatorversions
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func clusterOperator(name string, versions ...configv1.OperandVersion) *configv1.ClusterOperator {
+	return &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     configv1.ClusterOperatorStatus{Versions: versions},
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := New()
+	h := c.eventHandler()
+	h.OnAdd(clusterOperator("a", configv1.OperandVersion{Name: OperatorOperand, Version: "1.0.1"}))
+	h.OnAdd(clusterOperator("b", configv1.OperandVersion{Name: OperatorOperand, Version: "1.0.0"}))
+	h.OnAdd(clusterOperator("c"))
+	h.OnAdd(clusterOperator("d", configv1.OperandVersion{Name: OperatorOperand, Version: "1.0.0"}))
+	h.OnAdd(clusterOperator("addon", configv1.OperandVersion{Name: "addon-operator", Version: "0.3.0"}))
+
+	if got, want := c.NotAtVersion("1.0.1"), []string{"b", "d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected operators: %v != %v", got, want)
+	}
+
+	h.OnUpdate(nil, clusterOperator("b", configv1.OperandVersion{Name: OperatorOperand, Version: "1.0.1"}, configv1.OperandVersion{Name: "operand", Version: "2"}))
+	h.OnDelete(cache.DeletedFinalStateUnknown{Key: "c", Obj: clusterOperator("c")})
+	if got, want := c.NotAtVersion("1.0.1"), []string{"d"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected operators: %v != %v", got, want)
+	}
+	if version, ok := c.Version("b", "operand"); !ok || version != "2" {
+		t.Fatalf("unexpected operand version: %q %t", version, ok)
+	}
+	if _, ok := c.Version("b", "missing"); ok {
+		t.Fatalf("unexpected version for missing operand")
+	}
+	if _, ok := c.Version("c", OperatorOperand); ok {
+		t.Fatalf("unexpected version for deleted operator")
+	}
+}
+
+func TestUndone(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []configv1.OperandVersion
+		actual   []configv1.OperandVersion
+		want     map[string][]string
+	}{
+		{
+			name:     "done",
+			expected: []configv1.OperandVersion{{Name: "operator", Version: "1"}},
+			actual:   []configv1.OperandVersion{{Name: "operator", Version: "1"}, {Name: "other", Version: "2"}},
+			want:     map[string][]string{},
+		},
+		{
+			name:     "wrong version",
+			expected: []configv1.OperandVersion{{Name: "operator", Version: "1"}},
+			actual:   []configv1.OperandVersion{{Name: "operator", Version: "0"}},
+			want:     map[string][]string{"operator": {"1", "0"}},
+		},
+		{
+			name:     "missing operand",
+			expected: []configv1.OperandVersion{{Name: "operator", Version: "1"}, {Name: "operand", Version: "2"}},
+			actual:   []configv1.OperandVersion{{Name: "operator", Version: "1"}},
+			want:     map[string][]string{"operand": {"2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Undone(tt.expected, tt.actual); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected undone: %v != %v", got, tt.want)
+			}
+		})
+	}
+}
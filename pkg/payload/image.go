@@ -3,6 +3,7 @@ package payload
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 
 	"github.com/pkg/errors"
 )
@@ -32,3 +33,86 @@ func ImageForShortName(name string) (string, error) {
 
 	return "", fmt.Errorf("error: Unknown name requested, could not find %s in UpdatePayload", name)
 }
+
+// ImageReference is an image pullspec referenced by a release payload.
+type ImageReference struct {
+	// Image is the pullspec.
+	Image string `json:"image"`
+	// Component is the image-references tag naming the image. Images only referenced
+	// from manifests use the component from the 0000_NN_NAME_* filename of the first
+	// manifest referencing them, if any.
+	Component string `json:"component,omitempty"`
+	// Manifests are the original filenames of the manifests referencing the image.
+	Manifests []string `json:"manifests,omitempty"`
+}
+
+// ImageReferences returns every image pullspec referenced by update, from the
+// image-references tags and from image fields in the included manifests, sorted by
+// component and then by image.
+func ImageReferences(update *Update) []ImageReference {
+	refs := make(map[string]*ImageReference)
+	if update.ImageRef != nil {
+		for _, tag := range update.ImageRef.Spec.Tags {
+			if tag.From == nil || tag.From.Kind != "DockerImage" || len(tag.From.Name) == 0 {
+				continue
+			}
+			if _, ok := refs[tag.From.Name]; !ok {
+				refs[tag.From.Name] = &ImageReference{Image: tag.From.Name, Component: tag.Name}
+			}
+		}
+	}
+	for i := range update.Manifests {
+		m := &update.Manifests[i]
+		for _, image := range manifestImages(m.Obj.Object) {
+			ref, ok := refs[image]
+			if !ok {
+				ref = &ImageReference{Image: image}
+				if match := reMatchPattern.FindStringSubmatch(m.OriginalFilename); match != nil {
+					ref.Component = match[groupComponent]
+				}
+				refs[image] = ref
+			}
+			if len(m.OriginalFilename) > 0 && (len(ref.Manifests) == 0 || ref.Manifests[len(ref.Manifests)-1] != m.OriginalFilename) {
+				ref.Manifests = append(ref.Manifests, m.OriginalFilename)
+			}
+		}
+	}
+
+	images := make([]ImageReference, 0, len(refs))
+	for _, ref := range refs {
+		images = append(images, *ref)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].Component != images[j].Component {
+			return images[i].Component < images[j].Component
+		}
+		return images[i].Image < images[j].Image
+	})
+	return images
+}
+
+// manifestImages returns the non-empty string values of image fields anywhere in obj,
+// such as those of pod template containers.
+func manifestImages(obj interface{}) []string {
+	var images []string
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if image, ok := v[key].(string); ok && key == "image" && len(image) > 0 {
+				images = append(images, image)
+				continue
+			}
+			images = append(images, manifestImages(v[key])...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			images = append(images, manifestImages(item)...)
+		}
+	}
+	return images
+}
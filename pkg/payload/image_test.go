@@ -0,0 +1,44 @@
+package payload
+
+import (
+	"reflect"
+	"testing"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestImageReferences(t *testing.T) {
+	deployment := func(images ...string) *unstructured.Unstructured {
+		var containers []interface{}
+		for _, image := range images {
+			containers = append(containers, map[string]interface{}{"name": "c", "image": image})
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"kind": "Deployment",
+			"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"containers": containers}}},
+		}}
+	}
+	update := &Update{
+		ImageRef: &imagev1.ImageStream{Spec: imagev1.ImageStreamSpec{Tags: []imagev1.TagReference{
+			{Name: "cli", From: &corev1.ObjectReference{Kind: "DockerImage", Name: "quay.io/ocp@sha256:01"}},
+			{Name: "ingress-operator", From: &corev1.ObjectReference{Kind: "DockerImage", Name: "quay.io/ocp@sha256:02"}},
+			{Name: "not-an-image", From: &corev1.ObjectReference{Kind: "ImageStreamTag", Name: "other:latest"}},
+		}}},
+		Manifests: []manifest.Manifest{
+			{OriginalFilename: "0000_50_ingress-operator_02_deployment.yaml", Obj: deployment("quay.io/ocp@sha256:02", "quay.io/ocp@sha256:02")},
+			{OriginalFilename: "0000_90_monitoring_00_deployment.yaml", Obj: deployment("quay.io/ocp@sha256:02", "registry.example.com/extra:1")},
+			{OriginalFilename: "0000_90_monitoring_01_config.yaml", Obj: &unstructured.Unstructured{Object: map[string]interface{}{"data": map[string]interface{}{"image": ""}}}},
+		},
+	}
+	want := []ImageReference{
+		{Image: "quay.io/ocp@sha256:01", Component: "cli"},
+		{Image: "quay.io/ocp@sha256:02", Component: "ingress-operator", Manifests: []string{"0000_50_ingress-operator_02_deployment.yaml", "0000_90_monitoring_00_deployment.yaml"}},
+		{Image: "registry.example.com/extra:1", Component: "monitoring", Manifests: []string{"0000_90_monitoring_00_deployment.yaml"}},
+	}
+	if got := ImageReferences(update); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected images:\n%#v\n%#v", got, want)
+	}
+}
@@ -66,6 +66,18 @@ const (
 	// provide better visibility during install and upgrade of
 	// error conditions.
 	PrecreatingPayload
+	// PreflightPayload indicates we are submitting the payload with
+	// server-side dry-run before an update begins, so that manifests
+	// the server would reject are found before any are applied.
+	PreflightPayload
+	// RollingBackPayload indicates we are returning to the release
+	// we updated from after an update failed.
+	//
+	// A rollback is applied as conservatively as an update, but in
+	// the reverse of the run level order the update was applied in,
+	// so that later components are returned to their earlier
+	// versions before the components they depend upon.
+	RollingBackPayload
 )
 
 // Initializing is true if the state is InitializingPayload.
@@ -82,6 +94,10 @@ func (s State) String() string {
 		return "Updating"
 	case InitializingPayload:
 		return "Initializing"
+	case PreflightPayload:
+		return "Preflight"
+	case RollingBackPayload:
+		return "RollingBack"
 	default:
 		panic(fmt.Sprintf("unrecognized state %d", int(s)))
 	}
@@ -111,6 +127,39 @@ type Update struct {
 	// manifestHash is a hash of the manifests included in this payload
 	ManifestHash string
 	Manifests    []manifest.Manifest
+
+	// RunLevelBudgets are the expected durations of run levels during an update, keyed by
+	// the NN of 0000_NN_ manifest filenames. Run levels without a budget are not tracked.
+	RunLevelBudgets map[string]time.Duration
+
+	// Disabled are the manifests of the release that are not applied because they belong
+	// to a disabled capability.
+	Disabled []DisabledManifest
+}
+
+// CapabilityAnnotation on a manifest names the capabilities it belongs to, separated by "+".
+// Manifests are not applied to clusters where any of their capabilities is disabled.
+const CapabilityAnnotation = "capability.openshift.io/name"
+
+// DisabledManifest is a manifest of the release that is not applied because it belongs to a
+// disabled capability.
+type DisabledManifest struct {
+	OriginalFilename string
+	Kind             string
+	Namespace        string
+	Name             string
+
+	// Capabilities are the disabled capabilities the manifest belongs to.
+	Capabilities []string
+}
+
+// String describes the manifest and the capabilities that disable it.
+func (m DisabledManifest) String() string {
+	name := m.Name
+	if len(m.Namespace) > 0 {
+		name = m.Namespace + "/" + name
+	}
+	return fmt.Sprintf("%s %q (%s)", strings.ToLower(m.Kind), name, strings.Join(m.Capabilities, "+"))
 }
 
 // metadata represents Cincinnati metadata.
@@ -132,13 +181,17 @@ type metadata struct {
 	Metadata map[string]interface{}
 }
 
-func LoadUpdate(dir, releaseImage, excludeIdentifier, profile string) (*Update, error) {
+// LoadUpdate loads the release in dir. Manifests excluded by excludeIdentifier or not
+// included in profile are left out, and manifests belonging to any of disabledCapabilities
+// are left out and listed in the Disabled manifests of the release.
+func LoadUpdate(dir, releaseImage, excludeIdentifier, profile string, disabledCapabilities []string) (*Update, error) {
 	payload, tasks, err := loadUpdatePayloadMetadata(dir, releaseImage, profile)
 	if err != nil {
 		return nil, err
 	}
 
 	var manifests []manifest.Manifest
+	var disabled []DisabledManifest
 	var errs []error
 	for _, task := range tasks {
 		files, err := ioutil.ReadDir(task.idir)
@@ -185,6 +238,17 @@ func LoadUpdate(dir, releaseImage, excludeIdentifier, profile string) (*Update,
 				if shouldExclude(excludeIdentifier, profile, &manifest) {
 					continue
 				}
+				if capabilities := disabledCapabilitiesOf(&manifest, disabledCapabilities); len(capabilities) > 0 {
+					klog.V(4).Infof("Excluding %s %s from %s, which belongs to disabled capabilities %v", manifest.GVK.Kind, manifest.Obj.GetName(), file.Name(), capabilities)
+					disabled = append(disabled, DisabledManifest{
+						OriginalFilename: filepath.Base(file.Name()),
+						Kind:             manifest.GVK.Kind,
+						Namespace:        manifest.Obj.GetNamespace(),
+						Name:             manifest.Obj.GetName(),
+						Capabilities:     capabilities,
+					})
+					continue
+				}
 				filteredMs = append(filteredMs, manifest)
 			}
 			ms = filteredMs
@@ -210,9 +274,21 @@ func LoadUpdate(dir, releaseImage, excludeIdentifier, profile string) (*Update,
 
 	payload.ManifestHash = base64.URLEncoding.EncodeToString(hash.Sum(nil))
 	payload.Manifests = manifests
+	payload.Disabled = disabled
 	return payload, nil
 }
 
+// SecurityCriticalAnnotation marks a manifest whose drift must be corrected as quickly as
+// possible, such as RBAC or admission webhook configuration the platform depends on. When
+// reconciling, these manifests are applied before the rest of the payload and on their own,
+// shorter interval.
+const SecurityCriticalAnnotation = "release.openshift.io/security-critical"
+
+// IsSecurityCritical returns true if the manifest is annotated as security-critical.
+func IsSecurityCritical(manifest *manifest.Manifest) bool {
+	return manifest.Obj.GetAnnotations()[SecurityCriticalAnnotation] == "true"
+}
+
 func shouldExclude(excludeIdentifier, profile string, manifest *manifest.Manifest) bool {
 	annotations := manifest.Obj.GetAnnotations()
 	if annotations == nil {
@@ -231,6 +307,26 @@ func shouldExclude(excludeIdentifier, profile string, manifest *manifest.Manifes
 	return true
 }
 
+// disabledCapabilitiesOf returns the capabilities in CapabilityAnnotation of the manifest that
+// are listed in disabled.
+func disabledCapabilitiesOf(manifest *manifest.Manifest, disabled []string) []string {
+	value, ok := manifest.Obj.GetAnnotations()[CapabilityAnnotation]
+	if !ok || len(disabled) == 0 {
+		return nil
+	}
+	var found []string
+	for _, capability := range strings.Split(value, "+") {
+		capability = strings.TrimSpace(capability)
+		for _, name := range disabled {
+			if capability == name {
+				found = append(found, capability)
+				break
+			}
+		}
+	}
+	return found
+}
+
 // ValidateDirectory checks if a directory can be a candidate update by
 // looking for known files. It returns an error if the directory cannot
 // be an update.
@@ -266,7 +362,7 @@ func loadUpdatePayloadMetadata(dir, releaseImage, clusterProfile string) (*Updat
 		releaseDir = filepath.Join(dir, ReleaseManifestDir)
 	)
 
-	release, err := loadReleaseFromMetadata(releaseDir)
+	release, budgets, err := loadReleaseFromMetadata(releaseDir)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -284,8 +380,9 @@ func loadUpdatePayloadMetadata(dir, releaseImage, clusterProfile string) (*Updat
 	tasks := getPayloadTasks(releaseDir, cvoDir, releaseImage, clusterProfile)
 
 	return &Update{
-		Release:  release,
-		ImageRef: imageRef,
+		Release:         release,
+		ImageRef:        imageRef,
+		RunLevelBudgets: budgets,
 	}, tasks, nil
 }
 
@@ -309,29 +406,29 @@ func getPayloadTasks(releaseDir, cvoDir, releaseImage, clusterProfile string) []
 	}}
 }
 
-func loadReleaseFromMetadata(releaseDir string) (configv1.Release, error) {
+func loadReleaseFromMetadata(releaseDir string) (configv1.Release, map[string]time.Duration, error) {
 	var release configv1.Release
 	path := filepath.Join(releaseDir, cincinnatiJSONFile)
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		return release, err
+		return release, nil, err
 	}
 
 	var metadata metadata
 	if err := json.Unmarshal(data, &metadata); err != nil {
-		return release, fmt.Errorf("unmarshal Cincinnati metadata: %w", err)
+		return release, nil, fmt.Errorf("unmarshal Cincinnati metadata: %w", err)
 	}
 
 	if metadata.Kind != "cincinnati-metadata-v0" {
-		return release, fmt.Errorf("unrecognized Cincinnati metadata kind %q", metadata.Kind)
+		return release, nil, fmt.Errorf("unrecognized Cincinnati metadata kind %q", metadata.Kind)
 	}
 
 	if metadata.Version == "" {
-		return release, errors.New("missing required Cincinnati metadata version")
+		return release, nil, errors.New("missing required Cincinnati metadata version")
 	}
 
 	if _, err := semver.Parse(metadata.Version); err != nil {
-		return release, fmt.Errorf("Cincinnati metadata version %q is not a valid semantic version: %v", metadata.Version, err)
+		return release, nil, fmt.Errorf("Cincinnati metadata version %q is not a valid semantic version: %v", metadata.Version, err)
 	}
 
 	release.Version = metadata.Version
@@ -352,7 +449,47 @@ func loadReleaseFromMetadata(releaseDir string) (configv1.Release, error) {
 		}
 	}
 
-	return release, nil
+	var budgets map[string]time.Duration
+	if budgetsInterface, ok := metadata.Metadata[RunLevelBudgetsMetadataKey]; ok {
+		if budgetsString, ok := budgetsInterface.(string); ok {
+			if budgets, err = parseRunLevelBudgets(budgetsString); err != nil {
+				klog.Warningf("ignoring run level budgets from %s (%s): %v", cincinnatiJSONFile, release.Version, err)
+			}
+		} else {
+			klog.Warningf("run level budgets from %s (%s) are not a string: %v", cincinnatiJSONFile, release.Version, budgetsInterface)
+		}
+	}
+
+	return release, budgets, nil
+}
+
+// RunLevelBudgetsMetadataKey is the release metadata key holding the expected duration of
+// run levels during an update, as a comma-separated list of <run level>=<duration> pairs,
+// for example "10=5m,40=15m".
+const RunLevelBudgetsMetadataKey = "release.openshift.io/run-level-budgets"
+
+func parseRunLevelBudgets(value string) (map[string]time.Duration, error) {
+	budgets := make(map[string]time.Duration)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%q is not of the form <run level>=<duration>", pair)
+		}
+		level := strings.TrimSpace(parts[0])
+		if !IsRunLevel(level) {
+			return nil, fmt.Errorf("%q is not a two-digit run level, like 05", level)
+		}
+		budget, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || budget <= 0 {
+			return nil, fmt.Errorf("%q is not a positive duration", parts[1])
+		}
+		budgets[level] = budget
+	}
+	return budgets, nil
 }
 
 func loadImageReferences(releaseDir string) (*imagev1.ImageStream, error) {
@@ -2,9 +2,11 @@ package payload
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -112,7 +114,7 @@ func Test_loadUpdatePayload(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := LoadUpdate(tt.args.dir, tt.args.releaseImage, "exclude-test", DefaultClusterProfile)
+			got, err := LoadUpdate(tt.args.dir, tt.args.releaseImage, "exclude-test", DefaultClusterProfile, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("loadUpdatePayload() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -185,3 +187,107 @@ func Test_Exclude(t *testing.T) {
 		}
 	}
 }
+
+func Test_loadUpdatePayloadDisabledCapabilities(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payload-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join("..", "cvo", "testdata", "payloadtest")
+	for _, path := range []string{CVOManifestDir, ReleaseManifestDir} {
+		if err := os.Mkdir(filepath.Join(dir, path), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(ReleaseManifestDir, cincinnatiJSONFile):  string(mustRead(filepath.Join(source, ReleaseManifestDir, cincinnatiJSONFile))),
+		filepath.Join(ReleaseManifestDir, imageReferencesFile): string(mustRead(filepath.Join(source, ReleaseManifestDir, imageReferencesFile))),
+		filepath.Join(ReleaseManifestDir, "0000_50_console_deployment.yaml"): `
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: console
+  namespace: openshift-console
+  annotations:
+    include.release.openshift.io/self-managed-high-availability: "true"
+    capability.openshift.io/name: Console
+`,
+		filepath.Join(ReleaseManifestDir, "0000_50_insights_configmap.yaml"): `
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: insights
+  namespace: openshift-insights
+  annotations:
+    include.release.openshift.io/self-managed-high-availability: "true"
+    capability.openshift.io/name: Insights+Console
+`,
+		filepath.Join(ReleaseManifestDir, "0000_50_monitoring_configmap.yaml"): `
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: monitoring
+  namespace: openshift-monitoring
+  annotations:
+    include.release.openshift.io/self-managed-high-availability: "true"
+    capability.openshift.io/name: Monitoring
+`,
+	}
+	for path, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, path), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	update, err := LoadUpdate(dir, "image:1", "", DefaultClusterProfile, []string{"Console"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(update.Manifests) != 1 || update.Manifests[0].Obj.GetName() != "monitoring" {
+		t.Fatalf("unexpected manifests: %#v", update.Manifests)
+	}
+	expected := []DisabledManifest{
+		{OriginalFilename: "0000_50_console_deployment.yaml", Kind: "Deployment", Namespace: "openshift-console", Name: "console", Capabilities: []string{"Console"}},
+		{OriginalFilename: "0000_50_insights_configmap.yaml", Kind: "ConfigMap", Namespace: "openshift-insights", Name: "insights", Capabilities: []string{"Console"}},
+	}
+	if !reflect.DeepEqual(update.Disabled, expected) {
+		t.Fatalf("unexpected disabled manifests: %s", diff.ObjectReflectDiff(expected, update.Disabled))
+	}
+	if description := update.Disabled[0].String(); description != `deployment "openshift-console/console" (Console)` {
+		t.Fatalf("unexpected description %q", description)
+	}
+
+	if update, err := LoadUpdate(dir, "image:1", "", DefaultClusterProfile, nil); err != nil || len(update.Manifests) != 3 || len(update.Disabled) != 0 {
+		t.Fatalf("unexpected update without disabled capabilities: %#v, %v", update, err)
+	}
+}
+
+func Test_parseRunLevelBudgets(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: map[string]time.Duration{}},
+		{name: "valid", value: "10=5m, 40=1h30m,", want: map[string]time.Duration{"10": 5 * time.Minute, "40": 90 * time.Minute}},
+		{name: "missing duration", value: "10", wantErr: true},
+		{name: "non-numeric run level", value: "ten=5m", wantErr: true},
+		{name: "single-digit run level", value: "5=10m", wantErr: true},
+		{name: "signed run level", value: "+05=10m", wantErr: true},
+		{name: "invalid duration", value: "10=5 minutes", wantErr: true},
+		{name: "negative duration", value: "10=-5m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRunLevelBudgets(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected budgets: %v", got)
+			}
+		})
+	}
+}
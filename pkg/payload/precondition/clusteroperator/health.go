@@ -0,0 +1,120 @@
+// Package clusteroperator contains preconditions on the ClusterOperators of the cluster.
+package clusteroperator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+	"github.com/openshift/cluster-version-operator/pkg/internal"
+	precondition "github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+// Health checks that every ClusterOperator is Available=True and not Degraded=True, so that an
+// update does not begin on top of a broken component and fail much later. ClusterOperators
+// listed in the ToleratedDegradedOperatorsAnnotation of the ClusterVersion may be degraded.
+type Health struct {
+	lister    configv1listers.ClusterOperatorLister
+	tolerated map[string]struct{}
+}
+
+// NewHealth returns a new Health precondition check that ignores the named ClusterOperators.
+func NewHealth(lister configv1listers.ClusterOperatorLister, tolerated []string) *Health {
+	pf := &Health{lister: lister, tolerated: make(map[string]struct{}, len(tolerated))}
+	for _, name := range tolerated {
+		pf.tolerated[name] = struct{}{}
+	}
+	return pf
+}
+
+// Run runs the Health precondition. It always passes while the cluster is installing, when the
+// ClusterOperators have yet to become available.
+func (pf *Health) Run(ctx context.Context, releaseContext precondition.ReleaseContext, clusterVersion *configv1.ClusterVersion) error {
+	if !hasCompletedUpdate(clusterVersion) {
+		klog.V(4).Infof("Precondition %s passed: the cluster has not finished installing.", pf.Name())
+		return nil
+	}
+	operators, err := pf.lister.List(labels.Everything())
+	if err != nil {
+		return &precondition.Error{
+			Nested:  err,
+			Reason:  "UnknownError",
+			Message: err.Error(),
+			Name:    pf.Name(),
+		}
+	}
+	sort.Slice(operators, func(i, j int) bool { return operators[i].Name < operators[j].Name })
+
+	// the administrator may tolerate operators they knowingly run degraded
+	degradedTolerated := map[string]struct{}{}
+	for _, name := range internal.ToleratedDegradedOperators(clusterVersion.Annotations) {
+		degradedTolerated[name] = struct{}{}
+	}
+
+	var unavailable, degraded, problems []string
+	for _, co := range operators {
+		if _, ok := pf.tolerated[co.Name]; ok {
+			continue
+		}
+		if c := resourcemerge.FindOperatorStatusCondition(co.Status.Conditions, configv1.OperatorAvailable); c == nil || c.Status != configv1.ConditionTrue {
+			unavailable = append(unavailable, co.Name)
+			problems = append(problems, describe(co.Name, "is not available", c))
+		}
+		if _, ok := degradedTolerated[co.Name]; ok {
+			continue
+		}
+		if c := resourcemerge.FindOperatorStatusCondition(co.Status.Conditions, configv1.OperatorDegraded); c != nil && c.Status == configv1.ConditionTrue {
+			degraded = append(degraded, co.Name)
+			problems = append(problems, describe(co.Name, "is degraded", c))
+		}
+	}
+	if len(problems) == 0 {
+		klog.V(4).Infof("Precondition %s passed: all %d cluster operators are available and not degraded.", pf.Name(), len(operators))
+		return nil
+	}
+
+	reason := "ClusterOperatorsUnhealthy"
+	switch {
+	case len(degraded) == 0:
+		reason = "ClusterOperatorsNotAvailable"
+	case len(unavailable) == 0:
+		reason = "ClusterOperatorsDegraded"
+	}
+	return &precondition.Error{
+		Reason:  reason,
+		Message: fmt.Sprintf("Cluster operators must be available and not degraded before updating: %s", strings.Join(problems, "; ")),
+		Name:    pf.Name(),
+	}
+}
+
+// Name returns Name for the precondition.
+func (pf *Health) Name() string { return "ClusterOperatorHealth" }
+
+func describe(name, problem string, condition *configv1.ClusterOperatorStatusCondition) string {
+	if condition == nil {
+		return fmt.Sprintf("%s %s", name, problem)
+	}
+	if len(condition.Message) == 0 {
+		return fmt.Sprintf("%s %s (%s)", name, problem, condition.Reason)
+	}
+	return fmt.Sprintf("%s %s (%s: %s)", name, problem, condition.Reason, condition.Message)
+}
+
+func hasCompletedUpdate(cv *configv1.ClusterVersion) bool {
+	if cv == nil {
+		return false
+	}
+	for _, h := range cv.Status.History {
+		if h.State == configv1.CompletedUpdate {
+			return true
+		}
+	}
+	return false
+}
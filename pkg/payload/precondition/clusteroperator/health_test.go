@@ -0,0 +1,119 @@
+package clusteroperator
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-version-operator/pkg/internal"
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+func TestHealthRun(t *testing.T) {
+	operator := func(name string, available, degraded configv1.ConditionStatus) *configv1.ClusterOperator {
+		co := &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if available != "" {
+			co.Status.Conditions = append(co.Status.Conditions, configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorAvailable, Status: available, Reason: "AsExpected"})
+		}
+		if degraded != "" {
+			co.Status.Conditions = append(co.Status.Conditions, configv1.ClusterOperatorStatusCondition{Type: configv1.OperatorDegraded, Status: degraded, Reason: "SomethingBroke", Message: "something broke"})
+		}
+		return co
+	}
+	installed := &configv1.ClusterVersion{Status: configv1.ClusterVersionStatus{History: []configv1.UpdateHistory{{State: configv1.CompletedUpdate, Version: "4.8.1"}}}}
+	installing := &configv1.ClusterVersion{Status: configv1.ClusterVersionStatus{History: []configv1.UpdateHistory{{State: configv1.PartialUpdate, Version: "4.8.1"}}}}
+
+	tests := []struct {
+		name       string
+		operators  []*configv1.ClusterOperator
+		tolerated  []string
+		cv         *configv1.ClusterVersion
+		wantReason string
+		wantMsg    string
+	}{
+		{
+			name:      "healthy",
+			operators: []*configv1.ClusterOperator{operator("dns", configv1.ConditionTrue, configv1.ConditionFalse), operator("network", configv1.ConditionTrue, "")},
+			cv:        installed,
+		},
+		{
+			name:       "degraded",
+			operators:  []*configv1.ClusterOperator{operator("dns", configv1.ConditionTrue, configv1.ConditionTrue), operator("network", configv1.ConditionTrue, configv1.ConditionFalse)},
+			cv:         installed,
+			wantReason: "ClusterOperatorsDegraded",
+			wantMsg:    "Cluster operators must be available and not degraded before updating: dns is degraded (SomethingBroke: something broke)",
+		},
+		{
+			name:       "not available",
+			operators:  []*configv1.ClusterOperator{operator("network", configv1.ConditionFalse, ""), operator("dns", "", "")},
+			cv:         installed,
+			wantReason: "ClusterOperatorsNotAvailable",
+			wantMsg:    "Cluster operators must be available and not degraded before updating: dns is not available; network is not available (AsExpected)",
+		},
+		{
+			name:       "unhealthy",
+			operators:  []*configv1.ClusterOperator{operator("network", configv1.ConditionFalse, ""), operator("dns", configv1.ConditionTrue, configv1.ConditionTrue)},
+			cv:         installed,
+			wantReason: "ClusterOperatorsUnhealthy",
+			wantMsg:    "Cluster operators must be available and not degraded before updating: dns is degraded (SomethingBroke: something broke); network is not available (AsExpected)",
+		},
+		{
+			name:      "tolerated",
+			operators: []*configv1.ClusterOperator{operator("dns", configv1.ConditionFalse, configv1.ConditionTrue), operator("network", configv1.ConditionTrue, configv1.ConditionFalse)},
+			tolerated: []string{"dns"},
+			cv:        installed,
+		},
+		{
+			name:      "degraded tolerated by the ClusterVersion",
+			operators: []*configv1.ClusterOperator{operator("image-registry", configv1.ConditionTrue, configv1.ConditionTrue)},
+			cv: &configv1.ClusterVersion{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{internal.ToleratedDegradedOperatorsAnnotation: "image-registry"}},
+				Status:     installed.Status,
+			},
+		},
+		{
+			name:      "unavailable although degraded is tolerated",
+			operators: []*configv1.ClusterOperator{operator("image-registry", configv1.ConditionFalse, configv1.ConditionTrue)},
+			cv: &configv1.ClusterVersion{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{internal.ToleratedDegradedOperatorsAnnotation: "image-registry"}},
+				Status:     installed.Status,
+			},
+			wantReason: "ClusterOperatorsNotAvailable",
+			wantMsg:    "Cluster operators must be available and not degraded before updating: image-registry is not available (AsExpected)",
+		},
+		{
+			name:      "installing",
+			operators: []*configv1.ClusterOperator{operator("dns", configv1.ConditionFalse, configv1.ConditionTrue)},
+			cv:        installing,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, co := range tc.operators {
+				if err := indexer.Add(co); err != nil {
+					t.Fatal(err)
+				}
+			}
+			pf := NewHealth(configv1listers.NewClusterOperatorLister(indexer), tc.tolerated)
+			err := pf.Run(context.Background(), precondition.ReleaseContext{DesiredVersion: "4.8.2"}, tc.cv)
+			if tc.wantReason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			pErr, ok := err.(*precondition.Error)
+			if !ok {
+				t.Fatalf("expected a precondition error, got %v", err)
+			}
+			if pErr.Reason != tc.wantReason || pErr.Message != tc.wantMsg || pErr.Name != "ClusterOperatorHealth" {
+				t.Fatalf("unexpected error %s: %s", pErr.Reason, pErr.Message)
+			}
+		})
+	}
+}
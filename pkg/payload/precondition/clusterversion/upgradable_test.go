@@ -3,7 +3,9 @@ package clusterversion
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
 
@@ -12,6 +14,8 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
 
+	corev1 "k8s.io/api/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -153,3 +157,94 @@ func fakeClusterVersionLister(clusterVersion *configv1.ClusterVersion) configv1l
 	indexer.Add(clusterVersion)
 	return configv1listers.NewClusterVersionLister(indexer)
 }
+
+func TestUpgradeableOverride(t *testing.T) {
+	now := time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC)
+	clusterVersion := &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{Name: "version"},
+		Status: configv1.ClusterVersionStatus{
+			History: []configv1.UpdateHistory{{Version: "4.1.3", State: configv1.CompletedUpdate}},
+			Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorUpgradeable, Status: configv1.ConditionFalse, Reason: "ClusterOperatorsNotUpgradeable", Message: "set to False"},
+				{Type: "UpgradeableClusterOperators", Status: configv1.ConditionFalse, Reason: "ClusterOperatorsNotUpgradeable"},
+				{Type: "UpgradeableAdminAckRequired", Status: configv1.ConditionTrue, Reason: "AsExpected"},
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		data     map[string]string
+		expected string
+		waiver   *precondition.Waiver
+	}{
+		{
+			name:     "no override",
+			expected: "set to False",
+		},
+		{
+			name:     "override waives the failure",
+			data:     map[string]string{"expires": "2021-04-02T00:00:00Z", "requestedBy": "admin@example.com", "reason": "OCPBUGS-1"},
+			expected: `Precondition "ClusterVersionUpgradeable" failure waived by admin@example.com until 2021-04-02T00:00:00Z: set to False`,
+			waiver: &precondition.Waiver{
+				Name:        "ClusterVersionUpgradeable",
+				RequestedBy: "admin@example.com",
+				Reason:      "OCPBUGS-1",
+				Expires:     time.Date(2021, 4, 2, 0, 0, 0, 0, time.UTC),
+				Bypassed:    []string{"Upgradeable=False (ClusterOperatorsNotUpgradeable)", "UpgradeableClusterOperators=False (ClusterOperatorsNotUpgradeable)"},
+			},
+		},
+		{
+			name:     "override for the target version",
+			data:     map[string]string{"expires": "2021-04-02T00:00:00Z", "requestedBy": "admin@example.com", "version": "4.2.0"},
+			expected: `Precondition "ClusterVersionUpgradeable" failure waived by admin@example.com until 2021-04-02T00:00:00Z: set to False`,
+		},
+		{
+			name:     "override for another version",
+			data:     map[string]string{"expires": "2021-04-02T00:00:00Z", "requestedBy": "admin@example.com", "version": "4.3.0"},
+			expected: "set to False",
+		},
+		{
+			name:     "expired override",
+			data:     map[string]string{"expires": "2021-04-01T00:00:00Z", "requestedBy": "admin@example.com"},
+			expected: "set to False",
+		},
+		{
+			name:     "override without expiry",
+			data:     map[string]string{"requestedBy": "admin@example.com"},
+			expected: "set to False",
+		},
+		{
+			name:     "override without requester",
+			data:     map[string]string{"expires": "2021-04-02T00:00:00Z"},
+			expected: "set to False",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.data != nil {
+				indexer.Add(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: "cluster-version-upgradeable-override"},
+					Data:       tc.data,
+				})
+			}
+			instance := NewUpgradeableWithOverrides(fakeClusterVersionLister(clusterVersion), corev1listers.NewConfigMapLister(indexer).ConfigMaps("openshift-config"))
+			instance.now = func() time.Time { return now }
+
+			err := instance.Run(context.TODO(), precondition.ReleaseContext{DesiredVersion: "4.2.0"}, clusterVersion)
+			if err == nil || err.Error() != tc.expected {
+				t.Fatalf("unexpected error %v, expected %s", err, tc.expected)
+			}
+			if tc.waiver != nil {
+				waiver, ok := err.(*precondition.Waiver)
+				if !ok {
+					t.Fatalf("expected a waiver, got %T", err)
+				}
+				tc.waiver.Failure = waiver.Failure
+				if !reflect.DeepEqual(waiver, tc.waiver) {
+					t.Fatalf("unexpected waiver:\n%#v\n%#v", waiver, tc.waiver)
+				}
+			}
+		})
+	}
+}
@@ -2,15 +2,20 @@ package clusterversion
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
 	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+	"github.com/openshift/cluster-version-operator/pkg/internal"
 	precondition "github.com/openshift/cluster-version-operator/pkg/payload/precondition"
 )
 
@@ -18,6 +23,10 @@ import (
 type Upgradeable struct {
 	key    string
 	lister configv1listers.ClusterVersionLister
+
+	// overrides, if set, holds the administrator's UpgradeableOverride ConfigMap.
+	overrides corev1listers.ConfigMapNamespaceLister
+	now       func() time.Time
 }
 
 // NewUpgradeable returns a new Upgradeable precondition check.
@@ -25,9 +34,53 @@ func NewUpgradeable(lister configv1listers.ClusterVersionLister) *Upgradeable {
 	return &Upgradeable{
 		key:    "version",
 		lister: lister,
+		now:    time.Now,
 	}
 }
 
+// NewUpgradeableWithOverrides returns a new Upgradeable precondition check whose failures can be
+// waived by an UpgradeableOverride in the cluster-version-upgradeable-override ConfigMap.
+func NewUpgradeableWithOverrides(lister configv1listers.ClusterVersionLister, overrides corev1listers.ConfigMapNamespaceLister) *Upgradeable {
+	pf := NewUpgradeable(lister)
+	pf.overrides = overrides
+	return pf
+}
+
+// UpgradeableOverride waives Upgradeable=False for updates until it expires. It is read from
+// the expires, requestedBy, reason and version keys of its ConfigMap.
+type UpgradeableOverride struct {
+	// Expires is when the override stops applying. It is required.
+	Expires time.Time
+	// RequestedBy identifies who or what asked for the override, such as a user or ticket.
+	// It is required.
+	RequestedBy string
+	// Reason is an optional justification.
+	Reason string
+	// Version, if set, restricts the override to updates to that version.
+	Version string
+}
+
+// ParseUpgradeableOverride reads an UpgradeableOverride from its ConfigMap.
+func ParseUpgradeableOverride(cm *corev1.ConfigMap) (*UpgradeableOverride, error) {
+	override := &UpgradeableOverride{
+		RequestedBy: strings.TrimSpace(cm.Data["requestedBy"]),
+		Reason:      strings.TrimSpace(cm.Data["reason"]),
+		Version:     strings.TrimSpace(cm.Data["version"]),
+	}
+	expires, ok := cm.Data["expires"]
+	if !ok {
+		return nil, fmt.Errorf("%s/%s must set expires", cm.Namespace, cm.Name)
+	}
+	var err error
+	if override.Expires, err = time.Parse(time.RFC3339, strings.TrimSpace(expires)); err != nil {
+		return nil, fmt.Errorf("%s/%s expires is not an RFC 3339 time: %v", cm.Namespace, cm.Name, err)
+	}
+	if len(override.RequestedBy) == 0 {
+		return nil, fmt.Errorf("%s/%s must set requestedBy", cm.Namespace, cm.Name)
+	}
+	return override, nil
+}
+
 // ClusterVersionOverridesCondition returns an UpgradeableClusterVersionOverrides condition when overrides are set, and nil when no overrides are set.
 func ClusterVersionOverridesCondition(cv *configv1.ClusterVersion) *configv1.ClusterOperatorStatusCondition {
 	for _, override := range cv.Spec.Overrides {
@@ -90,22 +143,70 @@ func (pf *Upgradeable) Run(ctx context.Context, releaseContext precondition.Rele
 		if condition := ClusterVersionOverridesCondition(clusterVersion); condition != nil {
 			klog.V(4).Infof("Update from %s to %s blocked by %s: %s", currentVersion, releaseContext.DesiredVersion, condition.Reason, condition.Message)
 
-			return &precondition.Error{
+			return pf.waive(&precondition.Error{
 				Reason:  condition.Reason,
 				Message: condition.Message,
 				Name:    pf.Name(),
-			}
+			}, cv, releaseContext, []string{fmt.Sprintf("%s=%s (%s)", condition.Type, condition.Status, condition.Reason)})
 		} else {
 			return nil
 		}
 	}
 
-	return &precondition.Error{
+	return pf.waive(&precondition.Error{
 		Nested:  err,
 		Reason:  up.Reason,
 		Message: up.Message,
 		Name:    pf.Name(),
+	}, cv, releaseContext, bypassedConditions(cv.Status.Conditions))
+}
+
+// waive returns a Waiver for failure if an unexpired override applies to the release, and
+// failure otherwise. Invalid overrides are reported and do not apply.
+func (pf *Upgradeable) waive(failure *precondition.Error, cv *configv1.ClusterVersion, releaseContext precondition.ReleaseContext, bypassed []string) error {
+	if pf.overrides == nil {
+		return failure
+	}
+	cm, err := pf.overrides.Get(internal.UpgradeableOverrideConfigMap)
+	if apierrors.IsNotFound(err) {
+		return failure
+	}
+	if err != nil {
+		klog.Errorf("Unable to read the Upgradeable override: %v", err)
+		return failure
+	}
+	override, err := ParseUpgradeableOverride(cm)
+	if err != nil {
+		klog.Errorf("Ignoring invalid Upgradeable override: %v", err)
+		return failure
+	}
+	if !pf.now().Before(override.Expires) {
+		klog.V(2).Infof("Ignoring the Upgradeable override requested by %s, which expired at %s", override.RequestedBy, override.Expires.UTC().Format(time.RFC3339))
+		return failure
+	}
+	if len(override.Version) > 0 && override.Version != releaseContext.DesiredVersion {
+		klog.V(2).Infof("Ignoring the Upgradeable override requested by %s for version %s, the update is to %s", override.RequestedBy, override.Version, releaseContext.DesiredVersion)
+		return failure
+	}
+	return &precondition.Waiver{
+		Name:        pf.Name(),
+		RequestedBy: override.RequestedBy,
+		Reason:      override.Reason,
+		Expires:     override.Expires,
+		Bypassed:    bypassed,
+		Failure:     failure,
+	}
+}
+
+// bypassedConditions describes the False Upgradeable conditions of the ClusterVersion.
+func bypassedConditions(conditions []configv1.ClusterOperatorStatusCondition) []string {
+	var bypassed []string
+	for _, condition := range conditions {
+		if strings.HasPrefix(string(condition.Type), string(configv1.OperatorUpgradeable)) && condition.Status == configv1.ConditionFalse {
+			bypassed = append(bypassed, fmt.Sprintf("%s=%s (%s)", condition.Type, condition.Status, condition.Reason))
+		}
 	}
+	return bypassed
 }
 
 // Name returns Name for the precondition.
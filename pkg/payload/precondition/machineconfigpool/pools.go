@@ -0,0 +1,180 @@
+// Package machineconfigpool contains the precondition that the cluster can roll its nodes to
+// the machine configuration of a new release: that the machine config operator will not be
+// held up by paused or degraded MachineConfigPools, or by PodDisruptionBudgets that prevent
+// draining the control plane.
+package machineconfigpool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	precondition "github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+// ControlPlaneLabel labels the control plane nodes, and selects them in the nodeSelector of
+// the MachineConfigPools that contain them.
+const ControlPlaneLabel = "node-role.kubernetes.io/master"
+
+var machineConfigPoolsGVR = schema.GroupVersionResource{Group: "machineconfiguration.openshift.io", Version: "v1", Resource: "machineconfigpools"}
+
+// NodeRollout checks that the MachineConfigPools, and, if a PodDisruptionBudget client is
+// configured, the PodDisruptionBudgets of the cluster allow its nodes to be updated.
+type NodeRollout struct {
+	client     dynamic.Interface
+	kubeClient kubernetes.Interface
+}
+
+// NewNodeRollout returns a new NodeRollout precondition check reading MachineConfigPools with
+// client. If kubeClient is set, PodDisruptionBudgets that would block draining the control
+// plane fail the check too.
+func NewNodeRollout(client dynamic.Interface, kubeClient kubernetes.Interface) *NodeRollout {
+	return &NodeRollout{client: client, kubeClient: kubeClient}
+}
+
+// Run runs the NodeRollout precondition. It passes on clusters without MachineConfigPools, and
+// otherwise returns a precondition.Errors with a failure for each paused pool of control plane
+// nodes, each degraded pool, and each PodDisruptionBudget that blocks draining the control
+// plane.
+func (pf *NodeRollout) Run(ctx context.Context, releaseContext precondition.ReleaseContext, clusterVersion *configv1.ClusterVersion) error {
+	if pf.client == nil {
+		return nil
+	}
+	pools, err := pf.client.Resource(machineConfigPoolsGVR).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return pf.unknownError(err)
+	}
+
+	var errs precondition.Errors
+	for i := range pools.Items {
+		pool := &pools.Items[i]
+		if paused, _, _ := unstructured.NestedBool(pool.Object, "spec", "paused"); paused && controlPlanePool(pool) {
+			errs = append(errs, &precondition.Error{
+				Reason:  "ControlPlanePoolPaused",
+				Message: fmt.Sprintf("MachineConfigPool %s contains control plane nodes and is paused, so they cannot be updated", pool.GetName()),
+				Name:    pf.Name(),
+			})
+		}
+		if degraded, message := degradedCondition(pool); degraded {
+			if len(message) == 0 {
+				message = "no message"
+			}
+			errs = append(errs, &precondition.Error{
+				Reason:  "MachineConfigPoolDegraded",
+				Message: fmt.Sprintf("MachineConfigPool %s is degraded: %s", pool.GetName(), message),
+				Name:    pf.Name(),
+			})
+		}
+	}
+
+	if pf.kubeClient != nil {
+		blocking, err := pf.blockingDisruptionBudgets(ctx)
+		if err != nil {
+			return pf.unknownError(err)
+		}
+		for _, name := range blocking {
+			errs = append(errs, &precondition.Error{
+				Reason:  "PodDisruptionBudgetBlocksDrain",
+				Message: fmt.Sprintf("PodDisruptionBudget %s allows no disruptions and protects pods on control plane nodes, which cannot be drained", name),
+				Name:    pf.Name(),
+			})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Name returns Name for the precondition.
+func (pf *NodeRollout) Name() string { return "NodeRollout" }
+
+func (pf *NodeRollout) unknownError(err error) error {
+	return &precondition.Error{
+		Nested:  err,
+		Reason:  "UnknownError",
+		Message: err.Error(),
+		Name:    pf.Name(),
+	}
+}
+
+// blockingDisruptionBudgets returns the namespaced names of the PodDisruptionBudgets that allow
+// no disruptions and select pods running on control plane nodes.
+func (pf *NodeRollout) blockingDisruptionBudgets(ctx context.Context) ([]string, error) {
+	nodes, err := pf.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: ControlPlaneLabel})
+	if err != nil {
+		return nil, err
+	}
+	controlPlane := make(map[string]struct{}, len(nodes.Items))
+	for _, node := range nodes.Items {
+		controlPlane[node.Name] = struct{}{}
+	}
+	if len(controlPlane) == 0 {
+		return nil, nil
+	}
+
+	budgets, err := pf.kubeClient.PolicyV1beta1().PodDisruptionBudgets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var blocking []string
+	for _, budget := range budgets.Items {
+		if budget.Status.DisruptionsAllowed > 0 || budget.Status.ExpectedPods == 0 || budget.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(budget.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		pods, err := pf.kubeClient.CoreV1().Pods(budget.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			if _, ok := controlPlane[pod.Spec.NodeName]; ok {
+				blocking = append(blocking, budget.Namespace+"/"+budget.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(blocking)
+	return blocking, nil
+}
+
+// controlPlanePool returns true if the pool is the master pool or selects control plane nodes.
+func controlPlanePool(pool *unstructured.Unstructured) bool {
+	if pool.GetName() == "master" {
+		return true
+	}
+	labels, _, _ := unstructured.NestedStringMap(pool.Object, "spec", "nodeSelector", "matchLabels")
+	_, ok := labels[ControlPlaneLabel]
+	return ok
+}
+
+// degradedCondition returns true and the message of the pool's Degraded condition if it is
+// True.
+func degradedCondition(pool *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(pool.Object, "status", "conditions")
+	for _, value := range conditions {
+		condition, ok := value.(map[string]interface{})
+		if !ok || condition["type"] != "Degraded" || condition["status"] != "True" {
+			continue
+		}
+		message, _ := condition["message"].(string)
+		return true, strings.TrimSpace(message)
+	}
+	return false, ""
+}
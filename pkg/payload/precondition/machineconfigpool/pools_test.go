@@ -0,0 +1,121 @@
+package machineconfigpool
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+func newPool(name string, paused bool, labels map[string]interface{}, conditions ...interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "machineconfiguration.openshift.io/v1",
+		"kind":       "MachineConfigPool",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"paused":       paused,
+			"nodeSelector": map[string]interface{}{"matchLabels": labels},
+		},
+		"status": map[string]interface{}{"conditions": conditions},
+	}}
+}
+
+func TestNodeRolloutRun(t *testing.T) {
+	degraded := map[string]interface{}{"type": "Degraded", "status": "True", "message": "Node worker-1 is reporting: unexpected on-disk state"}
+	notDegraded := map[string]interface{}{"type": "Degraded", "status": "False"}
+	tests := []struct {
+		name     string
+		pools    []runtime.Object
+		kube     []runtime.Object
+		expected []string
+	}{
+		{
+			name: "no pools",
+		},
+		{
+			name: "healthy",
+			pools: []runtime.Object{
+				newPool("master", false, map[string]interface{}{"node-role.kubernetes.io/master": ""}, notDegraded),
+				newPool("worker", true, map[string]interface{}{"node-role.kubernetes.io/worker": ""}, notDegraded),
+			},
+		},
+		{
+			name: "paused control plane and degraded pools",
+			pools: []runtime.Object{
+				newPool("infra", true, map[string]interface{}{"node-role.kubernetes.io/master": ""}),
+				newPool("master", true, nil, notDegraded),
+				newPool("worker", false, nil, degraded),
+			},
+			expected: []string{
+				"ControlPlanePoolPaused: MachineConfigPool infra contains control plane nodes and is paused, so they cannot be updated",
+				"ControlPlanePoolPaused: MachineConfigPool master contains control plane nodes and is paused, so they cannot be updated",
+				"MachineConfigPoolDegraded: MachineConfigPool worker is degraded: Node worker-1 is reporting: unexpected on-disk state",
+			},
+		},
+		{
+			name: "disruption budgets",
+			kube: []runtime.Object{
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "master-0", Labels: map[string]string{"node-role.kubernetes.io/master": ""}}},
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}},
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "control", Labels: map[string]string{"app": "control"}}, Spec: corev1.PodSpec{NodeName: "master-0"}},
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "worker", Labels: map[string]string{"app": "worker"}}, Spec: corev1.PodSpec{NodeName: "worker-0"}},
+				newBudget("control", "control", 0),
+				newBudget("worker", "worker", 0),
+				newBudget("allowed", "control", 1),
+			},
+			expected: []string{
+				"PodDisruptionBudgetBlocksDrain: PodDisruptionBudget app/control allows no disruptions and protects pods on control plane nodes, which cannot be drained",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{machineConfigPoolsGVR: "MachineConfigPoolList"}, tc.pools...)
+			instance := NewNodeRollout(client, nil)
+			if tc.kube != nil {
+				instance = NewNodeRollout(client, kfake.NewSimpleClientset(tc.kube...))
+			}
+
+			err := instance.Run(context.TODO(), precondition.ReleaseContext{DesiredVersion: "4.8.2"}, nil)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var errs precondition.Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected precondition errors, got %v", err)
+			}
+			var actual []string
+			for _, err := range errs {
+				if err.Name != "NodeRollout" {
+					t.Errorf("unexpected name %q", err.Name)
+				}
+				actual = append(actual, err.Reason+": "+err.Message)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Fatalf("unexpected failures:\n%v\nexpected:\n%v", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func newBudget(name, app string, allowed int32) *policyv1beta1.PodDisruptionBudget {
+	return &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: name},
+		Spec:       policyv1beta1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}},
+		Status:     policyv1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed, ExpectedPods: 1},
+	}
+}
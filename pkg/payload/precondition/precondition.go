@@ -2,21 +2,54 @@ package precondition
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/cluster-version-operator/pkg/payload"
 )
 
+var metricPreconditionResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cluster_version_precondition_results_total",
+	Help: "Reports the number of precondition results by precondition name and reason, which is Passed, Waived, or the reason the precondition failed.",
+}, []string{"name", "reason"})
+
+func init() {
+	prometheus.MustRegister(metricPreconditionResults)
+}
+
+// Severity is how a precondition failure affects the update.
+type Severity string
+
+const (
+	// SeverityBlocking failures stop the update unless it is forced. Failures without a
+	// severity are blocking.
+	SeverityBlocking Severity = "Blocking"
+	// SeverityWarning failures do not stop the update, and are reported on the ClusterVersion.
+	SeverityWarning Severity = "Warning"
+	// SeverityInfo failures do not stop the update, and are only logged and reported as events.
+	SeverityInfo Severity = "Info"
+)
+
 // Error is a wrapper for errors that occur during a precondition check for payload.
 type Error struct {
 	Nested  error
 	Reason  string
 	Message string
 	Name    string
+
+	// Severity is how the failure affects the update. It defaults to SeverityBlocking.
+	Severity Severity
+
+	// Unforceable failures block the update even when it is forced. They may still be
+	// waived.
+	Unforceable bool
 }
 
 // Error returns the message
@@ -29,6 +62,117 @@ func (e *Error) Cause() error {
 	return e.Nested
 }
 
+// Unwrap returns the nested error so that errors.Is and errors.As can inspect
+// the chain.
+func (e *Error) Unwrap() error {
+	return e.Nested
+}
+
+// Blocking returns true if the failure stops the update.
+func (e *Error) Blocking() bool {
+	return len(e.Severity) == 0 || e.Severity == SeverityBlocking
+}
+
+// Is returns true if target is an *Error with the same non-empty Reason. If
+// the target also sets Name, the names must match as well.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || len(t.Reason) == 0 || t.Reason != e.Reason {
+		return false
+	}
+	return len(t.Name) == 0 || t.Name == e.Name
+}
+
+// Errors is returned by a precondition check that fails for several independent reasons, such
+// as several unacknowledged upgrade gates. RunAll returns each separately, so that each is
+// reported on its own.
+type Errors []*Error
+
+// Error returns the messages of each failure.
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Waiver is returned by a precondition check that failed, but whose failure an administrator
+// has waived. A waived failure does not block the update, and is reported so that the waiver
+// can be audited.
+type Waiver struct {
+	// Name is the name of the precondition.
+	Name string
+	// RequestedBy identifies who or what asked for the waiver.
+	RequestedBy string
+	// Reason is the justification given for the waiver, if any.
+	Reason string
+	// Expires is when the waiver stops applying.
+	Expires time.Time
+	// Bypassed describes the conditions the waiver allowed the update past.
+	Bypassed []string
+	// Failure is the error the precondition would otherwise have returned.
+	Failure error
+}
+
+// Error returns the message
+func (w *Waiver) Error() string {
+	return fmt.Sprintf("Precondition %q failure waived by %s until %s: %v", w.Name, w.RequestedBy, w.Expires.UTC().Format(time.RFC3339), w.Failure)
+}
+
+// Unwrap returns the waived failure.
+func (w *Waiver) Unwrap() error {
+	return w.Failure
+}
+
+// SplitWaivers separates the waived failures in errs, as returned by Results.Errors, from the failures
+// that block the update.
+func SplitWaivers(errs []error) ([]error, []*Waiver) {
+	var failures []error
+	var waivers []*Waiver
+	for _, err := range errs {
+		var waiver *Waiver
+		if errors.As(err, &waiver) {
+			waivers = append(waivers, waiver)
+			continue
+		}
+		failures = append(failures, err)
+	}
+	return failures, waivers
+}
+
+// SplitSeverity separates the failures in errs, as returned by Results.Errors, that do not block the
+// update from those that do.
+func SplitSeverity(errs []error) ([]error, []*Error) {
+	var blocking []error
+	var advisories []*Error
+	for _, err := range errs {
+		var pferr *Error
+		if errors.As(err, &pferr) && !pferr.Blocking() {
+			advisories = append(advisories, pferr)
+			continue
+		}
+		blocking = append(blocking, err)
+	}
+	return blocking, advisories
+}
+
+// Forceable returns true unless one of the failures in errs, as returned by Results.Errors, blocks
+// the update even when it is forced. Waived failures are forceable.
+func Forceable(errs []error) bool {
+	for _, err := range errs {
+		var waiver *Waiver
+		if errors.As(err, &waiver) {
+			continue
+		}
+		var pferr *Error
+		if errors.As(err, &pferr) && pferr.Unforceable {
+			return false
+		}
+	}
+	return true
+}
+
 // ReleaseContext holds information about the update being considered
 type ReleaseContext struct {
 	// DesiredVersion is the version of the payload being considered.
@@ -37,6 +181,10 @@ type ReleaseContext struct {
 	// where the author decided to use a different naming scheme, or
 	// to leave the version completely unset.
 	DesiredVersion string
+
+	// DesiredImage is the pullspec of the payload being considered,
+	// which is only verifiable when it references the image by digest.
+	DesiredImage string
 }
 
 // Precondition defines the precondition check for a payload.
@@ -48,43 +196,230 @@ type Precondition interface {
 	Name() string
 }
 
+// SeverityProvider is implemented by preconditions whose failures do not block the update.
+type SeverityProvider interface {
+	// Severity returns the severity of the precondition's failures.
+	Severity() Severity
+}
+
+type withSeverity struct {
+	Precondition
+	severity Severity
+}
+
+func (p *withSeverity) Severity() Severity {
+	return p.severity
+}
+
+// WithSeverity registers a precondition whose failures have the given severity, so softer
+// checks can be added to a List without forcing administrators to force updates past them.
+func WithSeverity(p Precondition, severity Severity) Precondition {
+	return &withSeverity{Precondition: p, severity: severity}
+}
+
 // List is a list of precondition checks.
 type List []Precondition
 
-// RunAll runs all the reflight checks in order, returning a list of errors if any.
-// All checks are run, regardless if any one precondition fails.
-func (pfList List) RunAll(ctx context.Context, releaseContext ReleaseContext, cv *configv1.ClusterVersion) []error {
+// Result is the outcome of a precondition check, or of one of its failures for checks that
+// fail for several independent reasons.
+type Result struct {
+	// Name is the name of the precondition.
+	Name string
+	// Reason is Passed, Waived, or the reason the precondition failed.
+	Reason  string
+	Message string
+	// Severity is how a failure affects the update.
+	Severity Severity
+	// LastProbeTime is when the precondition was checked.
+	LastProbeTime time.Time
+	// Since is when the precondition began reporting Reason, as carried over by
+	// CarrySince, or LastProbeTime.
+	Since time.Time
+	// Err is nil if the precondition passed, and otherwise the error it failed with, which
+	// is a *Waiver for waived failures.
+	Err error
+}
+
+// Blocking returns true if the result stops the update.
+func (r Result) Blocking() bool {
+	if r.Err == nil {
+		return false
+	}
+	var waiver *Waiver
+	if errors.As(r.Err, &waiver) {
+		return false
+	}
+	return len(r.Severity) == 0 || r.Severity == SeverityBlocking
+}
+
+// Results are the results of running a List.
+type Results []Result
+
+// Errors returns the errors of the preconditions that did not pass, in the form accepted by
+// SplitWaivers, SplitSeverity, Passed and Summarize.
+func (r Results) Errors() []error {
 	var errs []error
-	for _, pf := range pfList {
-		if err := pf.Run(ctx, releaseContext, cv); err != nil {
-			klog.Errorf("Precondition %q failed: %v", pf.Name(), err)
-			errs = append(errs, err)
+	for _, result := range r {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
 		}
 	}
 	return errs
 }
 
-// Summarize summarizes all the precondition.Error from errs.
+// CarrySince sets the Since of each result to that of the result in previous with the same
+// name and reason, so that a precondition failing for the same reason across several runs
+// reports when it began failing.
+func (r Results) CarrySince(previous Results) {
+	for i := range r {
+		for _, prior := range previous {
+			if prior.Name == r[i].Name && prior.Reason == r[i].Reason && !prior.Since.IsZero() && prior.Since.Before(r[i].Since) {
+				r[i].Since = prior.Since
+			}
+		}
+	}
+}
+
+// RunAll runs all the reflight checks in order, returning the result of each. A check that
+// fails with Errors has a result for each of them. All checks are run, regardless if any one
+// precondition fails. Waived failures have a *Waiver as their Err, see SplitWaivers. Failures
+// of preconditions that declare a severity have an *Error with that severity, see
+// SplitSeverity.
+func (pfList List) RunAll(ctx context.Context, releaseContext ReleaseContext, cv *configv1.ClusterVersion) Results {
+	var results Results
+	for _, pf := range pfList {
+		err := pf.Run(ctx, releaseContext, cv)
+		now := time.Now()
+		if err == nil {
+			metricPreconditionResults.WithLabelValues(pf.Name(), "Passed").Inc()
+			severity := SeverityBlocking
+			if p, ok := pf.(SeverityProvider); ok {
+				severity = p.Severity()
+			}
+			results = append(results, Result{Name: pf.Name(), Reason: "Passed", Severity: severity, LastProbeTime: now, Since: now})
+			continue
+		}
+		var waiver *Waiver
+		if errors.As(err, &waiver) {
+			klog.Warning(err)
+			metricPreconditionResults.WithLabelValues(pf.Name(), "Waived").Inc()
+			results = append(results, Result{Name: pf.Name(), Reason: "Waived", Message: err.Error(), LastProbeTime: now, Since: now, Err: err})
+			continue
+		}
+		if multiple, ok := err.(Errors); ok {
+			for _, err := range multiple {
+				results = append(results, runFailure(pf, err, now))
+			}
+			continue
+		}
+		results = append(results, runFailure(pf, err, now))
+	}
+	return results
+}
+
+// runFailure logs and counts the failure of pf, checked at now, and returns its result with
+// the precondition's severity.
+func runFailure(pf Precondition, err error, now time.Time) Result {
+	if p, ok := pf.(SeverityProvider); ok {
+		err = withFailureSeverity(pf.Name(), err, p.Severity())
+	}
+	result := Result{Name: pf.Name(), Reason: "Unknown", Message: err.Error(), Severity: SeverityBlocking, LastProbeTime: now, Since: now, Err: err}
+	var pferr *Error
+	if errors.As(err, &pferr) {
+		if len(pferr.Reason) > 0 {
+			result.Reason = pferr.Reason
+		}
+		if len(pferr.Severity) > 0 {
+			result.Severity = pferr.Severity
+		}
+	}
+	metricPreconditionResults.WithLabelValues(pf.Name(), result.Reason).Inc()
+	switch {
+	case !errors.As(err, &pferr) || pferr.Blocking():
+		klog.Errorf("Precondition %q failed: %v", pf.Name(), err)
+	case pferr.Severity == SeverityWarning:
+		klog.Warningf("Precondition %q failed with severity %s: %v", pf.Name(), pferr.Severity, err)
+	default:
+		klog.Infof("Precondition %q failed with severity %s: %v", pf.Name(), pferr.Severity, err)
+	}
+	return result
+}
+
+// Passed returns how many of the preconditions passed, given the errors of the Results RunAll
+// returned for them.
+func (pfList List) Passed(errs []error) int {
+	failed := map[string]struct{}{}
+	unnamed := 0
+	for _, err := range errs {
+		var waiver *Waiver
+		var pferr *Error
+		switch {
+		case errors.As(err, &waiver):
+			failed[waiver.Name] = struct{}{}
+		case errors.As(err, &pferr) && len(pferr.Name) > 0:
+			failed[pferr.Name] = struct{}{}
+		default:
+			unnamed++
+		}
+	}
+	passed := -unnamed
+	for _, pf := range pfList {
+		if _, ok := failed[pf.Name()]; !ok {
+			passed++
+		}
+	}
+	if passed < 0 {
+		return 0
+	}
+	return passed
+}
+
+// withFailureSeverity returns err as an *Error with the given severity, unless the
+// precondition already set one.
+func withFailureSeverity(name string, err error, severity Severity) error {
+	if pferr, ok := err.(*Error); ok {
+		if len(pferr.Severity) > 0 {
+			return err
+		}
+		copied := *pferr
+		copied.Severity = severity
+		return &copied
+	}
+	return &Error{
+		Nested:   err,
+		Reason:   "PreconditionFailed",
+		Message:  err.Error(),
+		Name:     name,
+		Severity: severity,
+	}
+}
+
+// Summarize summarizes all the precondition.Error from errs that block the update.
 func Summarize(errs []error) error {
+	errs, _ = SplitSeverity(errs)
 	if len(errs) == 0 {
 		return nil
 	}
 	var msgs []string
 	for _, e := range errs {
-		if pferr, ok := e.(*Error); ok {
+		var pferr *Error
+		if errors.As(e, &pferr) {
 			msgs = append(msgs, fmt.Sprintf("Precondition %q failed because of %q: %v", pferr.Name, pferr.Reason, pferr.Error()))
 			continue
 		}
 		msgs = append(msgs, e.Error())
 	}
 	msg := ""
+	var nested error
 	if len(msgs) == 1 {
 		msg = msgs[0]
+		nested = errs[0]
 	} else {
 		msg = fmt.Sprintf("Multiple precondition checks failed:\n* %s", strings.Join(msgs, "\n* "))
+		nested = utilerrors.NewAggregate(errs)
 	}
 	return &payload.UpdateError{
-		Nested:  nil,
+		Nested:  nested,
 		Reason:  "UpgradePreconditionCheckFailed",
 		Message: msg,
 		Name:    "PreconditionCheck",
@@ -1,8 +1,17 @@
 package precondition
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
 )
 
 func TestSummarize(t *testing.T) {
@@ -74,3 +83,195 @@ func TestSummarize(t *testing.T) {
 		})
 	}
 }
+
+func TestSummarizeUnwrap(t *testing.T) {
+	featureGate := &Error{
+		Reason:  "NotAllowedFeatureGateSet",
+		Message: "Feature Gate random is set for the cluster.",
+		Name:    "FeatureGate",
+	}
+	other := fmt.Errorf("random error")
+
+	for _, input := range [][]error{{featureGate}, {other, featureGate}} {
+		err := Summarize(input)
+		if !errors.Is(err, payload.ErrUpgradePreconditionCheckFailed) {
+			t.Errorf("expected %v to match the precondition check failed sentinel", err)
+		}
+		if !errors.Is(err, &Error{Reason: "NotAllowedFeatureGateSet"}) {
+			t.Errorf("expected %v to match the feature gate reason", err)
+		}
+		if !errors.Is(err, &Error{Reason: "NotAllowedFeatureGateSet", Name: "FeatureGate"}) {
+			t.Errorf("expected %v to match the feature gate reason and name", err)
+		}
+		if errors.Is(err, &Error{Reason: "NotAllowedFeatureGateSet", Name: "Other"}) {
+			t.Errorf("expected %v not to match a different precondition name", err)
+		}
+	}
+
+	var pfErr *Error
+	if err := Summarize([]error{featureGate}); !errors.As(err, &pfErr) || pfErr != featureGate {
+		t.Errorf("expected errors.As to return the precondition error, got %v", pfErr)
+	}
+}
+
+func TestSplitWaivers(t *testing.T) {
+	failure := &Error{Reason: "NotAllowedFeatureGateSet", Message: "Feature Gate random is set for the cluster.", Name: "FeatureGate"}
+	waiver := &Waiver{Name: "ClusterVersionUpgradeable", RequestedBy: "admin", Failure: &Error{Reason: "ClusterOperatorsNotUpgradeable", Name: "ClusterVersionUpgradeable"}}
+
+	failures, waivers := SplitWaivers([]error{waiver, failure})
+	if len(failures) != 1 || failures[0] != failure || len(waivers) != 1 || waivers[0] != waiver {
+		t.Fatalf("unexpected split %v %v", failures, waivers)
+	}
+	wrapped := fmt.Errorf("wrapped: %w", waiver)
+	if failures, waivers := SplitWaivers([]error{wrapped}); len(failures) != 0 || len(waivers) != 1 || waivers[0] != waiver {
+		t.Fatalf("unexpected split of a wrapped waiver %v %v", failures, waivers)
+	}
+	if !Forceable([]error{wrapped}) {
+		t.Errorf("expected wrapped waivers to be forceable")
+	}
+	if !errors.Is(waiver, &Error{Reason: "ClusterOperatorsNotUpgradeable"}) {
+		t.Errorf("expected %v to unwrap to the waived failure", waiver)
+	}
+	if err := Summarize(failures); err.Error() != `Precondition "FeatureGate" failed because of "NotAllowedFeatureGateSet": Feature Gate random is set for the cluster.` {
+		t.Errorf("unexpected summary %v", err)
+	}
+}
+
+type failingPrecondition struct {
+	name string
+	err  error
+}
+
+func (p *failingPrecondition) Run(ctx context.Context, releaseContext ReleaseContext, cv *configv1.ClusterVersion) error {
+	return p.err
+}
+
+func (p *failingPrecondition) Name() string {
+	return p.name
+}
+
+func TestRunAllSeverity(t *testing.T) {
+	blocking := &Error{Reason: "NotAllowedFeatureGateSet", Message: "Feature Gate random is set for the cluster.", Name: "FeatureGate"}
+	list := List{
+		&failingPrecondition{name: "FeatureGate", err: blocking},
+		WithSeverity(&failingPrecondition{name: "EtcdBackup", err: errors.New("the most recent etcd backup is 30h old")}, SeverityWarning),
+		WithSeverity(&failingPrecondition{name: "Notice", err: &Error{Reason: "Informational", Message: "something to know", Name: "Notice"}}, SeverityInfo),
+		WithSeverity(&failingPrecondition{name: "Passing"}, SeverityWarning),
+	}
+
+	errs := list.RunAll(context.Background(), ReleaseContext{}, nil).Errors()
+	failures, advisories := SplitSeverity(errs)
+	if len(failures) != 1 || failures[0] != blocking {
+		t.Fatalf("unexpected blocking failures %v", failures)
+	}
+	if len(advisories) != 2 {
+		t.Fatalf("unexpected advisories %v", advisories)
+	}
+	if a := advisories[0]; a.Name != "EtcdBackup" || a.Reason != "PreconditionFailed" || a.Severity != SeverityWarning || a.Message != "the most recent etcd backup is 30h old" {
+		t.Errorf("unexpected warning %#v", a)
+	}
+	if a := advisories[1]; a.Name != "Notice" || a.Reason != "Informational" || a.Severity != SeverityInfo {
+		t.Errorf("unexpected info %#v", a)
+	}
+
+	if err := Summarize(errs); err.Error() != `Precondition "FeatureGate" failed because of "NotAllowedFeatureGateSet": Feature Gate random is set for the cluster.` {
+		t.Errorf("unexpected summary %v", err)
+	}
+	if err := Summarize(errs[1:]); err != nil {
+		t.Errorf("expected failures that do not block to be left out of the summary, got %v", err)
+	}
+}
+
+func TestRunAllErrors(t *testing.T) {
+	list := List{
+		&failingPrecondition{name: "UpgradeGates", err: Errors{
+			{Reason: "UpgradeGateNotAcknowledged", Message: "Upgrade gate \"backup-verified\" has not been acknowledged for 4.8.2", Name: "UpgradeGates"},
+			{Reason: "UpgradeGateNotAcknowledged", Message: "Upgrade gate \"maintenance-window\" has not been acknowledged for 4.8.2", Name: "UpgradeGates"},
+		}},
+		&failingPrecondition{name: "Passing"},
+	}
+
+	errs := list.RunAll(context.Background(), ReleaseContext{}, nil).Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected each failure to be returned separately, got %v", errs)
+	}
+	if passed := list.Passed(errs); passed != 1 {
+		t.Errorf("expected 1 precondition to pass, got %d", passed)
+	}
+	expected := `Multiple precondition checks failed:
+* Precondition "UpgradeGates" failed because of "UpgradeGateNotAcknowledged": Upgrade gate "backup-verified" has not been acknowledged for 4.8.2
+* Precondition "UpgradeGates" failed because of "UpgradeGateNotAcknowledged": Upgrade gate "maintenance-window" has not been acknowledged for 4.8.2`
+	if err := Summarize(errs); err == nil || err.Error() != expected {
+		t.Errorf("unexpected summary %v", err)
+	}
+}
+
+func TestRunAllResults(t *testing.T) {
+	list := List{
+		&failingPrecondition{name: "FeatureGate", err: &Error{Reason: "NotAllowedFeatureGateSet", Message: "Feature Gate random is set for the cluster.", Name: "FeatureGate"}},
+		WithSeverity(&failingPrecondition{name: "EtcdBackup", err: errors.New("the most recent etcd backup is 30h old")}, SeverityWarning),
+		&failingPrecondition{name: "Waived", err: &Waiver{Name: "Waived"}},
+		&failingPrecondition{name: "Passing"},
+	}
+
+	results := list.RunAll(context.Background(), ReleaseContext{}, nil)
+	var summary []string
+	for _, result := range results {
+		if result.LastProbeTime.IsZero() || !result.Since.Equal(result.LastProbeTime) {
+			t.Errorf("unexpected times for %s: %#v", result.Name, result)
+		}
+		summary = append(summary, fmt.Sprintf("%s %s %s %t", result.Name, result.Reason, result.Severity, result.Blocking()))
+	}
+	expected := []string{
+		"FeatureGate NotAllowedFeatureGateSet Blocking true",
+		"EtcdBackup PreconditionFailed Warning false",
+		"Waived Waived  false",
+		"Passing Passed Blocking false",
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("unexpected results %v, expected %v", summary, expected)
+	}
+	if errs := results.Errors(); len(errs) != 3 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	// a precondition failing for the same reason keeps reporting when it began failing
+	since := time.Unix(0, 0)
+	previous := Results{{Name: "FeatureGate", Reason: "NotAllowedFeatureGateSet", Since: since}, {Name: "Passing", Reason: "Waived", Since: since}}
+	results.CarrySince(previous)
+	if !results[0].Since.Equal(since) || results[3].Since.Equal(since) {
+		t.Fatalf("unexpected carried times %v and %v", results[0].Since, results[3].Since)
+	}
+}
+
+func TestRunAllMetrics(t *testing.T) {
+	list := List{
+		&failingPrecondition{name: "MetricsGates", err: Errors{
+			{Reason: "UpgradeGateNotAcknowledged", Name: "MetricsGates"},
+			{Reason: "UpgradeGateNotAcknowledged", Name: "MetricsGates"},
+		}},
+		&failingPrecondition{name: "MetricsWaived", err: &Waiver{Name: "MetricsWaived"}},
+		&failingPrecondition{name: "MetricsUnknown", err: errors.New("unexpected failure")},
+		&failingPrecondition{name: "MetricsPassing"},
+	}
+	list.RunAll(context.Background(), ReleaseContext{}, nil)
+
+	for _, tt := range []struct {
+		name   string
+		reason string
+		want   float64
+	}{
+		{name: "MetricsGates", reason: "UpgradeGateNotAcknowledged", want: 2},
+		{name: "MetricsWaived", reason: "Waived", want: 1},
+		{name: "MetricsUnknown", reason: "Unknown", want: 1},
+		{name: "MetricsPassing", reason: "Passed", want: 1},
+	} {
+		m := &dto.Metric{}
+		if err := metricPreconditionResults.WithLabelValues(tt.name, tt.reason).Write(m); err != nil {
+			t.Fatal(err)
+		}
+		if value := m.GetCounter().GetValue(); value != tt.want {
+			t.Errorf("unexpected %s %s count %v, want %v", tt.name, tt.reason, value, tt.want)
+		}
+	}
+}
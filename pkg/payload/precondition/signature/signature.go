@@ -0,0 +1,344 @@
+// Package signature contains the precondition that the release image being updated to is
+// signed by a trusted key, either in one of the signature stores or in a bundle of signatures
+// and keys that administrators of disconnected clusters provide in a ConfigMap.
+package signature
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	"github.com/openshift/library-go/pkg/verify"
+	"github.com/openshift/library-go/pkg/verify/store"
+	"github.com/openshift/library-go/pkg/verify/store/sigstore"
+	"github.com/openshift/library-go/pkg/verify/util"
+	"golang.org/x/crypto/openpgp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/internal"
+	precondition "github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+const (
+	// PublicKeyPrefix is the prefix of the keys in the data of the bundle ConfigMap, and of the
+	// payload's verification ConfigMap, that hold a trusted GPG public key.
+	PublicKeyPrefix = "verifier-public-key-"
+
+	// OfflineKey in the data of the bundle ConfigMap, when "true", restricts the precondition
+	// to the signatures in the bundle, without consulting the signature stores.
+	OfflineKey = "offline"
+
+	storePrefix = "store-"
+)
+
+// The reasons the ReleaseSignature precondition fails with.
+const (
+	// ReasonUnsigned is reported when no signature could be found for the release. It may be
+	// forced, for releases that were never signed.
+	ReasonUnsigned = "ReleaseUnsigned"
+	// ReasonUntrustedKey is reported when the release has signatures, but none made by a
+	// trusted key. It may not be forced; the key must be trusted in the bundle instead.
+	ReasonUntrustedKey = "ReleaseSignatureUntrusted"
+	// ReasonStoreUnreachable is reported when no trusted signature was found, but some
+	// signature stores could not be read. It may be forced.
+	ReasonStoreUnreachable = "SignatureStoreUnreachable"
+)
+
+// StoresFromManifests returns the signature stores listed by the store-* keys of the payload's
+// verification ConfigMap, as described by verify.NewFromManifests. Stores that are not read
+// over HTTP or HTTPS are ignored.
+func StoresFromManifests(manifests []manifest.Manifest, clientBuilder sigstore.HTTPClient) []store.Store {
+	var stores []store.Store
+	for _, m := range manifests {
+		configMap, err := util.ReadConfigMap(m.Raw)
+		if err != nil || configMap == nil {
+			continue
+		}
+		if _, ok := configMap.Annotations[verify.ReleaseAnnotationConfigMapVerifier]; !ok {
+			continue
+		}
+		data, _, _ := unstructured.NestedStringMap(m.Obj.Object, "data")
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			if strings.HasPrefix(key, storePrefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			u, err := url.Parse(strings.TrimSpace(data[key]))
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				klog.V(4).Infof("Release signature precondition ignores the store %s in %s/%s", key, configMap.Namespace, configMap.Name)
+				continue
+			}
+			stores = append(stores, &sigstore.Store{URI: u, HTTPClient: clientBuilder})
+		}
+		break
+	}
+	return stores
+}
+
+// ReleaseSignature checks that the desired release image is signed by a trusted key. The
+// trusted keys are those of the payload's verifier and those in the bundle ConfigMap.
+type ReleaseSignature struct {
+	keys   map[string]openpgp.EntityList
+	stores []store.Store
+	lister corev1listers.ConfigMapNamespaceLister
+}
+
+// NewReleaseSignature returns a new ReleaseSignature precondition check trusting keys, searching
+// stores, and reading the bundle ConfigMap from lister.
+func NewReleaseSignature(keys map[string]openpgp.EntityList, stores []store.Store, lister corev1listers.ConfigMapNamespaceLister) *ReleaseSignature {
+	return &ReleaseSignature{keys: keys, stores: stores, lister: lister}
+}
+
+// Run runs the ReleaseSignature precondition. It passes if a signature of the desired image's
+// digest, in the bundle or in one of the stores, was made by a trusted key. Otherwise it fails
+// with ReasonUntrustedKey if signatures were found, ReasonStoreUnreachable if a store could not
+// be read, and ReasonUnsigned if there are no signatures.
+func (pf *ReleaseSignature) Run(ctx context.Context, releaseContext precondition.ReleaseContext, clusterVersion *configv1.ClusterVersion) error {
+	var digest string
+	if index := strings.LastIndex(releaseContext.DesiredImage, "@"); index != -1 {
+		digest = releaseContext.DesiredImage[index+1:]
+	}
+	if len(digest) == 0 {
+		return &precondition.Error{
+			Reason:  ReasonUnsigned,
+			Message: fmt.Sprintf("The release image %q is not referenced by digest, so its signatures cannot be verified", releaseContext.DesiredImage),
+			Name:    pf.Name(),
+		}
+	}
+
+	keys := make(map[string]openpgp.EntityList, len(pf.keys))
+	for name, keyring := range pf.keys {
+		keys[name] = keyring
+	}
+	stores := pf.stores
+	if pf.lister != nil {
+		cm, err := pf.lister.Get(internal.ReleaseSignatureBundleConfigMap)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return &precondition.Error{
+				Nested:  err,
+				Reason:  "UnknownError",
+				Message: err.Error(),
+				Name:    pf.Name(),
+			}
+		}
+		if err == nil {
+			b, err := newBundle(cm.Data, cm.BinaryData)
+			if err != nil {
+				return &precondition.Error{
+					Nested:  err,
+					Reason:  "InvalidSignatureBundle",
+					Message: fmt.Sprintf("The %s/%s ConfigMap is invalid: %v", internal.ConfigNamespace, internal.ReleaseSignatureBundleConfigMap, err),
+					Name:    pf.Name(),
+				}
+			}
+			for name, keyring := range b.keys {
+				keys[name] = keyring
+			}
+			if b.offline {
+				stores = nil
+			}
+			stores = append([]store.Store{b}, stores...)
+		}
+	}
+
+	s := &search{digest: digest, keys: keys}
+	for _, source := range stores {
+		if s.trustedBy != "" {
+			break
+		}
+		if err := source.Signatures(ctx, "", digest, s.callback(source)); err != nil {
+			s.unreachable = append(s.unreachable, fmt.Sprintf("%s: %v", source, err))
+		}
+	}
+	if len(s.trustedBy) > 0 {
+		klog.V(4).Infof("Release image %s is signed by %s", releaseContext.DesiredImage, s.trustedBy)
+		return nil
+	}
+
+	switch {
+	case len(s.untrusted) > 0:
+		message := fmt.Sprintf("None of the %d signatures found for the release image %s were made by a trusted key", len(s.untrusted), releaseContext.DesiredImage)
+		if len(keys) == 0 {
+			message = fmt.Sprintf("%d signatures were found for the release image %s, but no keys are trusted", len(s.untrusted), releaseContext.DesiredImage)
+		}
+		return &precondition.Error{
+			Reason:      ReasonUntrustedKey,
+			Message:     fmt.Sprintf("%s: %s. This cannot be forced; trust the signing key in the %s/%s ConfigMap", message, strings.Join(s.untrusted, "; "), internal.ConfigNamespace, internal.ReleaseSignatureBundleConfigMap),
+			Name:        pf.Name(),
+			Unforceable: true,
+		}
+	case len(s.unreachable) > 0:
+		return &precondition.Error{
+			Reason:  ReasonStoreUnreachable,
+			Message: fmt.Sprintf("No signature was found for the release image %s, and %d signature stores could not be read: %s", releaseContext.DesiredImage, len(s.unreachable), strings.Join(s.unreachable, "; ")),
+			Name:    pf.Name(),
+		}
+	default:
+		return &precondition.Error{
+			Reason:  ReasonUnsigned,
+			Message: fmt.Sprintf("No signature was found for the release image %s", releaseContext.DesiredImage),
+			Name:    pf.Name(),
+		}
+	}
+}
+
+// Name returns Name for the precondition.
+func (pf *ReleaseSignature) Name() string { return "ReleaseSignature" }
+
+// search collects the outcome of looking for a trusted signature of digest.
+type search struct {
+	digest string
+	keys   map[string]openpgp.EntityList
+
+	trustedBy   string
+	untrusted   []string
+	unreachable []string
+}
+
+func (s *search) callback(source store.Store) store.Callback {
+	return func(ctx context.Context, signature []byte, errIn error) (bool, error) {
+		if errIn != nil {
+			s.unreachable = append(s.unreachable, fmt.Sprintf("%s: %v", source, errIn))
+			return false, nil
+		}
+		var failures []string
+		names := make([]string, 0, len(s.keys))
+		for name := range s.keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			err := verifySignature(signature, s.keys[name], s.digest)
+			if err == nil {
+				s.trustedBy = name
+				return true, nil
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		}
+		detail := fmt.Sprintf("signature %d from %s", len(s.untrusted)+1, source)
+		if len(failures) > 0 {
+			detail = fmt.Sprintf("%s (%s)", detail, strings.Join(failures, ", "))
+		}
+		s.untrusted = append(s.untrusted, detail)
+		return false, nil
+	}
+}
+
+// verifySignature returns an error unless data is an atomic container signature of digest
+// signed by a key in keyring.
+func verifySignature(data []byte, keyring openpgp.EntityList, digest string) error {
+	md, err := openpgp.ReadMessage(bytes.NewReader(data), keyring, nil, nil)
+	if err != nil {
+		return fmt.Errorf("could not read the signature: %v", err)
+	}
+	if !md.IsSigned {
+		return fmt.Errorf("not signed")
+	}
+	content, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return err
+	}
+	if md.SignatureError != nil {
+		return fmt.Errorf("signature error: %v", md.SignatureError)
+	}
+	if md.SignedBy == nil {
+		return fmt.Errorf("signed by unknown key %X", md.SignedByKeyId)
+	}
+	if md.Signature != nil && md.Signature.SigLifetimeSecs != nil {
+		expiry := md.Signature.CreationTime.Add(time.Duration(*md.Signature.SigLifetimeSecs) * time.Second)
+		if time.Now().After(expiry) {
+			return fmt.Errorf("signature expired on %s", expiry)
+		}
+	}
+
+	var sig struct {
+		Critical struct {
+			Type  string `json:"type"`
+			Image struct {
+				DockerManifestDigest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+		} `json:"critical"`
+	}
+	if err := json.Unmarshal(content, &sig); err != nil {
+		return fmt.Errorf("the signature is not valid JSON: %v", err)
+	}
+	if sig.Critical.Type != "atomic container signature" {
+		return fmt.Errorf("signature is not the correct type")
+	}
+	if sig.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("signature digest does not match")
+	}
+	return nil
+}
+
+// bundle is a store of the signatures in the bundle ConfigMap, along with the keys it trusts.
+// Signatures are in its binary data under keys named for the digest they sign, as in the
+// signature ConfigMaps in openshift-config-managed, such as sha256-<hex>-1.
+type bundle struct {
+	keys       map[string]openpgp.EntityList
+	signatures map[string][]byte
+	offline    bool
+}
+
+func newBundle(data map[string]string, binaryData map[string][]byte) (*bundle, error) {
+	b := &bundle{keys: map[string]openpgp.EntityList{}, signatures: binaryData}
+	for key, value := range data {
+		switch {
+		case strings.HasPrefix(key, PublicKeyPrefix):
+			keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(value))
+			if err != nil {
+				keyring, err = openpgp.ReadKeyRing(strings.NewReader(value))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a GPG public key: %v", key, err)
+			}
+			b.keys["bundle-"+key] = keyring
+		case key == OfflineKey:
+			b.offline = strings.TrimSpace(value) == "true"
+		default:
+			klog.Warningf("Ignoring %s in %s, which is neither %s nor a %s* key", key, internal.ReleaseSignatureBundleConfigMap, OfflineKey, PublicKeyPrefix)
+		}
+	}
+	return b, nil
+}
+
+// Signatures calls fn with each signature of digest in the bundle.
+func (b *bundle) Signatures(ctx context.Context, name string, digest string, fn store.Callback) error {
+	prefix, err := util.DigestToKeyPrefix(digest, "-")
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(b.signatures))
+	for key := range b.signatures {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		done, err := fn(ctx, b.signatures[key], nil)
+		if done || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns a description of the bundle.
+func (b *bundle) String() string {
+	return fmt.Sprintf("the %s/%s ConfigMap", internal.ConfigNamespace, internal.ReleaseSignatureBundleConfigMap)
+}
@@ -0,0 +1,186 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/verify/store"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+const digest = "sha256:6d9a2c1b5b5b1c9d3b6e64a4f7f3d0e8a52a8e0e0c7d0c6c8b5f0f2a3a4b5c6d"
+
+type testStore struct {
+	signatures [][]byte
+	err        error
+}
+
+func (s *testStore) Signatures(ctx context.Context, name string, digest string, fn store.Callback) error {
+	if s.err != nil {
+		_, err := fn(ctx, nil, s.err)
+		return err
+	}
+	for _, signature := range s.signatures {
+		if done, err := fn(ctx, signature, nil); done || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *testStore) String() string { return "test store" }
+
+func newEntity(t *testing.T, name string) *openpgp.Entity {
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", &packet.Config{RSABits: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// prefer SHA256, since RIPEMD160 is not compiled in
+	for _, identity := range entity.Identities {
+		identity.SelfSignature.PreferredHash = []uint8{8}
+	}
+	return entity
+}
+
+func sign(t *testing.T, entity *openpgp.Entity, digest string) []byte {
+	var buf bytes.Buffer
+	w, err := openpgp.Sign(&buf, entity, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(w, `{"critical":{"type":"atomic container signature","image":{"docker-manifest-digest":%q},"identity":{"docker-reference":"quay.io/openshift/release"}}}`, digest)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func armoredPublicKey(t *testing.T, entity *openpgp.Entity) string {
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestReleaseSignatureRun(t *testing.T) {
+	trusted := newEntity(t, "trusted")
+	untrusted := newEntity(t, "untrusted")
+	offline := newEntity(t, "offline")
+	keys := map[string]openpgp.EntityList{"verifier-public-key-trusted": {trusted}}
+
+	tests := []struct {
+		name        string
+		image       string
+		stores      []store.Store
+		bundle      *corev1.ConfigMap
+		reason      string
+		unforceable bool
+	}{
+		{
+			name:   "signed by a trusted key",
+			image:  "quay.io/openshift/release@" + digest,
+			stores: []store.Store{&testStore{signatures: [][]byte{sign(t, untrusted, digest), sign(t, trusted, digest)}}},
+		},
+		{
+			name:   "not by digest",
+			image:  "quay.io/openshift/release:4.8.2",
+			reason: ReasonUnsigned,
+		},
+		{
+			name:   "unsigned",
+			image:  "quay.io/openshift/release@" + digest,
+			stores: []store.Store{&testStore{}},
+			reason: ReasonUnsigned,
+		},
+		{
+			name:        "untrusted key",
+			image:       "quay.io/openshift/release@" + digest,
+			stores:      []store.Store{&testStore{signatures: [][]byte{sign(t, untrusted, digest)}}, &testStore{err: errors.New("connection refused")}},
+			reason:      ReasonUntrustedKey,
+			unforceable: true,
+		},
+		{
+			name:        "signature of another digest",
+			image:       "quay.io/openshift/release@" + digest,
+			stores:      []store.Store{&testStore{signatures: [][]byte{sign(t, trusted, "sha256:0000")}}},
+			reason:      ReasonUntrustedKey,
+			unforceable: true,
+		},
+		{
+			name:   "store unreachable",
+			image:  "quay.io/openshift/release@" + digest,
+			stores: []store.Store{&testStore{}, &testStore{err: errors.New("connection refused")}},
+			reason: ReasonStoreUnreachable,
+		},
+		{
+			name:   "bundle signature by a bundle key",
+			image:  "quay.io/openshift/release@" + digest,
+			stores: []store.Store{&testStore{err: errors.New("connection refused")}},
+			bundle: &corev1.ConfigMap{
+				Data:       map[string]string{"verifier-public-key-offline": armoredPublicKey(t, offline)},
+				BinaryData: map[string][]byte{"sha256-" + strings.TrimPrefix(digest, "sha256:") + "-1": sign(t, offline, digest)},
+			},
+		},
+		{
+			name:   "offline bundle without a signature",
+			image:  "quay.io/openshift/release@" + digest,
+			stores: []store.Store{&testStore{err: errors.New("connection refused")}},
+			bundle: &corev1.ConfigMap{Data: map[string]string{"offline": "true"}},
+			reason: ReasonUnsigned,
+		},
+		{
+			name:   "invalid bundle",
+			image:  "quay.io/openshift/release@" + digest,
+			bundle: &corev1.ConfigMap{Data: map[string]string{"verifier-public-key-offline": "not a key"}},
+			reason: "InvalidSignatureBundle",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.bundle != nil {
+				tc.bundle.ObjectMeta = metav1.ObjectMeta{Namespace: "openshift-config", Name: "cluster-version-signature-bundle"}
+				indexer.Add(tc.bundle)
+			}
+			instance := NewReleaseSignature(keys, tc.stores, corev1listers.NewConfigMapLister(indexer).ConfigMaps("openshift-config"))
+
+			err := instance.Run(context.TODO(), precondition.ReleaseContext{DesiredVersion: "4.8.2", DesiredImage: tc.image}, nil)
+			if len(tc.reason) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var pferr *precondition.Error
+			if !errors.As(err, &pferr) {
+				t.Fatalf("expected a precondition error, got %v", err)
+			}
+			if pferr.Reason != tc.reason || pferr.Unforceable != tc.unforceable || pferr.Name != "ReleaseSignature" {
+				t.Fatalf("unexpected error %#v", pferr)
+			}
+			if forceable := precondition.Forceable([]error{err}); forceable == tc.unforceable {
+				t.Fatalf("unexpected forceable %t for %v", forceable, err)
+			}
+		})
+	}
+}
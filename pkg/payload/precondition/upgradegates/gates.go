@@ -0,0 +1,124 @@
+// Package upgradegates contains the precondition on gates that cluster administrators declare
+// must be acknowledged before each update.
+package upgradegates
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-version-operator/pkg/internal"
+	precondition "github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+// AcknowledgedSuffix is appended to the name of a gate to form the key that acknowledges it.
+// The value of that key is the version of the update the gate is acknowledged for.
+const AcknowledgedSuffix = ".acknowledged"
+
+// Gate is an administrator's requirement, such as a maintenance window or a verified
+// backup, that must be acknowledged before the cluster updates.
+type Gate struct {
+	// Name identifies the gate, such as "backup-verified".
+	Name string
+	// Description describes what must be done before the gate is acknowledged.
+	Description string
+	// Acknowledged is the version of the update the gate has been acknowledged for, if any.
+	Acknowledged string
+}
+
+// ParseGates reads the gates declared in the data of the upgrade gates ConfigMap. Each key
+// declares a gate, with a description as its value, except the keys ending in
+// AcknowledgedSuffix, which acknowledge the gate they name.
+func ParseGates(data map[string]string) []Gate {
+	gates := map[string]*Gate{}
+	for key, value := range data {
+		if strings.HasSuffix(key, AcknowledgedSuffix) {
+			continue
+		}
+		gates[key] = &Gate{Name: key, Description: strings.TrimSpace(value)}
+	}
+	for key, value := range data {
+		name := strings.TrimSuffix(key, AcknowledgedSuffix)
+		if name == key {
+			continue
+		}
+		gate, ok := gates[name]
+		if !ok {
+			klog.Warningf("Ignoring %s in %s, which acknowledges an undeclared gate %q", key, internal.UpgradeGatesConfigMap, name)
+			continue
+		}
+		gate.Acknowledged = strings.TrimSpace(value)
+	}
+	sorted := make([]Gate, 0, len(gates))
+	for _, gate := range gates {
+		sorted = append(sorted, *gate)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// UpgradeGates checks that every gate in the upgrade gates ConfigMap has been acknowledged for
+// the desired version.
+type UpgradeGates struct {
+	lister corev1listers.ConfigMapNamespaceLister
+}
+
+// NewUpgradeGates returns a new UpgradeGates precondition check reading the upgrade gates
+// ConfigMap from lister.
+func NewUpgradeGates(lister corev1listers.ConfigMapNamespaceLister) *UpgradeGates {
+	return &UpgradeGates{lister: lister}
+}
+
+// Run runs the UpgradeGates precondition. It passes if the ConfigMap does not exist, and
+// otherwise returns a precondition.Errors with a failure for each gate that has not been
+// acknowledged for the desired version.
+func (pf *UpgradeGates) Run(ctx context.Context, releaseContext precondition.ReleaseContext, clusterVersion *configv1.ClusterVersion) error {
+	if pf.lister == nil {
+		return nil
+	}
+	cm, err := pf.lister.Get(internal.UpgradeGatesConfigMap)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return &precondition.Error{
+			Nested:  err,
+			Reason:  "UnknownError",
+			Message: err.Error(),
+			Name:    pf.Name(),
+		}
+	}
+
+	var errs precondition.Errors
+	for _, gate := range ParseGates(cm.Data) {
+		if len(gate.Acknowledged) > 0 && gate.Acknowledged == releaseContext.DesiredVersion {
+			klog.V(4).Infof("Upgrade gate %s is acknowledged for %s.", gate.Name, releaseContext.DesiredVersion)
+			continue
+		}
+		message := fmt.Sprintf("Upgrade gate %q has not been acknowledged for %s", gate.Name, releaseContext.DesiredVersion)
+		if len(gate.Acknowledged) > 0 {
+			message = fmt.Sprintf("Upgrade gate %q is acknowledged for %s, not %s", gate.Name, gate.Acknowledged, releaseContext.DesiredVersion)
+		}
+		if len(gate.Description) > 0 {
+			message = fmt.Sprintf("%s: %s", message, gate.Description)
+		}
+		errs = append(errs, &precondition.Error{
+			Reason:  "UpgradeGateNotAcknowledged",
+			Message: message,
+			Name:    pf.Name(),
+		})
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Name returns Name for the precondition.
+func (pf *UpgradeGates) Name() string { return "UpgradeGates" }
@@ -0,0 +1,80 @@
+package upgradegates
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload/precondition"
+)
+
+func TestUpgradeGatesRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     map[string]string
+		expected []string
+	}{
+		{
+			name: "no gates",
+		},
+		{
+			name: "acknowledged",
+			data: map[string]string{
+				"backup-verified":              "Verify the etcd backup can be restored",
+				"backup-verified.acknowledged": "4.8.2",
+			},
+		},
+		{
+			name: "unacknowledged",
+			data: map[string]string{
+				"backup-verified":                 "Verify the etcd backup can be restored",
+				"maintenance-window":              "",
+				"maintenance-window.acknowledged": "4.8.1",
+				"typo.acknowledged":               "4.8.2",
+			},
+			expected: []string{
+				`Upgrade gate "backup-verified" has not been acknowledged for 4.8.2: Verify the etcd backup can be restored`,
+				`Upgrade gate "maintenance-window" is acknowledged for 4.8.1, not 4.8.2`,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.data != nil {
+				indexer.Add(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-config", Name: "cluster-version-upgrade-gates"},
+					Data:       tc.data,
+				})
+			}
+			instance := NewUpgradeGates(corev1listers.NewConfigMapLister(indexer).ConfigMaps("openshift-config"))
+
+			err := instance.Run(context.TODO(), precondition.ReleaseContext{DesiredVersion: "4.8.2"}, nil)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			errs, ok := err.(precondition.Errors)
+			if !ok {
+				t.Fatalf("expected a failure for each gate, got %v", err)
+			}
+			var messages []string
+			for _, err := range errs {
+				if err.Name != "UpgradeGates" || err.Reason != "UpgradeGateNotAcknowledged" {
+					t.Errorf("unexpected failure %#v", err)
+				}
+				messages = append(messages, err.Message)
+			}
+			if !reflect.DeepEqual(messages, tc.expected) {
+				t.Fatalf("unexpected failures:\n%v\nexpected:\n%v", messages, tc.expected)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package payload
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
 	"sync"
 	"time"
@@ -61,6 +62,20 @@ func COUpdateStartTimesGet(name string) time.Time {
 	return clusterOperatorUpdateStartTimes.m[name]
 }
 
+// LargeManifestSize is the size in bytes of the serialized manifest above which an object
+// is considered large. Large objects, usually CustomResourceDefinitions, are slow to
+// transfer and store on a stressed control plane, so they are given longer request
+// timeouts and back off exponentially between attempts.
+const LargeManifestSize = 128 * 1024
+
+// largeManifestMaxBackoff caps the exponential backoff between attempts to apply a large manifest.
+const largeManifestMaxBackoff = 2 * time.Minute
+
+// IsLargeManifest returns true if the manifest is at least LargeManifestSize bytes.
+func IsLargeManifest(m *manifest.Manifest) bool {
+	return len(m.Raw) >= LargeManifestSize
+}
+
 // ResourceBuilder abstracts how a manifest is created on the server. Introduced for testing.
 type ResourceBuilder interface {
 	Apply(context.Context, *manifest.Manifest, State) error
@@ -101,6 +116,13 @@ func (st *Task) Run(ctx context.Context, version string, builder ResourceBuilder
 	var lastErr error
 	backoff := st.Backoff
 	maxDuration := 15 * time.Second // TODO: fold back into Backoff in 1.13
+	large := IsLargeManifest(st.Manifest)
+	if large {
+		maxDuration = largeManifestMaxBackoff
+		if backoff.Factor < 2 {
+			backoff.Factor = 2
+		}
+	}
 	for {
 		// attempt the apply, waiting as long as necessary
 		err := builder.Apply(ctx, st.Manifest, state)
@@ -117,6 +139,10 @@ func (st *Task) Run(ctx context.Context, version string, builder ResourceBuilder
 		if d > maxDuration {
 			d = maxDuration
 		}
+		if large {
+			// back off exponentially so a slow server is not repeatedly handed the same large request
+			backoff.Duration = d
+		}
 		d = wait.Jitter(d, backoff.Jitter)
 
 		// sleep or wait for cancellation
@@ -124,25 +150,46 @@ func (st *Task) Run(ctx context.Context, version string, builder ResourceBuilder
 		case <-time.After(d):
 			continue
 		case <-ctx.Done():
-			if uerr, ok := lastErr.(*UpdateError); ok {
-				uerr.Task = st.Copy()
-				return uerr
-			}
-			reason, cause := reasonForPayloadSyncError(lastErr)
-			if len(cause) > 0 {
-				cause = ": " + cause
-			}
-			return &UpdateError{
-				Nested:  lastErr,
-				Reason:  reason,
-				Message: fmt.Sprintf("Could not update %s%s", st, cause),
-
-				Task: st.Copy(),
-			}
+			return st.updateError(lastErr, large)
 		}
 	}
 }
 
+// RunOnce attempts to create the provided object a single time, returning an error that
+// describes the failure as Run does.
+func (st *Task) RunOnce(ctx context.Context, version string, builder ResourceBuilder, state State) error {
+	err := builder.Apply(ctx, st.Manifest, state)
+	if err == nil {
+		return nil
+	}
+	utilruntime.HandleError(errors.Wrapf(err, "error running apply for %s", st))
+	metricPayloadErrors.WithLabelValues(version).Inc()
+	return st.updateError(err, IsLargeManifest(st.Manifest))
+}
+
+// updateError describes err, the last error applying the task, for the update status.
+func (st *Task) updateError(err error, large bool) error {
+	var uerr *UpdateError
+	if errors.As(err, &uerr) {
+		uerr.Task = st.Copy()
+		return uerr
+	}
+	reason, cause := reasonForPayloadSyncError(err)
+	if large && isTimeout(err) {
+		reason, cause = "LargeObjectApplyTimeout", fmt.Sprintf("the %d byte object could not be applied before timing out", len(st.Manifest.Raw))
+	}
+	if len(cause) > 0 {
+		cause = ": " + cause
+	}
+	return &UpdateError{
+		Nested:  err,
+		Reason:  reason,
+		Message: fmt.Sprintf("Could not update %s%s", st, cause),
+
+		Task: st.Copy(),
+	}
+}
+
 // UpdateEffectType defines the effect an update error has on the overall update state.
 type UpdateEffectType string
 
@@ -166,9 +213,21 @@ type UpdateError struct {
 	Message      string
 	Name         string
 
+	// History, when set, holds the distinct errors observed while waiting before this
+	// error was returned, oldest first and including this one.
+	History []ErrorObservation
+
 	Task *Task
 }
 
+// ErrorObservation is an error observed at a point in time, such as while waiting for a
+// ClusterOperator to finish updating.
+type ErrorObservation struct {
+	Time    time.Time
+	Reason  string
+	Message string
+}
+
 func (e *UpdateError) Error() string {
 	return e.Message
 }
@@ -177,6 +236,31 @@ func (e *UpdateError) Cause() error {
 	return e.Nested
 }
 
+// Unwrap returns the nested error so that errors.Is and errors.As can inspect
+// the chain.
+func (e *UpdateError) Unwrap() error {
+	return e.Nested
+}
+
+// Is returns true if target is an *UpdateError with the same non-empty Reason.
+// This allows the reason sentinels below to be matched with errors.Is.
+func (e *UpdateError) Is(target error) bool {
+	t, ok := target.(*UpdateError)
+	return ok && len(t.Reason) > 0 && t.Reason == e.Reason
+}
+
+// Sentinels for the update error reasons inspected by the operator. They are
+// intended for use with errors.Is and should never be returned directly.
+var (
+	ErrImageVerificationFailed        = &UpdateError{Reason: "ImageVerificationFailed"}
+	ErrUpgradePreconditionCheckFailed = &UpdateError{Reason: "UpgradePreconditionCheckFailed"}
+	ErrClusterOperatorNotAvailable    = &UpdateError{Reason: "ClusterOperatorNotAvailable"}
+	ErrClusterOperatorsNotAvailable   = &UpdateError{Reason: "ClusterOperatorsNotAvailable"}
+	ErrClusterOperatorDegraded        = &UpdateError{Reason: "ClusterOperatorDegraded"}
+	ErrClusterOperatorStuck           = &UpdateError{Reason: "ClusterOperatorStuck"}
+	ErrLargeObjectApplyTimeout        = &UpdateError{Reason: "LargeObjectApplyTimeout"}
+)
+
 // reasonForUpdateError provides a succint explanation of a known error type for use in a human readable
 // message during update. Since all objects in the image should be successfully applied, messages
 // should direct the reader (likely a cluster administrator) to a possible cause in their own config.
@@ -206,6 +290,19 @@ func reasonForPayloadSyncError(err error) (string, string) {
 	}
 }
 
+// isTimeout returns true if err indicates that a request to the server timed out.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	err = errors.Cause(err)
+	return apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err)
+}
+
 func SummaryForReason(reason, name string) string {
 	switch reason {
 
@@ -220,6 +317,8 @@ func SummaryForReason(reason, name string) string {
 		return "the control plane is overloaded and is not accepting updates"
 	case "UpdatePayloadClusterUnauthorized":
 		return "could not authenticate to the server"
+	case "LargeObjectApplyTimeout":
+		return "the control plane timed out storing a large resource"
 	case "UpdatePayloadRetrievalFailed":
 		return "could not download the update"
 
@@ -235,6 +334,8 @@ func SummaryForReason(reason, name string) string {
 		return "some cluster configuration is invalid"
 	case "UpdatePayloadIntegrity":
 		return "the contents of the update are invalid"
+	case "PayloadVersionMismatch":
+		return "the requested version does not match the release image"
 
 	case "ImageVerificationFailed":
 		return "the image may not be safe to use"
@@ -254,6 +355,11 @@ func SummaryForReason(reason, name string) string {
 		return "a cluster operator has not yet rolled out"
 	case "ClusterOperatorsNotAvailable":
 		return "some cluster operators have not yet rolled out"
+	case "ClusterOperatorStuck":
+		if len(name) > 0 {
+			return fmt.Sprintf("the cluster operator %s has stopped making progress", name)
+		}
+		return "a cluster operator has stopped making progress"
 	case "WorkloadNotAvailable":
 		if len(name) > 0 {
 			return fmt.Sprintf("the workload %s has not yet successfully rolled out", name)
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/openshift/library-go/pkg/manifest"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/klog/v2"
@@ -25,6 +26,68 @@ const (
 	groupComponent = 2
 )
 
+// TaskComponent returns the component name from a task's original filename of the form
+// 0000_NN_NAME_*, or an empty string if the filename does not follow that form.
+func TaskComponent(task *Task) string {
+	return ManifestComponent(task.Manifest)
+}
+
+// ManifestComponent returns the NAME of a manifest whose original filename is of the form
+// 0000_NN_NAME_*, or an empty string.
+func ManifestComponent(m *manifest.Manifest) string {
+	if match := reMatchPattern.FindStringSubmatch(m.OriginalFilename); match != nil {
+		return match[groupComponent]
+	}
+	return ""
+}
+
+// TaskRunLevel returns the run level NN from a task's original filename of the form
+// 0000_NN_NAME_*, or an empty string if the filename does not follow that form.
+func TaskRunLevel(task *Task) string {
+	return ManifestRunLevel(task.Manifest)
+}
+
+// runLevelPattern matches the run levels of release manifests, which are always two digits.
+var runLevelPattern = regexp.MustCompile(`^\d{2}$`)
+
+// IsRunLevel returns true if level is of the two-digit form TaskRunLevel returns for release
+// manifests, like 05.
+func IsRunLevel(level string) bool {
+	return runLevelPattern.MatchString(level)
+}
+
+// ManifestRunLevel returns the NN of a manifest whose original filename is of the form
+// 0000_NN_NAME_*, or an empty string.
+func ManifestRunLevel(m *manifest.Manifest) string {
+	if match := reMatchPattern.FindStringSubmatch(m.OriginalFilename); match != nil {
+		return match[groupNumber]
+	}
+	return ""
+}
+
+// ReverseRunLevels returns tasks with their run levels in reverse order, so that the run levels
+// applied last during an update are applied first during a rollback. Consecutive tasks that
+// share a run level are kept together in their original order, as are tasks whose filenames
+// are not of the form 0000_NN_NAME_*, which stay with the run level before them.
+func ReverseRunLevels(tasks []*Task) []*Task {
+	if len(tasks) == 0 {
+		return tasks
+	}
+	var groups [][]*Task
+	for i, task := range tasks {
+		level := TaskRunLevel(task)
+		if i == 0 || (len(level) > 0 && level != TaskRunLevel(groups[len(groups)-1][0])) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], task)
+	}
+	reversed := make([]*Task, 0, len(tasks))
+	for i := len(groups) - 1; i >= 0; i-- {
+		reversed = append(reversed, groups[i]...)
+	}
+	return reversed
+}
+
 // ByNumberAndComponent creates parallelization for tasks whose original filenames are of the form
 // 0000_NN_NAME_* - files that share 0000_NN_NAME_ are run in serial, but chunks of files that have
 // the same 0000_NN but different NAME can be run in parallel. If the input is not sorted in an order
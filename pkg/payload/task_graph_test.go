@@ -233,6 +233,49 @@ func TestByNumberAndComponent(t *testing.T) {
 	}
 }
 
+func TestReverseRunLevels(t *testing.T) {
+	tasks := func(names ...string) []*Task {
+		var arr []*Task
+		for _, name := range names {
+			arr = append(arr, &Task{Manifest: &manifest.Manifest{OriginalFilename: name}})
+		}
+		return arr
+	}
+	tests := []struct {
+		name  string
+		tasks []*Task
+		want  []*Task
+	}{
+		{
+			name:  "empty tasks",
+			tasks: tasks(),
+			want:  tasks(),
+		},
+		{
+			name:  "no recognizable run levels",
+			tasks: tasks("a", "b", "c"),
+			want:  tasks("a", "b", "c"),
+		},
+		{
+			name:  "run levels are reversed, their components are not",
+			tasks: tasks("0000_01_a_file1", "0000_01_b_file1", "0000_02_a_file1", "0000_03_a_file1", "0000_03_a_file2"),
+			want:  tasks("0000_03_a_file1", "0000_03_a_file2", "0000_02_a_file1", "0000_01_a_file1", "0000_01_b_file1"),
+		},
+		{
+			name:  "unrecognizable names stay with the run level before them",
+			tasks: tasks("a", "0000_01_a_file1", "b", "0000_02_a_file1"),
+			want:  tasks("0000_02_a_file1", "0000_01_a_file1", "b", "a"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReverseRunLevels(tt.tasks); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("%s", diff.ObjectReflectDiff(tt.want, got))
+			}
+		})
+	}
+}
+
 func TestShiftOrder(t *testing.T) {
 	tasks := func(names ...string) []*Task {
 		var arr []*Task
@@ -487,7 +530,7 @@ func Test_TaskGraph_real(t *testing.T) {
 	if len(path) == 0 {
 		t.Skip("TEST_GRAPH_PATH unset")
 	}
-	p, err := LoadUpdate(path, "arbitrary/image:1", "", DefaultClusterProfile)
+	p, err := LoadUpdate(path, "arbitrary/image:1", "", DefaultClusterProfile, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1,13 +1,19 @@
 package payload
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/openshift/library-go/pkg/manifest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 func TestTaskString(t *testing.T) {
@@ -167,3 +173,101 @@ func TestTaskString(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateErrorUnwrap(t *testing.T) {
+	nested := fmt.Errorf("nested failure")
+	uErr := &UpdateError{
+		Nested:  nested,
+		Reason:  "ClusterOperatorDegraded",
+		Message: "Cluster operator test-co is degraded",
+		Name:    "test-co",
+	}
+	wrapped := fmt.Errorf("wrapped: %w", uErr)
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{name: "matches nested error", err: wrapped, target: nested, want: true},
+		{name: "matches reason sentinel", err: wrapped, target: ErrClusterOperatorDegraded, want: true},
+		{name: "does not match other reason sentinel", err: wrapped, target: ErrClusterOperatorNotAvailable},
+		{name: "does not match empty reason", err: wrapped, target: &UpdateError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Fatalf("errors.Is() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	var found *UpdateError
+	if !errors.As(wrapped, &found) || found != uErr {
+		t.Fatalf("errors.As() did not return the wrapped update error: %v", found)
+	}
+}
+
+type errorBuilder struct {
+	err error
+}
+
+func (b *errorBuilder) Apply(_ context.Context, _ *manifest.Manifest, _ State) error {
+	return b.err
+}
+
+func TestTaskRunLargeObjectTimeout(t *testing.T) {
+	newManifest := func(size int) *manifest.Manifest {
+		return &manifest.Manifest{
+			Raw: make([]byte, size),
+			GVK: schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+			Obj: &unstructured.Unstructured{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "large"}}},
+		}
+	}
+	tests := []struct {
+		name       string
+		manifest   *manifest.Manifest
+		err        error
+		wantReason string
+	}{
+		{
+			name:       "large object timeout",
+			manifest:   newManifest(LargeManifestSize),
+			err:        apierrors.NewTimeoutError("request timed out", 1),
+			wantReason: "LargeObjectApplyTimeout",
+		},
+		{
+			name:       "large object deadline",
+			manifest:   newManifest(LargeManifestSize),
+			err:        fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			wantReason: "LargeObjectApplyTimeout",
+		},
+		{
+			name:       "large object other error",
+			manifest:   newManifest(LargeManifestSize),
+			err:        apierrors.NewConflict(schema.GroupResource{}, "large", fmt.Errorf("conflict")),
+			wantReason: "UpdatePayloadResourceConflict",
+		},
+		{
+			name:       "small object timeout",
+			manifest:   newManifest(10),
+			err:        apierrors.NewTimeoutError("request timed out", 1),
+			wantReason: "UpdatePayloadClusterDown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			task := &Task{Index: 1, Total: 1, Manifest: tt.manifest, Backoff: wait.Backoff{Duration: time.Millisecond, Factor: 1.5}}
+			err := task.Run(ctx, "1.0.0", &errorBuilder{err: tt.err}, UpdatingPayload)
+			var uErr *UpdateError
+			if !errors.As(err, &uErr) {
+				t.Fatalf("expected an update error, got %v", err)
+			}
+			if uErr.Reason != tt.wantReason {
+				t.Fatalf("unexpected reason %q, want %q", uErr.Reason, tt.wantReason)
+			}
+		})
+	}
+}
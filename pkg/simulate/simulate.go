@@ -0,0 +1,325 @@
+// Package simulate predicts how the cluster-version operator would walk a payload's task
+// graph against a snapshot of cluster objects, without writing to any cluster. It is
+// intended for client tooling such as oc plugins and support scripts that want to explain
+// what an update or reconcile would do and where it would wait.
+package simulate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/openshift/cluster-version-operator/lib/resourcebuilder"
+	"github.com/openshift/cluster-version-operator/lib/resourcemerge"
+	"github.com/openshift/cluster-version-operator/pkg/operatorversions"
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+// Action is the decision the sync worker would make for a manifest.
+type Action string
+
+const (
+	// ActionApply means the manifest would be applied and is not expected to block.
+	ActionApply Action = "Apply"
+	// ActionSkip means the manifest would not be applied because it is unmanaged.
+	ActionSkip Action = "Skip"
+	// ActionWait means the manifest would be applied, but the operator would wait for
+	// the object to become ready before continuing with this part of the graph.
+	ActionWait Action = "Wait"
+	// ActionBlocked means the manifest would not be reached because an earlier manifest
+	// in the graph is waiting.
+	ActionBlocked Action = "Blocked"
+)
+
+// Step describes the simulated handling of a single manifest.
+type Step struct {
+	// Node is the index of the task graph node containing the manifest.
+	Node int
+	// Task is the human readable description of the manifest used in operator logs.
+	Task string
+	// Manifest is the manifest from the payload.
+	Manifest *manifest.Manifest
+	// Action is the decision the operator would make.
+	Action Action
+	// Exists is true if the object is present in the snapshot.
+	Exists bool
+	// Message explains waits and skips.
+	Message string
+}
+
+// Options control how the payload is simulated.
+type Options struct {
+	// State is the mode the sync worker would apply the payload in.
+	State payload.State
+	// Overrides are the ClusterVersion spec.overrides in effect.
+	Overrides []configv1.ComponentOverride
+}
+
+// Snapshot is a read-only set of cluster objects, such as those collected by must-gather
+// or read from a live cluster.
+type Snapshot struct {
+	objects map[objectKey]*unstructured.Unstructured
+}
+
+type objectKey struct {
+	gk        schema.GroupKind
+	namespace string
+	name      string
+}
+
+// NewSnapshot returns a snapshot containing objects. List objects are expanded into
+// their items.
+func NewSnapshot(objects ...*unstructured.Unstructured) *Snapshot {
+	s := &Snapshot{objects: make(map[objectKey]*unstructured.Unstructured)}
+	for _, obj := range objects {
+		s.Add(obj)
+	}
+	return s
+}
+
+// LoadSnapshot reads every YAML and JSON file below dir into a snapshot.
+func LoadSnapshot(dir string) (*Snapshot, error) {
+	s := NewSnapshot()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		manifests, err := manifest.ParseManifests(strings.NewReader(string(data)))
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %v", path, err)
+		}
+		for i := range manifests {
+			s.Add(manifests[i].Obj)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add records obj in the snapshot, replacing any object with the same identity.
+func (s *Snapshot) Add(obj *unstructured.Unstructured) {
+	if obj == nil {
+		return
+	}
+	if obj.IsList() {
+		_ = obj.EachListItem(func(item runtime.Object) error {
+			if u, ok := item.(*unstructured.Unstructured); ok {
+				s.Add(u)
+			}
+			return nil
+		})
+		return
+	}
+	s.objects[keyFor(obj.GroupVersionKind(), obj.GetNamespace(), obj.GetName())] = obj
+}
+
+// Get returns the object with the given kind, namespace and name. Objects are matched by
+// group and kind so that a snapshot taken with a different preferred version still matches.
+func (s *Snapshot) Get(gvk schema.GroupVersionKind, namespace, name string) (*unstructured.Unstructured, bool) {
+	obj, ok := s.objects[keyFor(gvk, namespace, name)]
+	return obj, ok
+}
+
+func keyFor(gvk schema.GroupVersionKind, namespace, name string) objectKey {
+	return objectKey{gk: gvk.GroupKind(), namespace: namespace, name: name}
+}
+
+// Run walks the payload's task graph in the order the sync worker would use for the given
+// state and reports the decision for every manifest. Graph nodes whose prerequisites are
+// waiting are reported as blocked, as the operator would not start them. During reconcile
+// the operator randomizes the order of independent nodes; the simulation uses the
+// unpermuted order.
+func Run(update *payload.Update, snapshot *Snapshot, options Options) []Step {
+	total := len(update.Manifests)
+	var tasks []*payload.Task
+	for i := range update.Manifests {
+		tasks = append(tasks, &payload.Task{
+			Index:    i + 1,
+			Total:    total,
+			Manifest: &update.Manifests[i],
+		})
+	}
+	graph := payload.NewTaskGraph(tasks)
+	graph.Split(payload.SplitOnJobs)
+	switch options.State {
+	case payload.InitializingPayload, payload.ReconcilingPayload:
+		graph.Parallelize(payload.FlattenByNumberAndComponent)
+	default:
+		graph.Parallelize(payload.ByNumberAndComponent)
+	}
+
+	// visit nodes in the order RunGraph would select them with a single worker
+	var steps []Step
+	visited := make([]bool, len(graph.Nodes))
+	succeeded := make([]bool, len(graph.Nodes))
+	for {
+		next := -1
+		for i, node := range graph.Nodes {
+			if visited[i] {
+				continue
+			}
+			ready := true
+			for _, previous := range node.In {
+				if !visited[previous] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		visited[next] = true
+
+		node := graph.Nodes[next]
+		blocked := false
+		for _, previous := range node.In {
+			if !succeeded[previous] {
+				blocked = true
+				break
+			}
+		}
+		succeeded[next] = !blocked
+		for _, task := range node.Tasks {
+			step := Step{Node: next, Task: task.String(), Manifest: task.Manifest}
+			_, step.Exists = snapshot.Get(task.Manifest.GVK, task.Manifest.Obj.GetNamespace(), task.Manifest.Obj.GetName())
+			switch {
+			case !succeeded[next]:
+				// the node is abandoned at the first manifest that waits
+				step.Action = ActionBlocked
+			case isUnmanaged(options.Overrides, task.Manifest):
+				step.Action = ActionSkip
+				step.Message = "unmanaged by ClusterVersion overrides"
+			default:
+				if msg := pendingWait(task.Manifest, snapshot, options.State); len(msg) > 0 {
+					step.Action = ActionWait
+					step.Message = msg
+					succeeded[next] = false
+				} else {
+					step.Action = ActionApply
+				}
+			}
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+func isUnmanaged(overrides []configv1.ComponentOverride, m *manifest.Manifest) bool {
+	kind, namespace, name := m.GVK.Kind, m.Obj.GetNamespace(), m.Obj.GetName()
+	for _, ov := range overrides {
+		if ov.Kind == kind && (namespace == "" || ov.Namespace == namespace) && ov.Name == name {
+			return ov.Unmanaged
+		}
+	}
+	return false
+}
+
+// pendingWait returns a description of why the operator would wait after applying the
+// manifest, or an empty string if it would continue.
+func pendingWait(m *manifest.Manifest, snapshot *Snapshot, state payload.State) string {
+	actual, ok := snapshot.Get(m.GVK, m.Obj.GetNamespace(), m.Obj.GetName())
+
+	if m.GVK.GroupKind() == configv1.SchemeGroupVersion.WithKind("ClusterOperator").GroupKind() {
+		if !ok {
+			return fmt.Sprintf("cluster operator %s has not yet reported success", m.Obj.GetName())
+		}
+		return clusterOperatorWait(m, actual, state)
+	}
+
+	// other health checks are skipped while initializing
+	if state == payload.InitializingPayload {
+		return ""
+	}
+	if !ok {
+		if m.GVK.GroupKind() == (schema.GroupKind{Group: "batch", Kind: "Job"}) || resourcebuilder.HasWaitConditions(m.Obj) {
+			return fmt.Sprintf("%s would be created and must become ready", strings.ToLower(m.GVK.Kind))
+		}
+		return ""
+	}
+	switch m.GVK.GroupKind() {
+	case schema.GroupKind{Group: "batch", Kind: "Job"}:
+		if succeeded, _, _ := unstructured.NestedInt64(actual.Object, "status", "succeeded"); succeeded == 0 {
+			return fmt.Sprintf("job %s/%s has not succeeded", actual.GetNamespace(), actual.GetName())
+		}
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+		if conditionStatus(actual, "Available") == "False" && conditionStatus(actual, "Progressing") == "False" {
+			return fmt.Sprintf("deployment %s/%s is not available and not progressing", actual.GetNamespace(), actual.GetName())
+		}
+	}
+	if resourcebuilder.HasWaitConditions(m.Obj) {
+		if err := resourcebuilder.CheckWaitConditions(m.Obj, actual); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+func clusterOperatorWait(m *manifest.Manifest, actual *unstructured.Unstructured, state payload.State) string {
+	var expected, co configv1.ClusterOperator
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m.Obj.Object, &expected); err != nil {
+		return fmt.Sprintf("unable to read cluster operator manifest: %v", err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(actual.Object, &co); err != nil {
+		return fmt.Sprintf("unable to read cluster operator %s: %v", actual.GetName(), err)
+	}
+	if undone := operatorversions.Undone(expected.Status.Versions, co.Status.Versions); len(undone) > 0 {
+		return fmt.Sprintf("cluster operator %s is still updating", co.Name)
+	}
+	// mirror the ClusterOperator builder: operators that do not report Degraded=False
+	// are treated as degraded, and degraded is allowed while initializing
+	available := resourcemerge.IsOperatorStatusConditionTrue(co.Status.Conditions, configv1.OperatorAvailable)
+	progressing := !resourcemerge.IsOperatorStatusConditionFalse(co.Status.Conditions, configv1.OperatorProgressing)
+	degraded := !resourcemerge.IsOperatorStatusConditionFalse(co.Status.Conditions, configv1.OperatorDegraded)
+	if !available {
+		return fmt.Sprintf("cluster operator %s is not available", co.Name)
+	}
+	if progressing && len(expected.Status.Versions) == 0 {
+		return fmt.Sprintf("cluster operator %s is progressing", co.Name)
+	}
+	if degraded && state != payload.InitializingPayload {
+		if resourcemerge.IsOperatorStatusConditionTrue(co.Status.Conditions, configv1.OperatorDegraded) {
+			return fmt.Sprintf("cluster operator %s is degraded", co.Name)
+		}
+		return fmt.Sprintf("cluster operator %s is updating versions", co.Name)
+	}
+	return ""
+}
+
+func conditionStatus(obj *unstructured.Unstructured, conditionType string) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		if condition, ok := c.(map[string]interface{}); ok && condition["type"] == conditionType {
+			status, _ := condition["status"].(string)
+			return status
+		}
+	}
+	return ""
+}
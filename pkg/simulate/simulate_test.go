@@ -0,0 +1,133 @@
+package simulate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/manifest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/openshift/cluster-version-operator/pkg/payload"
+)
+
+func mustManifest(t *testing.T, filename, raw string) manifest.Manifest {
+	m := manifest.Manifest{}
+	if err := json.Unmarshal([]byte(raw), &m); err != nil {
+		t.Fatal(err)
+	}
+	m.OriginalFilename = filename
+	return m
+}
+
+func mustObject(t *testing.T, raw string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON([]byte(raw)); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
+
+func TestRun(t *testing.T) {
+	update := &payload.Update{
+		Manifests: []manifest.Manifest{
+			mustManifest(t, "0000_10_a_clusteroperator.yaml", `{
+				"apiVersion": "config.openshift.io/v1",
+				"kind": "ClusterOperator",
+				"metadata": {"name": "a"},
+				"status": {"versions": [{"name": "operator", "version": "1.0.1"}]}
+			}`),
+			mustManifest(t, "0000_20_b_configmap.yaml", `{
+				"apiVersion": "v1",
+				"kind": "ConfigMap",
+				"metadata": {"namespace": "b", "name": "config"}
+			}`),
+			mustManifest(t, "0000_20_b_job.yaml", `{
+				"apiVersion": "batch/v1",
+				"kind": "Job",
+				"metadata": {"namespace": "b", "name": "migrate"}
+			}`),
+		},
+	}
+	operatorAt := func(version string) *unstructured.Unstructured {
+		return mustObject(t, `{
+			"apiVersion": "config.openshift.io/v1",
+			"kind": "ClusterOperator",
+			"metadata": {"name": "a"},
+			"status": {
+				"versions": [{"name": "operator", "version": "`+version+`"}],
+				"conditions": [
+					{"type": "Available", "status": "True"},
+					{"type": "Degraded", "status": "False"}
+				]
+			}
+		}`)
+	}
+	job := mustObject(t, `{
+		"apiVersion": "batch/v1",
+		"kind": "Job",
+		"metadata": {"namespace": "b", "name": "migrate"},
+		"status": {"succeeded": 1}
+	}`)
+
+	tests := []struct {
+		name     string
+		snapshot *Snapshot
+		options  Options
+		want     []Action
+		messages []string
+	}{
+		{
+			name:     "operator still updating blocks later run levels",
+			snapshot: NewSnapshot(operatorAt("1.0.0")),
+			want:     []Action{ActionWait, ActionBlocked, ActionBlocked},
+			messages: []string{"cluster operator a is still updating", "", ""},
+		},
+		{
+			name:     "operator at version and job missing",
+			snapshot: NewSnapshot(operatorAt("1.0.1")),
+			want:     []Action{ActionApply, ActionApply, ActionWait},
+			messages: []string{"", "", "job would be created and must become ready"},
+		},
+		{
+			name:     "everything ready",
+			snapshot: NewSnapshot(operatorAt("1.0.1"), job),
+			want:     []Action{ActionApply, ActionApply, ActionApply},
+			messages: []string{"", "", ""},
+		},
+		{
+			name:     "unmanaged operator is skipped",
+			snapshot: NewSnapshot(job),
+			options: Options{
+				Overrides: []configv1.ComponentOverride{{Kind: "ClusterOperator", Name: "a", Unmanaged: true}},
+			},
+			want:     []Action{ActionSkip, ActionApply, ActionApply},
+			messages: []string{"unmanaged by ClusterVersion overrides", "", ""},
+		},
+		{
+			name:     "initializing ignores workload health",
+			snapshot: NewSnapshot(operatorAt("1.0.1")),
+			options:  Options{State: payload.InitializingPayload},
+			want:     []Action{ActionApply, ActionApply, ActionApply},
+			messages: []string{"", "", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := Run(update, tt.snapshot, tt.options)
+			var actions []Action
+			var messages []string
+			for _, step := range steps {
+				actions = append(actions, step.Action)
+				messages = append(messages, step.Message)
+			}
+			if !reflect.DeepEqual(actions, tt.want) {
+				t.Errorf("unexpected actions: %v != %v", actions, tt.want)
+			}
+			if !reflect.DeepEqual(messages, tt.messages) {
+				t.Errorf("unexpected messages: %q != %q", messages, tt.messages)
+			}
+		})
+	}
+}
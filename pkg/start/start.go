@@ -10,6 +10,10 @@ import (
 	"math/rand"
 	"os"
 	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -51,8 +55,26 @@ const (
 	leaseDuration = 30 * time.Second
 	renewDeadline = 15 * time.Second
 	retryPeriod   = 10 * time.Second
+
+	standbyVerificationInterval = 5 * time.Minute
+
+	// defaultSyncWorkerStallTimeout is well beyond the longest sync attempt, which is twice
+	// the minimum reconcile interval.
+	defaultSyncWorkerStallTimeout = 30 * time.Minute
+
+	// defaultSlowOperatorFactor is how many times its usual update duration a ClusterOperator
+	// may take before it is reported as slower than usual.
+	defaultSlowOperatorFactor = 2.0
+
+	// controllerWorkers is the number of workers for each controller queue.
+	controllerWorkers = 2
 )
 
+// runLevelPattern matches the two-digit run levels, like 05, that payload.TaskRunLevel
+// returns for release manifests, so that a run level like 5 is rejected rather than never
+// matching a manifest.
+var runLevelPattern = regexp.MustCompile(`^\d{2}$`)
+
 // Options are the valid inputs to starting the CVO.
 type Options struct {
 	ReleaseImage    string
@@ -73,6 +95,90 @@ type Options struct {
 
 	ClusterProfile string
 
+	// StatusWebhookURL, if set, receives a JSON document describing the
+	// sync status every time it changes.
+	StatusWebhookURL string
+
+	// EnableStandbyVerification makes a replica that is not the leader
+	// periodically verify the release manifests against the cluster.
+	EnableStandbyVerification bool
+
+	// EnableUpdateRehearsal makes the leader rehearse updates proposed in
+	// the cluster-version-rehearsal ConfigMap.
+	EnableUpdateRehearsal bool
+
+	// ToleratedClusterOperators are not required to be available and not
+	// degraded before an update begins.
+	ToleratedClusterOperators []string
+
+	// Preflight makes updates submit the release with server-side dry-run
+	// before any of it is applied.
+	Preflight bool
+
+	// ReleaseSignaturePrecondition makes updates check that the release
+	// image is signed by a trusted key before they begin.
+	ReleaseSignaturePrecondition bool
+
+	// PodDisruptionBudgetPrecondition makes updates check that no
+	// PodDisruptionBudget would block draining the control plane before
+	// they begin.
+	PodDisruptionBudgetPrecondition bool
+
+	// UpdateCheckpoints records the progress of updates in a ConfigMap, so
+	// that an operator restarted during an update resumes it without
+	// reapplying the manifests it already applied.
+	UpdateCheckpoints bool
+
+	// SyncWorkerStallTimeout is how long the sync worker may go without
+	// progress despite pending work before it is reported as stalled.
+	// Zero disables the watchdog.
+	SyncWorkerStallTimeout time.Duration
+
+	// RestartStalledSyncWorker cancels the sync attempt of a stalled sync
+	// worker, so that it starts over.
+	RestartStalledSyncWorker bool
+
+	// RunLevelKubeconfigs maps run levels, the NN of manifest filenames of
+	// the form 0000_NN_*, to kubeconfig files for alternate API endpoints
+	// that the manifests of that run level are applied through.
+	RunLevelKubeconfigs map[string]string
+
+	// OwnershipIdentity, if set, is stamped on every applied resource, and
+	// resources stamped with a different identity are not overwritten
+	// unless they allow a takeover.
+	OwnershipIdentity string
+
+	// ParallelClusterOperatorWaits makes updates wait for the ClusterOperators
+	// of each run level in parallel.
+	ParallelClusterOperatorWaits bool
+
+	// SlowOperatorFactor, if positive, reports ClusterOperators that have
+	// been updating for more than this many times their usual duration.
+	SlowOperatorFactor float64
+
+	// ClusterOperatorWaitTimeout, if positive, is how long updates wait for
+	// a ClusterOperator before they are reported as failing.
+	ClusterOperatorWaitTimeout time.Duration
+
+	// ClusterOperatorStuckTimeout, if positive, is how long a ClusterOperator
+	// may go without changing its versions or conditions before it is
+	// reported as stuck.
+	ClusterOperatorStuckTimeout time.Duration
+
+	// ClusterOperatorWaitEvents selects the objects the events of
+	// ClusterOperator waits are recorded on: ClusterVersion,
+	// ClusterOperator or OperatorNamespace.
+	ClusterOperatorWaitEvents string
+
+	// RollbackTriggers are update failures, as REASON[@RUNLEVEL]=DURATION,
+	// that roll the cluster back to the release it updated from once they
+	// have persisted for that long.
+	RollbackTriggers []string
+
+	// DisabledCapabilities are capabilities whose manifests, named by the
+	// capability.openshift.io/name annotation, are not applied.
+	DisabledCapabilities []string
+
 	// for testing only
 	Name            string
 	Namespace       string
@@ -107,6 +213,11 @@ func NewOptions() *Options {
 		ResyncInterval:  minResyncPeriod,
 		Exclude:         os.Getenv("EXCLUDE_MANIFESTS"),
 		ClusterProfile:  defaultEnv("CLUSTER_PROFILE", payload.DefaultClusterProfile),
+
+		SyncWorkerStallTimeout: defaultSyncWorkerStallTimeout,
+		SlowOperatorFactor:     defaultSlowOperatorFactor,
+
+		ClusterOperatorWaitEvents: "ClusterVersion",
 	}
 }
 
@@ -130,11 +241,26 @@ func (o *Options) Run(ctx context.Context) error {
 		klog.Infof("Excluding manifests for %q", o.Exclude)
 	}
 
+	for level := range o.RunLevelKubeconfigs {
+		if !runLevelPattern.MatchString(level) {
+			return fmt.Errorf("--run-level-kubeconfig run level %q must be two digits, like 05", level)
+		}
+	}
+
+	rollbackTriggers, err := cvo.ParseRollbackTriggers(o.RollbackTriggers)
+	if err != nil {
+		return fmt.Errorf("--rollback-trigger: %v", err)
+	}
+
 	// initialize the core objects
 	cb, err := newClientBuilder(o.Kubeconfig)
 	if err != nil {
 		return fmt.Errorf("error creating clients: %v", err)
 	}
+	endpoints, err := o.runLevelEndpoints()
+	if err != nil {
+		return err
+	}
 	lock, err := createResourceLock(cb, o.Namespace, o.Name)
 	if err != nil {
 		return err
@@ -142,6 +268,13 @@ func (o *Options) Run(ctx context.Context) error {
 
 	// initialize the controllers and attempt to load the payload information
 	controllerCtx := o.NewControllerContext(cb)
+	controllerCtx.CVO.SetRunLevelEndpoints(endpoints)
+	if err := controllerCtx.CVO.RouteClusterOperatorWaitEvents(o.ClusterOperatorWaitEvents); err != nil {
+		return fmt.Errorf("--cluster-operator-wait-events: %v", err)
+	}
+	if len(rollbackTriggers) > 0 {
+		controllerCtx.CVO.EnableRollback(rollbackTriggers)
+	}
 	if err := controllerCtx.CVO.InitializeFromPayload(cb.RestConfig(defaultQPS), cb.RestConfig(highQPS)); err != nil {
 		return err
 	}
@@ -150,6 +283,27 @@ func (o *Options) Run(ctx context.Context) error {
 	return nil
 }
 
+// runLevelEndpoints loads the alternate API endpoints for run levels, with the same client
+// rate limits as the cluster the operator runs against.
+func (o *Options) runLevelEndpoints() (map[string]cvo.RunLevelEndpoint, error) {
+	if len(o.RunLevelKubeconfigs) == 0 {
+		return nil, nil
+	}
+	endpoints := make(map[string]cvo.RunLevelEndpoint, len(o.RunLevelKubeconfigs))
+	for level, kubeconfig := range o.RunLevelKubeconfigs {
+		cb, err := newClientBuilder(kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("error creating clients for run level %s: %v", level, err)
+		}
+		klog.Infof("Applying manifests for run level %s through %s", level, cb.config.Host)
+		endpoints[level] = cvo.RunLevelEndpoint{
+			Config:      cb.RestConfig(defaultQPS),
+			BurstConfig: cb.RestConfig(highQPS),
+		}
+	}
+	return endpoints, nil
+}
+
 func (o *Options) makeTLSConfig() (*tls.Config, error) {
 	// Load the initial certificate contents.
 	certBytes, err := ioutil.ReadFile(o.ServingCertFile)
@@ -212,7 +366,7 @@ func (o *Options) run(ctx context.Context, controllerCtx *Context, lock *resourc
 		resultChannelCount++
 		go func() {
 			defer utilruntime.HandleCrash()
-			err := cvo.RunMetrics(postMainContext, shutdownContext, o.ListenAddr, tlsConfig)
+			err := cvo.RunMetrics(postMainContext, shutdownContext, o.ListenAddr, tlsConfig, controllerCtx.CVO.RuntimeConfigHandler(o.flags()), controllerCtx.CVO.ProgressHandler())
 			resultChannel <- asyncResult{name: "metrics server", error: err}
 		}()
 	}
@@ -224,6 +378,16 @@ func (o *Options) run(ctx context.Context, controllerCtx *Context, lock *resourc
 	controllerCtx.OpenshiftConfigManagedInformerFactory.Start(informersDone)
 	controllerCtx.InformerFactory.Start(informersDone)
 
+	// verify the release while waiting to lead, stopping once this replica leads
+	standbyContext, standbyCancel := context.WithCancel(runContext)
+	defer standbyCancel()
+	if o.EnableStandbyVerification {
+		go func() {
+			defer utilruntime.HandleCrash()
+			controllerCtx.CVO.RunStandbyVerification(standbyContext, standbyVerificationInterval)
+		}()
+	}
+
 	resultChannelCount++
 	go func() {
 		defer utilruntime.HandleCrash()
@@ -236,10 +400,11 @@ func (o *Options) run(ctx context.Context, controllerCtx *Context, lock *resourc
 			Callbacks: leaderelection.LeaderCallbacks{
 				OnStartedLeading: func(_ context.Context) { // no need for this passed-through postMainContext, because goroutines we launch inside will use runContext
 					launchedMain = true
+					standbyCancel()
 					resultChannelCount++
 					go func() {
 						defer utilruntime.HandleCrash()
-						err := controllerCtx.CVO.Run(runContext, shutdownContext, 2)
+						err := controllerCtx.CVO.Run(runContext, shutdownContext, controllerWorkers)
 						resultChannel <- asyncResult{name: "main operator", error: err}
 					}()
 
@@ -247,7 +412,7 @@ func (o *Options) run(ctx context.Context, controllerCtx *Context, lock *resourc
 						resultChannelCount++
 						go func() {
 							defer utilruntime.HandleCrash()
-							err := controllerCtx.AutoUpdate.Run(runContext, 2)
+							err := controllerCtx.AutoUpdate.Run(runContext, controllerWorkers)
 							resultChannel <- asyncResult{name: "auto-update controller", error: err}
 						}()
 					}
@@ -306,6 +471,56 @@ func (o *Options) run(ctx context.Context, controllerCtx *Context, lock *resourc
 	klog.Info("Finished collecting operator goroutines.")
 }
 
+// flags returns the options the operator was started with, for publishing its runtime
+// configuration. URLs are redacted, as they may embed credentials.
+func (o *Options) flags() map[string]string {
+	return map[string]string{
+		"enable-auto-update":                 strconv.FormatBool(o.EnableAutoUpdate),
+		"enable-default-cluster-version":     strconv.FormatBool(o.EnableDefaultClusterVersion),
+		"enable-standby-verification":        strconv.FormatBool(o.EnableStandbyVerification),
+		"enable-update-rehearsal":            strconv.FormatBool(o.EnableUpdateRehearsal),
+		"listen":                             o.ListenAddr,
+		"release-image":                      o.ReleaseImage,
+		"serving-cert-file":                  o.ServingCertFile,
+		"serving-key-file":                   o.ServingKeyFile,
+		"status-webhook-url":                 cvo.RedactURL(o.StatusWebhookURL),
+		"exclude":                            o.Exclude,
+		"cluster-profile":                    o.ClusterProfile,
+		"disabled-capabilities":              strings.Join(o.DisabledCapabilities, ","),
+		"cluster-operator-stuck-timeout":     o.ClusterOperatorStuckTimeout.String(),
+		"cluster-operator-wait-events":       o.ClusterOperatorWaitEvents,
+		"cluster-operator-wait-timeout":      o.ClusterOperatorWaitTimeout.String(),
+		"ownership-identity":                 o.OwnershipIdentity,
+		"parallel-cluster-operator-waits":    strconv.FormatBool(o.ParallelClusterOperatorWaits),
+		"payload-override":                   o.PayloadOverride,
+		"pod-disruption-budget-precondition": strconv.FormatBool(o.PodDisruptionBudgetPrecondition),
+		"preflight":                          strconv.FormatBool(o.Preflight),
+		"release-signature-precondition":     strconv.FormatBool(o.ReleaseSignaturePrecondition),
+		"resync-interval":                    o.ResyncInterval.String(),
+		"restart-stalled-sync-worker":        strconv.FormatBool(o.RestartStalledSyncWorker),
+		"rollback-trigger":                   strings.Join(o.RollbackTriggers, ","),
+		"run-level-kubeconfig":               formatRunLevelKubeconfigs(o.RunLevelKubeconfigs),
+		"slow-operator-factor":               strconv.FormatFloat(o.SlowOperatorFactor, 'f', -1, 64),
+		"sync-worker-stall-timeout":          o.SyncWorkerStallTimeout.String(),
+		"tolerated-cluster-operators":        strings.Join(o.ToleratedClusterOperators, ","),
+		"update-checkpoints":                 strconv.FormatBool(o.UpdateCheckpoints),
+	}
+}
+
+// formatRunLevelKubeconfigs formats run level kubeconfigs as the flag accepts them, ordered
+// by run level.
+func formatRunLevelKubeconfigs(kubeconfigs map[string]string) string {
+	levels := make([]string, 0, len(kubeconfigs))
+	for level := range kubeconfigs {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	for i, level := range levels {
+		levels[i] = fmt.Sprintf("%s=%s", level, kubeconfigs[level])
+	}
+	return strings.Join(levels, ",")
+}
+
 // createResourceLock initializes the lock.
 func createResourceLock(cb *ClientBuilder, namespace, name string) (*resourcelock.ConfigMapLock, error) {
 	client := cb.KubeClientOrDie("leader-election")
@@ -446,8 +661,45 @@ func (o *Options) NewControllerContext(cb *ClientBuilder) *Context {
 			cb.KubeClientOrDie(o.Namespace, useProtobuf),
 			o.Exclude,
 			o.ClusterProfile,
+			o.StatusWebhookURL,
 		),
 	}
+	if o.ParallelClusterOperatorWaits {
+		ctx.CVO.EnableParallelClusterOperatorWaits()
+	}
+	if len(o.ToleratedClusterOperators) > 0 {
+		ctx.CVO.TolerateClusterOperators(o.ToleratedClusterOperators)
+	}
+	if len(o.DisabledCapabilities) > 0 {
+		ctx.CVO.DisableCapabilities(o.DisabledCapabilities)
+	}
+	if o.Preflight {
+		ctx.CVO.EnablePreflight()
+	}
+	if o.ReleaseSignaturePrecondition {
+		ctx.CVO.EnableReleaseSignaturePrecondition()
+	}
+	if o.PodDisruptionBudgetPrecondition {
+		ctx.CVO.EnablePodDisruptionBudgetPrecondition()
+	}
+	if o.UpdateCheckpoints {
+		ctx.CVO.EnableUpdateCheckpoints()
+	}
+	if o.EnableUpdateRehearsal {
+		ctx.CVO.EnableUpdateRehearsal()
+	}
+	if o.SlowOperatorFactor > 0 {
+		ctx.CVO.EnableSlowOperatorDetection(o.SlowOperatorFactor)
+	}
+	if o.ClusterOperatorWaitTimeout > 0 || o.ClusterOperatorStuckTimeout > 0 {
+		ctx.CVO.SetClusterOperatorWaitLimits(o.ClusterOperatorWaitTimeout, o.ClusterOperatorStuckTimeout)
+	}
+	if len(o.OwnershipIdentity) > 0 {
+		ctx.CVO.EnableOwnership(o.OwnershipIdentity)
+	}
+	if o.SyncWorkerStallTimeout > 0 {
+		ctx.CVO.EnableSyncWorkerWatchdog(o.SyncWorkerStallTimeout, o.RestartStalledSyncWorker)
+	}
 	if o.EnableAutoUpdate {
 		ctx.AutoUpdate = autoupdate.New(
 			o.Namespace, o.Name,